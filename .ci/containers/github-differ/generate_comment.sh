@@ -30,6 +30,43 @@ TFC_LOCAL_PATH=$PWD/../tfc
 
 DIFFS=""
 NEWLINE=$'\n'
+DETECTOR_NOTES=""
+DETECTOR_FAILED=""
+
+# Comparisons needing symbols that providers generated before them don't
+# export, built in only when both providers export them
+OPTIONAL_DETECTOR_TAGS="framework provider_env_vars"
+
+# set_detector_identities sets DETECTOR_IDENTITIES to the flags comparing
+# the resource_identities.json exported at the roots of the old provider $1
+# and the new provider $2, noting when either doesn't export it for the
+# downstream named $3
+set_detector_identities() {
+    if [ -f "$1/resource_identities.json" ] && [ -f "$2/resource_identities.json" ]; then
+        DETECTOR_IDENTITIES="-oldResourceIdentities=$(realpath $1/resource_identities.json) -newResourceIdentities=$(realpath $2/resource_identities.json)"
+    else
+        DETECTOR_IDENTITIES=""
+        DETECTOR_NOTES="${DETECTOR_NOTES}* $3: resource ID and import format changes weren't compared, as the old or new provider doesn't export \`resource_identities.json\`${NEWLINE}"
+    fi
+}
+
+# set_detector_tags sets DETECTOR_TAGS to the optional tags the breaking
+# change detector builds with against the current providers, noting the
+# comparisons skipped for the downstream named $1. Other build failures
+# keep the tag, so running the detector fails loudly.
+set_detector_tags() {
+    DETECTOR_TAGS=""
+    for tag in $OPTIONAL_DETECTOR_TAGS; do
+        if output="$(go build -tags "$DETECTOR_TAGS $tag" -o /dev/null . 2>&1)"; then
+            DETECTOR_TAGS="$DETECTOR_TAGS $tag"
+        elif grep -qE "undefined: (old|new)Provider\." <<< "$output"; then
+            echo "breaking-change-detector skipping the $tag comparison for $1:${NEWLINE}$output" >&2
+            DETECTOR_NOTES="${DETECTOR_NOTES}* $1: the \`$tag\` comparison was skipped, as the old or new provider doesn't export the symbols it needs${NEWLINE}"
+        else
+            DETECTOR_TAGS="$DETECTOR_TAGS $tag"
+        fi
+    done
+}
 
 # TPG difference
 mkdir -p $TPG_LOCAL_PATH
@@ -57,15 +94,23 @@ git checkout origin/$OLD_BRANCH
 popd
 set +e
 pushd $MM_LOCAL_PATH/tools/breaking-change-detector
-sed -i.bak -E "s~google/provider/(.*)/([0-9A-Za-z-]*)~google/provider/\1/google~" comparison.go
+sed -i.bak -E "s~google/provider/(.*)/([0-9A-Za-z-]*)~google/provider/\1/google~" comparison.go framework_providers.go provider_env_vars.go
 go mod edit -replace google/provider/new=$(realpath $TPG_LOCAL_PATH)
 go mod edit -replace google/provider/old=$(realpath $TPG_LOCAL_PATH_OLD)
 go mod tidy
-export TPG_BREAKING="$(go run .)"
+set_detector_tags "Terraform GA"
+set_detector_identities $TPG_LOCAL_PATH_OLD $TPG_LOCAL_PATH "Terraform GA"
+TPG_REPORT="$(go run -tags "$DETECTOR_TAGS" . $DETECTOR_IDENTITIES)"
 retVal=$?
 if [ $retVal -ne 0 ]; then
-    export TPG_BREAKING=""
+    echo "breaking-change-detector failed for TPG with exit code $retVal" >&2
+    DETECTOR_NOTES="${DETECTOR_NOTES}* Terraform GA: the breaking change detector failed with exit code $retVal, so breaking changes couldn't be detected${NEWLINE}"
+    DETECTOR_FAILED="true"
+    TPG_REPORT=""
 fi
+# the breakages are followed by the suppressed ones after a blank line
+export TPG_BREAKING="$(awk 'NF == 0 { exit } { print }' <<< "$TPG_REPORT")"
+TPG_SUPPRESSIONS="$(awk 'suppressions { print } NF == 0 { suppressions = 1 }' <<< "$TPG_REPORT")"
 set -e
 popd
 
@@ -91,15 +136,23 @@ git checkout origin/$OLD_BRANCH
 popd
 set +e
 pushd $MM_LOCAL_PATH/tools/breaking-change-detector
-sed -i.bak -E "s~google/provider/(.*)/([0-9A-Za-z-]*)~google/provider/\1/google-beta~" comparison.go
+sed -i.bak -E "s~google/provider/(.*)/([0-9A-Za-z-]*)~google/provider/\1/google-beta~" comparison.go framework_providers.go provider_env_vars.go
 go mod edit -replace google/provider/new=$(realpath $TPGB_LOCAL_PATH)
 go mod edit -replace google/provider/old=$(realpath $TPGB_LOCAL_PATH_OLD)
 go mod tidy
-export TPGB_BREAKING="$(go run .)"
+set_detector_tags "Terraform Beta"
+set_detector_identities $TPGB_LOCAL_PATH_OLD $TPGB_LOCAL_PATH "Terraform Beta"
+TPGB_REPORT="$(go run -tags "$DETECTOR_TAGS" . $DETECTOR_IDENTITIES)"
 retVal=$?
 if [ $retVal -ne 0 ]; then
-    export TPGB_BREAKING=""
+    echo "breaking-change-detector failed for TPGB with exit code $retVal" >&2
+    DETECTOR_NOTES="${DETECTOR_NOTES}* Terraform Beta: the breaking change detector failed with exit code $retVal, so breaking changes couldn't be detected${NEWLINE}"
+    DETECTOR_FAILED="true"
+    TPGB_REPORT=""
 fi
+# the breakages are followed by the suppressed ones after a blank line
+export TPGB_BREAKING="$(awk 'NF == 0 { exit } { print }' <<< "$TPGB_REPORT")"
+TPGB_SUPPRESSIONS="$(awk 'suppressions { print } NF == 0 { suppressions = 1 }' <<< "$TPGB_REPORT")"
 BREAKINGCHANGES="$(/compare_breaking_changes.sh)"
 set -e
 popd
@@ -110,11 +163,18 @@ pushd $MM_LOCAL_PATH/tools/missing-test-detector
 go mod edit -replace google/provider/new=$(realpath $TPGB_LOCAL_PATH)
 go mod edit -replace google/provider/old=$(realpath $TPGB_LOCAL_PATH_OLD)
 go mod tidy
-export MISSINGTESTS="$(go run . -provider-dir=$TPGB_LOCAL_PATH/google-beta)"
+# exit codes: 2 - missing tests were found, 3 - the analysis failed
+go build -o /tmp/missing-test-detector .
+MISSINGTESTS="$(/tmp/missing-test-detector -provider-dir=$TPGB_LOCAL_PATH/google-beta)"
 retVal=$?
-if [ $retVal -ne 0 ]; then
-    export MISSINGTESTS=""
+if [ $retVal -eq 3 ]; then
+    echo "missing-test-detector analysis failed" >&2
+    MISSINGTESTS="${MISSINGTESTS}${NEWLINE}${NEWLINE}The missing test analysis failed for some files, so tests may be missing beyond those listed."
+elif [ $retVal -ne 0 ] && [ $retVal -ne 2 ]; then
+    echo "missing-test-detector failed with exit code $retVal" >&2
+    MISSINGTESTS=""
 fi
+export MISSINGTESTS
 set -e
 popd
 
@@ -174,6 +234,23 @@ if [ -n "$BREAKINGCHANGES" ]; then
   fi
 fi
 
+if [ -n "$DETECTOR_NOTES" ]; then
+  MESSAGE="${MESSAGE}## Breaking change detection${NEWLINE}${DETECTOR_NOTES}${NEWLINE}"
+fi
+if [ -n "$DETECTOR_FAILED" ]; then
+  BREAKINGSTATE="error"
+fi
+
+SUPPRESSIONS=""
+if [ -n "$TPG_SUPPRESSIONS" ]; then
+  SUPPRESSIONS="${SUPPRESSIONS}### Terraform GA${NEWLINE}${TPG_SUPPRESSIONS}${NEWLINE}"
+fi
+if [ -n "$TPGB_SUPPRESSIONS" ]; then
+  SUPPRESSIONS="${SUPPRESSIONS}### Terraform Beta${NEWLINE}${TPGB_SUPPRESSIONS}${NEWLINE}"
+fi
+if [ -n "$SUPPRESSIONS" ]; then
+  MESSAGE="${MESSAGE}## Breaking change suppressions${NEWLINE}${SUPPRESSIONS}${NEWLINE}"
+fi
 
 if [ -z "$DIFFS" ]; then
   MESSAGE="${MESSAGE}## Diff report ${NEWLINE}Your PR hasn't generated any diffs, but I'll let you know if a future commit does."
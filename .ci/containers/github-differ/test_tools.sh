@@ -11,7 +11,7 @@ github_username=modular-magician
 
 set +e
 pushd $MM_LOCAL_PATH/tools/breaking-change-detector
-go test ./...
+go test ./... && make vet-tags
 exit_code=$?
 popd
 set -e
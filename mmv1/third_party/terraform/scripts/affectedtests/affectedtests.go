@@ -1,40 +1,83 @@
-// affectedtests determines, for a given GitHub PR, which acceptance tests it affects.
+// affectedtests determines, for a range of commits such as a PR, which acceptance tests it affects.
 //
-// Example usage: git diff HEAD~ > tmp.diff && go run affectedtests.go -diff tmp.diff
+// Example usage: go run ./scripts/affectedtests -base origin/main -head my-branch
 //
-// It is also possible to get the diff from a PR: go run affectedtests.go -pr 2771
-// However, this mode only reads the changed files from the PR and does not (currently)
-// take into account new resources/tests that might have been added in this PR.
+// The diff is read from the local repository with the git CLI, so no network access or
+// GitHub token is needed. The head tree is checked out to a temporary worktree and analyzed,
+// so resources and tests added in the range are taken into account. Without -head, the
+// working tree is compared to its merge base with -base, including uncommitted and untracked
+// files.
 //
-// This script currently only works for changes to resources.
-// It is a TODO to make it work for changes to tests, data sources, and common utilities.
-// It also currently does not pick up tests that use configs from other files.
+// It is also possible to pass a diff, which is applied to the working tree:
+// git diff HEAD~ > tmp.diff && go run ./scripts/affectedtests -diff tmp.diff
+//
+// The provider's packages are type checked to build a graph of which declarations refer
+// to which, across files. From the functions, types and variables changed by the diff,
+// it finds every resource and data source whose schema reaches them, and every TestAcc
+// function that reaches them or uses one of those resources in a config, even if the
+// config is defined in another file. Changes to tests, data sources and common utilities
+// are handled the same way as changes to resources. Changes to the provider's resource
+// maps, to the shared test harness used by most tests or to go.mod affect every test.
+// Changed files that aren't Go files are reported, but not analyzed.
+//
+// Use -format json to get the affected resources and tests along with a regex for
+// go test -run, or -format regex to get just the regex.
 
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
-	"go/ast"
-	"go/parser"
-	"go/token"
+	"go/types"
 	"io/ioutil"
 	"log"
-	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 )
 
+// affectedTests is the JSON output of the script.
+type affectedTests struct {
+	// Changed lists the declarations changed by the diff
+	Changed     []string `json:"changed"`
+	Resources   []string `json:"resources"`
+	DataSources []string `json:"data_sources"`
+	Tests       []string `json:"tests"`
+	// Run is a regex matching exactly Tests, for go test -run
+	Run string `json:"run"`
+	// FullSuite is set when the diff affects every test, such as changes to
+	// the provider's registries or the shared test harness. Tests then lists
+	// every acceptance test.
+	FullSuite       bool   `json:"full_suite"`
+	FullSuiteReason string `json:"full_suite_reason,omitempty"`
+	// Unanalyzed lists the changed files that aren't Go files, such as test
+	// fixtures, which may affect tests without being found
+	Unanalyzed []string `json:"unanalyzed"`
+}
+
 func main() {
 	diff := flag.String("diff", "", "file containing git diff to use when determining changed files")
-	pr := flag.Uint("pr", 0, "PR # to use to determine changed files")
+	base := flag.String("base", "", "git ref to compare against, such as the target branch of a PR")
+	head := flag.String("head", "", "git ref with the changes, such as the branch of a PR. Defaults to the working tree")
+	format := flag.String("format", "list", "output format: list (one test per line), json, or regex (for go test -run)")
 	flag.Parse()
-	if (*pr == 0 && *diff == "") || (*pr != 0 && *diff != "") {
-		fmt.Println("Exactly one of -pr and -diff must be set")
+	if (*base == "" && *diff == "") || (*base != "" && *diff != "") {
+		fmt.Println("Exactly one of -base and -diff must be set")
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *head != "" && *base == "" {
+		fmt.Println("-head requires -base")
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *format != "list" && *format != "json" && *format != "regex" {
+		fmt.Println("-format must be one of list, json or regex")
 		flag.Usage()
 		os.Exit(1)
 	}
@@ -50,214 +93,223 @@ func main() {
 	if tpgDir == "/" {
 		log.Fatal("Script was run outside of google provider directory")
 	}
-	repo := strings.TrimPrefix(filepath.Base(tpgDir), "terraform-provider-")
-	googleDir := tpgDir + "/" + repo
 
-	providerFiles, err := readProviderFiles(googleDir)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	var diffVal string
+	var result affectedTests
 	if *diff == "" {
-		diffVal, err = getDiffFromPR(*pr, repo)
+		r, err := newGitRange(tpgDir, *base, *head)
 		if err != nil {
 			log.Fatal(err)
 		}
-	} else {
-		d, err := ioutil.ReadFile(*diff)
+		diffVal, err := r.diff()
 		if err != nil {
 			log.Fatal(err)
 		}
-		diffVal = string(d)
-	}
-
-	tests := map[string]struct{}{}
-	for _, r := range getChangedResourcesFromDiff(diffVal, repo) {
-		rn, err := getResourceName(r, googleDir, providerFiles)
+		rel, err := filepath.Rel(r.root, tpgDir)
 		if err != nil {
 			log.Fatal(err)
 		}
-		if rn == "" {
-			log.Fatalf("Could not find resource represented by %s", r)
+		headDir, cleanup, err := r.checkout()
+		if err != nil {
+			log.Fatal(err)
 		}
-		log.Printf("File %s matches resource %s", r, rn)
-		ts, err := getTestsAffectedBy(rn, googleDir)
+		result, err = findAffectedTests(diffVal, headDir, filepath.Join(headDir, rel))
+		cleanup()
 		if err != nil {
 			log.Fatal(err)
 		}
-		for _, t := range ts {
-			tests[t] = struct{}{}
+	} else {
+		d, err := ioutil.ReadFile(*diff)
+		if err != nil {
+			log.Fatal(err)
 		}
-	}
-	testnames := []string{}
-	for tn := range tests {
-		testnames = append(testnames, tn)
-	}
-	sort.Strings(testnames)
-	for _, tn := range testnames {
-		fmt.Println(tn)
-	}
-}
-
-func readProviderFiles(googleDir string) ([]string, error) {
-	pfs := []string{}
-	dir, err := ioutil.ReadDir(googleDir)
-	if err != nil {
-		return nil, err
-	}
-	for _, f := range dir {
-		if strings.HasPrefix(f.Name(), "provider") {
-			p, err := ioutil.ReadFile(googleDir + "/" + f.Name())
-			if err != nil {
-				return nil, err
-			}
-			pfs = append(pfs, string(p))
+		result, err = findAffectedTests(string(d), tpgDir, tpgDir)
+		if err != nil {
+			log.Fatal(err)
 		}
 	}
-	return pfs, nil
-}
 
-func getDiffFromPR(pr uint, repo string) (string, error) {
-	resp, err := http.Get(fmt.Sprintf("https://github.com/hashicorp/terraform-provider-%s/pull/%d.diff", repo, pr))
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
+	if len(result.Unanalyzed) > 0 {
+		log.Printf("Warning: changes to files that aren't Go files weren't analyzed and may affect other tests: %s", strings.Join(result.Unanalyzed, ", "))
 	}
-	return string(body), nil
-}
 
-func getChangedResourcesFromDiff(diff, repo string) []string {
-	results := []string{}
-	for _, l := range strings.Split(diff, "\n") {
-		if strings.HasPrefix(l, "+++ b/") {
-			log.Println("Found addition: " + l)
-			fName := strings.TrimPrefix(l, "+++ b/"+repo+"/")
-			if strings.HasPrefix(fName, "resource_") && !strings.HasSuffix(fName, "_test.go") {
-				results = append(results, fName)
-			}
+	switch *format {
+	case "json":
+		b, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(b))
+	case "regex":
+		fmt.Println(result.Run)
+	default:
+		for _, tn := range result.Tests {
+			fmt.Println(tn)
 		}
 	}
-	log.Printf("PR contains resource files %v", results)
-	return results
 }
 
-func getResourceName(fName, googleDir string, providerFiles []string) (string, error) {
-	resourceFile, err := parser.ParseFile(token.NewFileSet(), googleDir+"/"+fName, nil, parser.AllErrors)
+// findAffectedTests returns the tests affected by diff, whose paths are
+// relative to diffRoot, in the provider in srcDir.
+func findAffectedTests(diff, diffRoot, srcDir string) (affectedTests, error) {
+	g, err := loadCallGraph(srcDir)
 	if err != nil {
-		return "", err
+		return affectedTests{}, err
 	}
-	// Loop through all the top-level objects in the resource file.
-	// One of them is the resource definition: something like ResourceComputeInstance()
-	for k := range resourceFile.Scope.Objects {
-		// Matches the line in the provider file where the resource is defined,
-		// e.g. "google_compute_instance":     ResourceComputeInstance()
-		re := regexp.MustCompile(`"(.*)":\s*` + k + `\(\)`)
 
-		// Check all the provider files to see if they have a line that matches
-		// that regexp. If so, return the resource name.
-		for _, pf := range providerFiles {
-			sm := re.FindStringSubmatch(pf)
-			if len(sm) > 1 {
-				log.Println("Full match is " + sm[0])
-				return sm[1], nil
-			}
+	result := affectedTests{Unanalyzed: []string{}}
+	var changed []types.Object
+	for _, f := range getChangedLinesFromDiff(diff) {
+		if base := filepath.Base(f.name); base == "go.mod" || base == "go.sum" {
+			result.FullSuiteReason = f.name + " changes the dependencies"
+			continue
+		}
+		if !strings.HasSuffix(f.name, ".go") {
+			log.Printf("Not analyzing changes to %s, which isn't a Go file", f.name)
+			result.Unanalyzed = append(result.Unanalyzed, f.name)
+			continue
 		}
+		objs := g.changedObjects(filepath.Join(diffRoot, f.name), f.lines)
+		log.Printf("File %s changes %d declarations", f.name, len(objs))
+		changed = append(changed, objs...)
 	}
 
-	return "", nil
-}
+	seen := make(map[types.Object]bool)
+	g.affected(changed, seen)
+	resources, dataSources := g.affectedResources(seen)
+	log.Printf("Affected resources: %v", resources)
+	log.Printf("Affected data sources: %v", dataSources)
+	g.affected(g.configsUsing(resources, dataSources), seen)
 
-func getTestsAffectedBy(rn, googleDir string) ([]string, error) {
-	lines, err := getLinesContainingResourceName(rn, googleDir)
-	if err != nil {
-		return nil, err
+	result.Resources = resources
+	result.DataSources = dataSources
+	result.Tests = acceptanceTests(seen)
+	result.Run = runRegex(result.Tests)
+	for _, obj := range changed {
+		result.Changed = append(result.Changed, objectName(obj))
 	}
+	result.Changed = dedupe(result.Changed)
 
-	results := []string{}
-	for _, line := range lines {
-		fset := token.NewFileSet()
-		p, err := parser.ParseFile(fset, line.file, nil, parser.AllErrors)
-		if err != nil {
-			return nil, err
-		}
-
-		// Find the top-level func containing this offset
-		def := findFuncContainingOffset(line.offset, fset, p)
-		if def == "" {
-			// We couldn't find the place in the file that contains this offset, just skip and move on
-			continue
-		}
-
-		// Go back through and find the test that calls the definition we just found
-		results = append(results, findTestsCallingFunc(p, def)...)
+	if result.FullSuiteReason == "" {
+		result.FullSuiteReason = g.fullSuiteReason(changed)
 	}
-	return results, nil
+	if result.FullSuiteReason != "" {
+		log.Printf("Every test is affected: %s", result.FullSuiteReason)
+		result.FullSuite = true
+		result.Tests = g.allAcceptanceTests()
+		result.Run = "^TestAcc"
+	}
+	return result, nil
 }
 
-func findFuncContainingOffset(offset int, fset *token.FileSet, p *ast.File) string {
-	for k, sc := range p.Scope.Objects {
-		d := sc.Decl.(ast.Node)
-		if fset.Position(d.Pos()).Offset < offset && offset < fset.Position(d.End()).Offset {
-			return k
-		}
+// runRegex returns a regex for go test -run matching exactly tests. It matches
+// no test if tests is empty, as an empty regex would match every test.
+func runRegex(tests []string) string {
+	if len(tests) == 0 {
+		return "^$"
 	}
-	return ""
+	quoted := make([]string, 0, len(tests))
+	for _, t := range tests {
+		quoted = append(quoted, regexp.QuoteMeta(t))
+	}
+	return "^(" + strings.Join(quoted, "|") + ")$"
 }
 
-func findTestsCallingFunc(p *ast.File, funcName string) []string {
-	results := []string{}
-	for objName, sc := range p.Scope.Objects {
-		if !strings.HasPrefix(objName, "Test") {
-			continue
-		}
-		d, ok := sc.Decl.(*ast.FuncDecl)
-		if !ok {
+// changedFile is a file changed by a diff, with the lines of the new version
+// of the file that were added, changed or next to removed lines.
+type changedFile struct {
+	name  string
+	lines []int
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -[0-9]+(?:,([0-9]+))? \+([0-9]+)(?:,([0-9]+))? @@`)
+
+var binaryFiles = regexp.MustCompile(`^Binary files (?:a/)?(.+) and (?:b/)?(.+) differ$`)
+
+// getChangedLinesFromDiff returns the files changed by a unified diff. The
+// lines of each hunk are counted from its header, so added and removed lines
+// that look like file headers, such as "++ x" and "-- x", are read as lines.
+// Deleted and binary files are returned without lines.
+func getChangedLinesFromDiff(diff string) []changedFile {
+	results := []changedFile{}
+	// current is the index in results of the file of the current hunk, if any
+	current := -1
+	// oldName is the name of the file before the change, from the "---" header
+	oldName := ""
+	deleted := false
+	// line is the next line of the new file, and oldLeft and newLeft the
+	// number of lines of the old and new file left in the current hunk
+	line, oldLeft, newLeft := 0, 0, 0
+	for _, l := range strings.Split(diff, "\n") {
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(l, "+"):
+				results[current].lines = append(results[current].lines, line)
+				line++
+				newLeft--
+			case strings.HasPrefix(l, "-"):
+				// Removed lines change the declaration they were removed from
+				if !deleted {
+					results[current].lines = append(results[current].lines, line)
+				}
+				oldLeft--
+			case strings.HasPrefix(l, "\\"):
+				// "\ No newline at end of file"
+			default:
+				// Context lines, which may have lost their leading space
+				line++
+				oldLeft--
+				newLeft--
+			}
 			continue
 		}
-		// Starting at each Test, see if there's a path to the func we just found.
-		ast.Inspect(d, func(n ast.Node) bool {
-			if n, ok := n.(*ast.Ident); ok {
-				if n.Name == funcName {
-					results = append(results, objName)
-				}
+
+		switch {
+		case strings.HasPrefix(l, "diff --git "):
+			current = -1
+			oldName = ""
+		case strings.HasPrefix(l, "--- "):
+			oldName = strings.TrimPrefix(l, "--- a/")
+		case strings.HasPrefix(l, "+++ "):
+			name := strings.TrimPrefix(l, "+++ b/")
+			deleted = l == "+++ /dev/null"
+			if deleted {
+				// Deleted files have no declarations left to change
+				name = oldName
+			}
+			log.Println("Found change: " + name)
+			results = append(results, changedFile{name: name})
+			current = len(results) - 1
+		case binaryFiles.MatchString(l):
+			m := binaryFiles.FindStringSubmatch(l)
+			name := m[2]
+			if name == "/dev/null" {
+				name = m[1]
 			}
-			return true
-		})
+			log.Println("Found change: " + name)
+			results = append(results, changedFile{name: name})
+			current = -1
+		case current != -1 && hunkHeader.MatchString(l):
+			m := hunkHeader.FindStringSubmatch(l)
+			oldLeft, newLeft = hunkLength(m[1]), hunkLength(m[3])
+			line, _ = strconv.Atoi(m[2])
+			if newLeft == 0 {
+				// Lines are removed after the given line
+				line++
+			}
+		}
+	}
+	for _, f := range results {
+		sort.Ints(f.lines)
 	}
 	return results
 }
 
-type location struct {
-	file   string
-	offset int
-}
-
-func getLinesContainingResourceName(rn, googleDir string) ([]location, error) {
-	results := []location{}
-	resDef := regexp.MustCompile(fmt.Sprintf(`resource "%s"`, rn))
-	dir, err := ioutil.ReadDir(googleDir)
-	if err != nil {
-		return nil, err
-	}
-	for _, f := range dir {
-		if f.IsDir() {
-			continue
-		}
-		fPath := googleDir + "/" + f.Name()
-		contents, err := ioutil.ReadFile(fPath)
-		if err != nil {
-			return nil, err
-		}
-		matches := resDef.FindAllIndex(contents, -1)
-		for _, loc := range matches {
-			// the full match is at contents[loc[0]:loc[1]], but we only need one value
-			results = append(results, location{fPath, loc[0]})
-		}
+// hunkLength returns the number of lines of a hunk header range, which is 1
+// if it's left out.
+func hunkLength(s string) int {
+	if s == "" {
+		return 1
 	}
-	return results, nil
+	n, _ := strconv.Atoi(s)
+	return n
 }
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetChangedLinesFromDiff(t *testing.T) {
+	cases := map[string]struct {
+		diff     string
+		expected []changedFile
+	}{
+		"modified file": {
+			diff: `diff --git a/google/utils.go b/google/utils.go
+index 1111111..2222222 100644
+--- a/google/utils.go
++++ b/google/utils.go
+@@ -10,3 +10,4 @@ func a() {
+ 	x := 1
+-	y := 2
++	y := 3
++	z := 4
+ 	return x
+@@ -30 +31 @@ func b() {
+-	return nil
++	return err
+`,
+			expected: []changedFile{
+				{name: "google/utils.go", lines: []int{11, 11, 12, 31, 31}},
+			},
+		},
+		"lines looking like headers": {
+			diff: `diff --git a/google/config.go b/google/config.go
+--- a/google/config.go
++++ b/google/config.go
+@@ -5,3 +5,3 @@
+ headers := []string{
+--- "removed",
++++ "added",
+ }
+diff --git a/google/other.go b/google/other.go
+--- a/google/other.go
++++ b/google/other.go
+@@ -1,2 +1,2 @@
+-- x
++++ y
+ z
+`,
+			expected: []changedFile{
+				{name: "google/config.go", lines: []int{6, 6}},
+				{name: "google/other.go", lines: []int{1, 1}},
+			},
+		},
+		"added file": {
+			diff: `diff --git a/google/new.go b/google/new.go
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/google/new.go
+@@ -0,0 +1,3 @@
++package google
++
++func New() {}
+`,
+			expected: []changedFile{
+				{name: "google/new.go", lines: []int{1, 2, 3}},
+			},
+		},
+		"deleted file": {
+			diff: `diff --git a/google/old.go b/google/old.go
+deleted file mode 100644
+index 4444444..0000000
+--- a/google/old.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package google
+-
+diff --git a/google/utils.go b/google/utils.go
+--- a/google/utils.go
++++ b/google/utils.go
+@@ -8,2 +7,0 @@ func a() {
+-	x := 1
+-	y := 2
+`,
+			expected: []changedFile{
+				{name: "google/old.go"},
+				{name: "google/utils.go", lines: []int{8, 8}},
+			},
+		},
+		"no newline at end of file": {
+			diff: `diff --git a/website/docs/index.md b/website/docs/index.md
+--- a/website/docs/index.md
++++ b/website/docs/index.md
+@@ -1 +1 @@
+-old
+\ No newline at end of file
++new
+\ No newline at end of file
+`,
+			expected: []changedFile{
+				{name: "website/docs/index.md", lines: []int{1, 1}},
+			},
+		},
+		"binary file": {
+			diff: `diff --git a/google/test-fixtures/a.png b/google/test-fixtures/a.png
+new file mode 100644
+index 0000000..5555555
+Binary files /dev/null and b/google/test-fixtures/a.png differ
+diff --git a/google/test-fixtures/b.png b/google/test-fixtures/b.png
+deleted file mode 100644
+index 6666666..0000000
+Binary files a/google/test-fixtures/b.png and /dev/null differ
+`,
+			expected: []changedFile{
+				{name: "google/test-fixtures/a.png"},
+				{name: "google/test-fixtures/b.png"},
+			},
+		},
+	}
+	for tn, tc := range cases {
+		if got := getChangedLinesFromDiff(tc.diff); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tn, tc.expected, got)
+		}
+	}
+}
+
+func TestRunRegex(t *testing.T) {
+	cases := map[string]struct {
+		tests    []string
+		expected string
+	}{
+		"none": {
+			tests:    []string{},
+			expected: "^$",
+		},
+		"several": {
+			tests:    []string{"TestAccFoo_basic", "TestAccFoo_update"},
+			expected: "^(TestAccFoo_basic|TestAccFoo_update)$",
+		},
+	}
+	for tn, tc := range cases {
+		if got := runRegex(tc.tests); got != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tn, tc.expected, got)
+		}
+	}
+}
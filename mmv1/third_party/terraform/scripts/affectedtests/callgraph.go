@@ -0,0 +1,549 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// callGraph records which package-level declarations of the provider refer to
+// which others, across all files and packages of the provider module.
+type callGraph struct {
+	fset  *token.FileSet
+	files map[string][]declRange
+	// referrers maps an object to the objects whose declarations refer to it
+	referrers map[types.Object][]types.Object
+	// refs maps an object to the objects its declaration refers to
+	refs map[types.Object][]types.Object
+	// resources maps the schema functions registered in the provider to the
+	// resource and data source names they're registered as
+	resources []registeredResource
+	// registries are the declarations registering resources, such as the
+	// provider's resource map. Every test reaches them through the provider,
+	// so tests are only affected by a resource through its configs instead,
+	// and changing a registry affects every test.
+	registries map[types.Object]bool
+	// harness is the shared test harness, such as the function running
+	// acceptance tests and the provider factories, which every test reaches
+	harness map[types.Object]bool
+	// literals are the string literals of every declaration, used to find
+	// the configs that use a resource
+	literals []declLiteral
+}
+
+// declRange is the lines of a top-level declaration and the objects it
+// declares.
+type declRange struct {
+	start, end int
+	objs       []types.Object
+}
+
+type declLiteral struct {
+	obj   types.Object
+	value string
+}
+
+// registeredResource is an entry of the provider's resource or data source
+// map, such as "google_compute_instance": ResourceComputeInstance().
+type registeredResource struct {
+	name       string
+	dataSource bool
+	refs       []types.Object
+}
+
+type localPackage struct {
+	path  string
+	name  string
+	files []*ast.File
+	pkg   *types.Package
+	info  *types.Info
+}
+
+// loadCallGraph parses and type checks the packages of the module in tpgDir.
+// Dependencies outside the module aren't loaded: references to them are left
+// unresolved, which is enough to resolve references between the provider's
+// own declarations without network access or a module cache.
+func loadCallGraph(tpgDir string) (*callGraph, error) {
+	modulePath, err := readModulePath(tpgDir)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &callGraph{
+		fset:       token.NewFileSet(),
+		files:      make(map[string][]declRange),
+		referrers:  make(map[types.Object][]types.Object),
+		refs:       make(map[types.Object][]types.Object),
+		registries: make(map[types.Object]bool),
+		harness:    make(map[types.Object]bool),
+	}
+
+	pkgs := make(map[string]*localPackage)
+	var order []*localPackage
+	err = filepath.Walk(tpgDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			name := info.Name()
+			if path != tpgDir && (strings.HasPrefix(name, ".") || name == "testdata" || name == "test-fixtures" || name == "scripts" || name == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		f, err := parser.ParseFile(g.fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(tpgDir, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		importPath := modulePath
+		if rel != "." {
+			importPath = modulePath + "/" + filepath.ToSlash(rel)
+		}
+		// External test packages are type checked separately from the
+		// package they test.
+		key := importPath
+		if strings.HasSuffix(f.Name.Name, "_test") {
+			key += "_test"
+		}
+		p, ok := pkgs[key]
+		if !ok {
+			p = &localPackage{path: importPath, name: f.Name.Name}
+			pkgs[key] = p
+			order = append(order, p)
+		}
+		p.files = append(p.files, f)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &localImporter{fset: g.fset, pkgs: pkgs, loading: make(map[string]bool), external: make(map[string]*types.Package)}
+	for _, p := range order {
+		imp.check(p)
+	}
+	for _, p := range order {
+		g.addPackage(p)
+	}
+	g.findHarness()
+	return g, nil
+}
+
+func readModulePath(tpgDir string) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(tpgDir, "go.mod"))
+	if err != nil {
+		return "", err
+	}
+	for _, l := range strings.Split(string(b), "\n") {
+		if strings.HasPrefix(l, "module ") {
+			return strings.TrimSpace(strings.TrimPrefix(l, "module ")), nil
+		}
+	}
+	return "", fmt.Errorf("no module path found in %s/go.mod", tpgDir)
+}
+
+// localImporter type checks the packages of the module from source, and
+// stands in empty packages for everything else.
+type localImporter struct {
+	fset     *token.FileSet
+	pkgs     map[string]*localPackage
+	loading  map[string]bool
+	external map[string]*types.Package
+}
+
+func (imp *localImporter) Import(path string) (*types.Package, error) {
+	if p, ok := imp.pkgs[path]; ok && !imp.loading[path] {
+		return imp.check(p), nil
+	}
+	if pkg, ok := imp.external[path]; ok {
+		return pkg, nil
+	}
+	pkg := types.NewPackage(path, guessPackageName(path))
+	pkg.MarkComplete()
+	imp.external[path] = pkg
+	return pkg, nil
+}
+
+func (imp *localImporter) check(p *localPackage) *types.Package {
+	if p.pkg != nil {
+		return p.pkg
+	}
+	imp.loading[p.path] = true
+	defer delete(imp.loading, p.path)
+
+	p.info = &types.Info{
+		Defs: make(map[*ast.Ident]types.Object),
+		Uses: make(map[*ast.Ident]types.Object),
+	}
+	errCount := 0
+	conf := types.Config{
+		Importer: imp,
+		// Unresolved references to other modules are expected
+		Error: func(error) { errCount++ },
+	}
+	p.pkg, _ = conf.Check(p.path, imp.fset, p.files, p.info)
+	log.Printf("Type checked %s (%d files, %d unresolved references)", p.path, len(p.files), errCount)
+	return p.pkg
+}
+
+var majorVersionSuffix = regexp.MustCompile(`^v[0-9]+$`)
+
+// guessPackageName guesses the name of a package from its import path, such
+// as compute for google.golang.org/api/compute/v1.
+func guessPackageName(path string) string {
+	elems := strings.Split(path, "/")
+	name := elems[len(elems)-1]
+	if majorVersionSuffix.MatchString(name) && len(elems) > 1 {
+		name = elems[len(elems)-2]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	if i := strings.IndexAny(name, ".-"); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+func (g *callGraph) addPackage(p *localPackage) {
+	for _, f := range p.files {
+		filename := g.fset.Position(f.Pos()).Filename
+		for _, decl := range f.Decls {
+			objs := declObjects(decl, p.info)
+			if len(objs) == 0 {
+				continue
+			}
+			g.files[filename] = append(g.files[filename], declRange{
+				start: g.fset.Position(decl.Pos()).Line,
+				end:   g.fset.Position(decl.End()).Line,
+				objs:  objs,
+			})
+
+			for _, obj := range objs {
+				g.addReferences(obj, decl, p.info)
+			}
+		}
+	}
+}
+
+// declObjects returns the package-level objects declared by decl.
+func declObjects(decl ast.Decl, info *types.Info) []types.Object {
+	var objs []types.Object
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if obj := info.Defs[d.Name]; obj != nil {
+			objs = append(objs, obj)
+		}
+	case *ast.GenDecl:
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.ValueSpec:
+				for _, n := range s.Names {
+					if obj := info.Defs[n]; obj != nil && n.Name != "_" {
+						objs = append(objs, obj)
+					}
+				}
+			case *ast.TypeSpec:
+				if obj := info.Defs[s.Name]; obj != nil {
+					objs = append(objs, obj)
+				}
+			}
+		}
+	}
+	return objs
+}
+
+// addReferences records the package-level objects, string literals and
+// registered resources in the declaration of obj.
+func (g *callGraph) addReferences(obj types.Object, decl ast.Decl, info *types.Info) {
+	ast.Inspect(decl, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Ident:
+			if used := info.Uses[n]; used != nil && used != obj && isPackageLevel(used) {
+				g.referrers[used] = append(g.referrers[used], obj)
+				g.refs[obj] = append(g.refs[obj], used)
+			}
+		case *ast.BasicLit:
+			if n.Kind == token.STRING {
+				if v, err := strconv.Unquote(n.Value); err == nil {
+					g.literals = append(g.literals, declLiteral{obj: obj, value: v})
+				}
+			}
+		case *ast.KeyValueExpr:
+			if r, ok := registeredResourceOf(n, info); ok {
+				g.resources = append(g.resources, r)
+				g.registries[obj] = true
+			}
+		}
+		return true
+	})
+}
+
+// isPackageLevel returns whether obj is a package-level object or a method of
+// the provider, rather than a local variable, field or import.
+func isPackageLevel(obj types.Object) bool {
+	if obj.Pkg() == nil {
+		return false
+	}
+	if _, ok := obj.(*types.PkgName); ok {
+		return false
+	}
+	if obj.Parent() == obj.Pkg().Scope() {
+		return true
+	}
+	if f, ok := obj.(*types.Func); ok {
+		if recv := f.Type().(*types.Signature).Recv(); recv != nil {
+			_, isInterface := recv.Type().Underlying().(*types.Interface)
+			return !isInterface
+		}
+	}
+	return false
+}
+
+// registeredResourceOf returns the resource registered by a map entry such as
+// "google_compute_instance": ResourceComputeInstance(). IAM resources are
+// registered with a shared function and per-resource arguments, so every
+// object the entry refers to is recorded.
+func registeredResourceOf(kv *ast.KeyValueExpr, info *types.Info) (registeredResource, bool) {
+	key, ok := kv.Key.(*ast.BasicLit)
+	if !ok || key.Kind != token.STRING {
+		return registeredResource{}, false
+	}
+	name, err := strconv.Unquote(key.Value)
+	if err != nil || !strings.HasPrefix(name, "google_") {
+		return registeredResource{}, false
+	}
+	call, ok := kv.Value.(*ast.CallExpr)
+	if !ok {
+		return registeredResource{}, false
+	}
+
+	r := registeredResource{name: name}
+	if fn, ok := call.Fun.(*ast.Ident); ok {
+		r.dataSource = strings.HasPrefix(fn.Name, "DataSource")
+	}
+	ast.Inspect(call, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			if obj := info.Uses[id]; obj != nil && isPackageLevel(obj) {
+				r.refs = append(r.refs, obj)
+			}
+		}
+		return true
+	})
+	return r, len(r.refs) > 0
+}
+
+// harnessShare is the share of the acceptance tests that must use a
+// declaration directly for it to be part of the shared test harness.
+const harnessShare = 0.5
+
+// findHarness finds the shared test harness: the declarations used directly
+// by at least harnessShare of the acceptance tests, and every declaration they
+// reach. The resources registered by the registries aren't part of it, as
+// every test reaches them through the provider.
+func (g *callGraph) findHarness() {
+	tests := 0
+	uses := make(map[types.Object]int)
+	for obj, refs := range g.refs {
+		if !isAcceptanceTest(obj) {
+			continue
+		}
+		tests++
+		counted := make(map[types.Object]bool)
+		for _, ref := range refs {
+			if !counted[ref] {
+				counted[ref] = true
+				uses[ref]++
+			}
+		}
+	}
+
+	registered := make(map[types.Object]bool)
+	for _, r := range g.resources {
+		for _, ref := range r.refs {
+			registered[ref] = true
+		}
+	}
+
+	var queue []types.Object
+	for obj, n := range uses {
+		if float64(n) >= harnessShare*float64(tests) && !isAcceptanceTest(obj) {
+			queue = append(queue, obj)
+		}
+	}
+	for len(queue) > 0 {
+		obj := queue[0]
+		queue = queue[1:]
+		if g.harness[obj] {
+			continue
+		}
+		g.harness[obj] = true
+		for _, ref := range g.refs[obj] {
+			if g.registries[obj] && registered[ref] {
+				continue
+			}
+			queue = append(queue, ref)
+		}
+	}
+}
+
+// fullSuiteReason returns why changing one of changed affects every test, or
+// an empty string if it doesn't. Every test reaches the registries and the
+// shared test harness, so they aren't followed by affected.
+func (g *callGraph) fullSuiteReason(changed []types.Object) string {
+	for _, obj := range changed {
+		if g.registries[obj] {
+			return objectName(obj) + " registers resources"
+		}
+		if g.harness[obj] {
+			return objectName(obj) + " is part of the shared test harness"
+		}
+	}
+	return ""
+}
+
+// changedObjects returns the objects declared in the changed lines of file.
+func (g *callGraph) changedObjects(file string, lines []int) []types.Object {
+	var objs []types.Object
+	for _, d := range g.files[file] {
+		for _, l := range lines {
+			if d.start <= l && l <= d.end {
+				objs = append(objs, d.objs...)
+				break
+			}
+		}
+	}
+	return objs
+}
+
+// affected adds every object that transitively refers to one of changed to
+// seen, without going through the registries.
+func (g *callGraph) affected(changed []types.Object, seen map[types.Object]bool) {
+	queue := append([]types.Object{}, changed...)
+	for len(queue) > 0 {
+		obj := queue[0]
+		queue = queue[1:]
+		if seen[obj] || g.registries[obj] {
+			continue
+		}
+		seen[obj] = true
+		queue = append(queue, g.referrers[obj]...)
+	}
+}
+
+// affectedResources returns the registered resources and data sources whose
+// registration refers to an affected object.
+func (g *callGraph) affectedResources(seen map[types.Object]bool) (resources, dataSources []string) {
+	for _, r := range g.resources {
+		for _, ref := range r.refs {
+			if !seen[ref] {
+				continue
+			}
+			if r.dataSource {
+				dataSources = append(dataSources, r.name)
+			} else {
+				resources = append(resources, r.name)
+			}
+			break
+		}
+	}
+	return dedupe(resources), dedupe(dataSources)
+}
+
+// configsUsing returns the declarations with a string literal declaring one
+// of resources or dataSources in HCL, such as the config functions of tests.
+func (g *callGraph) configsUsing(resources, dataSources []string) []types.Object {
+	var names []string
+	for _, r := range resources {
+		names = append(names, `resource\s+"`+regexp.QuoteMeta(r)+`"`)
+	}
+	for _, d := range dataSources {
+		names = append(names, `data\s+"`+regexp.QuoteMeta(d)+`"`)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	re := regexp.MustCompile(strings.Join(names, "|"))
+
+	var objs []types.Object
+	for _, l := range g.literals {
+		if re.MatchString(l.value) {
+			objs = append(objs, l.obj)
+		}
+	}
+	return objs
+}
+
+// acceptanceTests returns the names of the affected acceptance tests.
+func acceptanceTests(seen map[types.Object]bool) []string {
+	var tests []string
+	for obj := range seen {
+		if isAcceptanceTest(obj) {
+			tests = append(tests, obj.Name())
+		}
+	}
+	return dedupe(tests)
+}
+
+// allAcceptanceTests returns the names of every acceptance test.
+func (g *callGraph) allAcceptanceTests() []string {
+	var tests []string
+	for _, decls := range g.files {
+		for _, d := range decls {
+			for _, obj := range d.objs {
+				if isAcceptanceTest(obj) {
+					tests = append(tests, obj.Name())
+				}
+			}
+		}
+	}
+	return dedupe(tests)
+}
+
+func isAcceptanceTest(obj types.Object) bool {
+	fn, ok := obj.(*types.Func)
+	return ok && strings.HasPrefix(fn.Name(), "TestAcc") && fn.Type().(*types.Signature).Recv() == nil
+}
+
+func objectName(obj types.Object) string {
+	if f, ok := obj.(*types.Func); ok {
+		if recv := f.Type().(*types.Signature).Recv(); recv != nil {
+			t := recv.Type()
+			if p, ok := t.(*types.Pointer); ok {
+				t = p.Elem()
+			}
+			if n, ok := t.(*types.Named); ok {
+				return n.Obj().Name() + "." + f.Name()
+			}
+		}
+	}
+	return obj.Name()
+}
+
+func dedupe(s []string) []string {
+	set := make(map[string]bool)
+	results := []string{}
+	for _, v := range s {
+		if !set[v] {
+			set[v] = true
+			results = append(results, v)
+		}
+	}
+	sort.Strings(results)
+	return results
+}
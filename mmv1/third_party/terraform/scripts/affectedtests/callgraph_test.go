@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"go/types"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// testProviderDir is a fake provider with two resources and a data source.
+const testProviderDir = "testdata/provider"
+
+func loadTestCallGraph(t *testing.T) (*callGraph, string) {
+	t.Helper()
+	dir, err := filepath.Abs(testProviderDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := loadCallGraph(dir)
+	if err != nil {
+		t.Fatalf("error loading the call graph: %v", err)
+	}
+	return g, dir
+}
+
+// lineOf returns the line of the first occurrence of s in the file.
+func lineOf(t *testing.T, file, s string) int {
+	t.Helper()
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := strings.Index(string(b), s)
+	if i < 0 {
+		t.Fatalf("%q not found in %s", s, file)
+	}
+	return strings.Count(string(b)[:i], "\n") + 1
+}
+
+func objectNames(objs []types.Object) []string {
+	names := []string{}
+	for _, obj := range objs {
+		names = append(names, objectName(obj))
+	}
+	return dedupe(names)
+}
+
+func objectSetNames(objs map[types.Object]bool) []string {
+	var names []string
+	for obj := range objs {
+		names = append(names, objectName(obj))
+	}
+	return dedupe(names)
+}
+
+func TestLoadCallGraph(t *testing.T) {
+	g, _ := loadTestCallGraph(t)
+
+	var resources []string
+	for _, r := range g.resources {
+		resources = append(resources, fmt.Sprintf("%s %v %v", r.name, r.dataSource, objectNames(r.refs)))
+	}
+	sort.Strings(resources)
+	expected := []string{
+		"google_bar false [ResourceBar]",
+		"google_foo false [ResourceFoo]",
+		"google_foo true [DataSourceFoo]",
+	}
+	if !reflect.DeepEqual(resources, expected) {
+		t.Errorf("expected registered resources %v, got %v", expected, resources)
+	}
+
+	if got := objectSetNames(g.registries); !reflect.DeepEqual(got, []string{"Provider"}) {
+		t.Errorf("expected Provider to be the only registry, got %v", got)
+	}
+
+	referrers := make(map[string][]string)
+	for obj, refs := range g.referrers {
+		referrers[objectName(obj)] = objectNames(refs)
+	}
+	for name, expected := range map[string][]string{
+		"fooName":          {"TestFooName", "resourceFooCreate"},
+		"Config.fooUrl":    {"resourceFooCreate"},
+		"resourceFooRead":  {"DataSourceFoo", "ResourceFoo", "resourceFooCreate"},
+		"testAccFoo_basic": {"TestAccFoo_basic", "testAccDataSourceFoo_basic"},
+	} {
+		if !reflect.DeepEqual(referrers[name], expected) {
+			t.Errorf("expected %s to be referred to by %v, got %v", name, expected, referrers[name])
+		}
+	}
+
+	expected = []string{"Config", "Provider", "providerConfigure", "testAccPreCheck", "testAccProviders", "vcrTest"}
+	if got := objectSetNames(g.harness); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected the shared test harness to be %v, got %v", expected, got)
+	}
+}
+
+func TestChangedObjects(t *testing.T) {
+	g, dir := loadTestCallGraph(t)
+	resourceFoo := filepath.Join(dir, "google", "resource_foo.go")
+
+	cases := map[string]struct {
+		file     string
+		lines    []int
+		expected []string
+	}{
+		"function body": {
+			file:     resourceFoo,
+			lines:    []int{lineOf(t, resourceFoo, "d.SetId")},
+			expected: []string{"resourceFooCreate"},
+		},
+		"several declarations": {
+			file:     resourceFoo,
+			lines:    []int{lineOf(t, resourceFoo, "Create: resourceFooCreate"), lineOf(t, resourceFoo, "func resourceFooDelete")},
+			expected: []string{"ResourceFoo", "resourceFooDelete"},
+		},
+		"method": {
+			file:     filepath.Join(dir, "google", "provider.go"),
+			lines:    []int{lineOf(t, filepath.Join(dir, "google", "provider.go"), "return \"https://foo")},
+			expected: []string{"Config.fooUrl"},
+		},
+		"imports": {
+			file:     resourceFoo,
+			lines:    []int{lineOf(t, resourceFoo, "import")},
+			expected: []string{},
+		},
+		"unknown file": {
+			file:     filepath.Join(dir, "google", "missing.go"),
+			lines:    []int{1},
+			expected: []string{},
+		},
+	}
+	for tn, tc := range cases {
+		if got := objectNames(g.changedObjects(tc.file, tc.lines)); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tn, tc.expected, got)
+		}
+	}
+}
+
+// changeLineDiff returns a diff changing the given line of file.
+func changeLineDiff(t *testing.T, dir, file, s string) string {
+	line := lineOf(t, filepath.Join(dir, file), s)
+	return fmt.Sprintf("diff --git a/%[1]s b/%[1]s\n--- a/%[1]s\n+++ b/%[1]s\n@@ -%[2]d,1 +%[2]d,1 @@\n-old\n+new\n", file, line)
+}
+
+func TestFindAffectedTests(t *testing.T) {
+	_, dir := loadTestCallGraph(t)
+	allTests := []string{"TestAccBar_basic", "TestAccDataSourceFoo_basic", "TestAccFoo_basic"}
+
+	cases := map[string]struct {
+		diff       string
+		tests      []string
+		fullSuite  bool
+		unanalyzed []string
+	}{
+		"utility": {
+			diff:  changeLineDiff(t, dir, "google/utils.go", "strings.ToLower"),
+			tests: []string{"TestAccDataSourceFoo_basic", "TestAccFoo_basic"},
+		},
+		"resource": {
+			diff:  changeLineDiff(t, dir, "google/resource_bar.go", "d.SetId"),
+			tests: []string{"TestAccBar_basic"},
+		},
+		"method of the config": {
+			diff:  changeLineDiff(t, dir, "google/provider.go", "return \"https://foo"),
+			tests: []string{"TestAccDataSourceFoo_basic", "TestAccFoo_basic"},
+		},
+		"test": {
+			diff:  changeLineDiff(t, dir, "google/resource_bar_test.go", "name = \"tf-test-bar\""),
+			tests: []string{"TestAccBar_basic"},
+		},
+		"unit test": {
+			diff:  changeLineDiff(t, dir, "google/resource_foo_test.go", "fooName(\"Foo\")"),
+			tests: []string{},
+		},
+		"registry": {
+			diff:      changeLineDiff(t, dir, "google/provider.go", "\"google_bar\": ResourceBar()"),
+			tests:     allTests,
+			fullSuite: true,
+		},
+		"reached by the provider": {
+			diff:      changeLineDiff(t, dir, "google/provider.go", "return &Config{"),
+			tests:     allTests,
+			fullSuite: true,
+		},
+		"test harness": {
+			diff:      changeLineDiff(t, dir, "google/provider_test.go", "resource.Test(t, c)"),
+			tests:     allTests,
+			fullSuite: true,
+		},
+		"go.mod": {
+			diff:      changeLineDiff(t, dir, "go.mod", "go 1.18"),
+			tests:     allTests,
+			fullSuite: true,
+		},
+		"not a Go file": {
+			diff:       changeLineDiff(t, dir, "website/docs/r/foo.html.markdown", "Manages a foo."),
+			tests:      []string{},
+			unanalyzed: []string{"website/docs/r/foo.html.markdown"},
+		},
+	}
+	for tn, tc := range cases {
+		result, err := findAffectedTests(tc.diff, dir, dir)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tn, err)
+			continue
+		}
+		if !reflect.DeepEqual(result.Tests, tc.tests) {
+			t.Errorf("%s: expected tests %v, got %v", tn, tc.tests, result.Tests)
+		}
+		if result.FullSuite != tc.fullSuite {
+			t.Errorf("%s: expected full suite to be %v, got %v (%s)", tn, tc.fullSuite, result.FullSuite, result.FullSuiteReason)
+		}
+		if tc.fullSuite && result.Run != "^TestAcc" {
+			t.Errorf("%s: expected the full suite to be run, got %q", tn, result.Run)
+		}
+		if tc.unanalyzed == nil {
+			tc.unanalyzed = []string{}
+		}
+		if !reflect.DeepEqual(result.Unanalyzed, tc.unanalyzed) {
+			t.Errorf("%s: expected unanalyzed files %v, got %v", tn, tc.unanalyzed, result.Unanalyzed)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// gitRange is a range of commits of the local provider repository, read with
+// the git CLI so no network access or GitHub token is needed.
+type gitRange struct {
+	// root is the top-level directory of the repository
+	root string
+	base string
+	// head is empty for the working tree
+	head string
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+func newGitRange(dir, base, head string) (*gitRange, error) {
+	root, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	r := &gitRange{root: strings.TrimSpace(root), base: base, head: head}
+	for _, ref := range []string{base, head} {
+		if ref == "" {
+			continue
+		}
+		if _, err := git(r.root, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+			return nil, fmt.Errorf("unknown git ref %q", ref)
+		}
+	}
+	return r, nil
+}
+
+// diff returns the changes from the merge base of base and head to head, like
+// the diff of a PR. Added files are diffed against /dev/null, so all of their
+// lines are changed.
+func (r *gitRange) diff() (string, error) {
+	args := []string{"diff", "--no-color", "--no-ext-diff", "--no-renames"}
+	if r.head == "" {
+		mergeBase, err := git(r.root, "merge-base", r.base, "HEAD")
+		if err != nil {
+			return "", err
+		}
+		args = append(args, strings.TrimSpace(mergeBase))
+	} else {
+		args = append(args, r.base+"..."+r.head)
+	}
+	diff, err := git(r.root, args...)
+	if err != nil {
+		return "", err
+	}
+	if r.head == "" {
+		// git diff leaves out untracked files, which are new in the
+		// working tree
+		untracked, err := r.untrackedDiff()
+		if err != nil {
+			return "", err
+		}
+		diff += untracked
+	}
+	return diff, nil
+}
+
+// untrackedDiff returns a diff adding the untracked files of the working tree,
+// which aren't ignored, like git diff would if they were added.
+func (r *gitRange) untrackedDiff() (string, error) {
+	out, err := git(r.root, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return "", err
+	}
+	var diff strings.Builder
+	for _, f := range strings.Split(out, "\n") {
+		if f == "" {
+			continue
+		}
+		contents, err := ioutil.ReadFile(filepath.Join(r.root, f))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&diff, "diff --git a/%s b/%s\nnew file mode 100644\n--- /dev/null\n+++ b/%s\n", f, f, f)
+		if len(contents) == 0 {
+			continue
+		}
+		lines := strings.Split(strings.TrimSuffix(string(contents), "\n"), "\n")
+		fmt.Fprintf(&diff, "@@ -0,0 +1,%d @@\n", len(lines))
+		for _, l := range lines {
+			diff.WriteString("+" + l + "\n")
+		}
+	}
+	return diff.String(), nil
+}
+
+// checkout returns a directory with the tree of head, and a function removing
+// it. The working tree is used as is if head is empty.
+func (r *gitRange) checkout() (string, func(), error) {
+	if r.head == "" {
+		return r.root, func() {}, nil
+	}
+	dir, err := ioutil.TempDir("", "affectedtests")
+	if err != nil {
+		return "", nil, err
+	}
+	worktree := filepath.Join(dir, "head")
+	if _, err := git(r.root, "worktree", "add", "--detach", worktree, r.head); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+	cleanup := func() {
+		if _, err := git(r.root, "worktree", "remove", "--force", worktree); err != nil {
+			log.Printf("Unable to remove worktree %s: %s", worktree, err)
+		}
+		os.RemoveAll(dir)
+	}
+	return worktree, cleanup, nil
+}
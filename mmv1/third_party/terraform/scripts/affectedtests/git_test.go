@@ -0,0 +1,145 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// newTestRepo returns a git repository with a commit of files, and the working
+// tree changed to changes. Files with empty contents in changes are removed.
+func newTestRepo(t *testing.T, files, changes map[string]string) *gitRange {
+	t.Helper()
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	dir := t.TempDir()
+	write := func(files map[string]string) {
+		for name, contents := range files {
+			path := filepath.Join(dir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	write(files)
+	for _, args := range [][]string{{"init", "-q"}, {"add", "-A"}, {"commit", "-q", "-m", "base"}} {
+		if _, err := git(dir, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, contents := range changes {
+		if contents == "" {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil {
+				t.Fatal(err)
+			}
+			delete(changes, name)
+		}
+	}
+	write(changes)
+
+	r, err := newGitRange(dir, "HEAD", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestGitRangeDiff(t *testing.T) {
+	cases := map[string]struct {
+		files    map[string]string
+		changes  map[string]string
+		expected []changedFile
+	}{
+		"modified file": {
+			files: map[string]string{
+				"google/utils.go": "package google\n\nfunc a() {\n\treturn\n}\n",
+			},
+			changes: map[string]string{
+				"google/utils.go": "package google\n\nfunc a() {\n\t// -- comment\n\treturn\n}\n",
+			},
+			expected: []changedFile{
+				{name: "google/utils.go", lines: []int{4}},
+			},
+		},
+		"deleted file": {
+			files: map[string]string{
+				"google/old.go":   "package google\n",
+				"google/utils.go": "package google\n",
+			},
+			changes: map[string]string{
+				"google/old.go": "",
+			},
+			expected: []changedFile{
+				{name: "google/old.go"},
+			},
+		},
+		"untracked files": {
+			files: map[string]string{
+				".gitignore": "*.log\n",
+			},
+			changes: map[string]string{
+				"google/new.go":     "package google\n\n++ not a header\n",
+				"google/no_newline": "a\nb",
+				"google/empty.go":   "\n",
+				"test.log":          "ignored\n",
+			},
+			expected: []changedFile{
+				{name: "google/empty.go", lines: []int{1}},
+				{name: "google/new.go", lines: []int{1, 2, 3}},
+				{name: "google/no_newline", lines: []int{1, 2}},
+			},
+		},
+	}
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			r := newTestRepo(t, tc.files, tc.changes)
+			diff, err := r.diff()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := getChangedLinesFromDiff(diff); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %v, got %v\n%s", tc.expected, got, diff)
+			}
+		})
+	}
+}
+
+func TestUntrackedDiff(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"main.go": "package main\n"}, map[string]string{
+		"empty":      "\n",
+		"new/new.go": "package new\n-- x\n",
+	})
+	// Files can't be written empty by newTestRepo
+	if err := ioutil.WriteFile(filepath.Join(r.root, "empty"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := r.untrackedDiff()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `diff --git a/empty b/empty
+new file mode 100644
+--- /dev/null
++++ b/empty
+diff --git a/new/new.go b/new/new.go
+new file mode 100644
+--- /dev/null
++++ b/new/new.go
+@@ -0,0 +1,2 @@
++package new
++-- x
+`
+	if diff != expected {
+		t.Errorf("expected diff:\n%s\ngot:\n%s", expected, diff)
+	}
+}
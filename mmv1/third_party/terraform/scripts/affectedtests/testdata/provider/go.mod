@@ -0,0 +1,3 @@
+module example.com/terraform-provider-google
+
+go 1.18
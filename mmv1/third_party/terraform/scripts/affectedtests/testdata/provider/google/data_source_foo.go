@@ -0,0 +1,11 @@
+package google
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func DataSourceFoo() *schema.Resource {
+	return &schema.Resource{
+		Read: resourceFooRead,
+	}
+}
@@ -0,0 +1,30 @@
+package google
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func Provider() *schema.Provider {
+	return &schema.Provider{
+		ResourcesMap: map[string]*schema.Resource{
+			"google_foo": ResourceFoo(),
+			"google_bar": ResourceBar(),
+		},
+		DataSourcesMap: map[string]*schema.Resource{
+			"google_foo": DataSourceFoo(),
+		},
+		ConfigureFunc: providerConfigure,
+	}
+}
+
+func providerConfigure(d *schema.ResourceData) (interface{}, error) {
+	return &Config{Project: d.Get("project").(string)}, nil
+}
+
+type Config struct {
+	Project string
+}
+
+func (c *Config) fooUrl(name string) string {
+	return "https://foo.googleapis.com/v1/projects/" + c.Project + "/foos/" + name
+}
@@ -0,0 +1,19 @@
+package google
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+var testAccProviders = map[string]*schema.Provider{
+	"google": Provider(),
+}
+
+func vcrTest(t *testing.T, c resource.TestCase) {
+	resource.Test(t, c)
+}
+
+func testAccPreCheck(t *testing.T) {
+}
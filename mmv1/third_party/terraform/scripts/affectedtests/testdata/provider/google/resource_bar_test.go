@@ -0,0 +1,23 @@
+package google
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccBar_basic(t *testing.T) {
+	vcrTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "google_bar" "bar" {
+  name = "tf-test-bar"
+}
+`,
+			},
+		},
+	})
+}
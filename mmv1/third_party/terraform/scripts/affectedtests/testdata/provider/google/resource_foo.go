@@ -0,0 +1,27 @@
+package google
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func ResourceFoo() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceFooCreate,
+		Read:   resourceFooRead,
+		Delete: resourceFooDelete,
+	}
+}
+
+func resourceFooCreate(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	d.SetId(config.fooUrl(fooName(d.Get("name").(string))))
+	return resourceFooRead(d, meta)
+}
+
+func resourceFooRead(d *schema.ResourceData, meta interface{}) error {
+	return nil
+}
+
+func resourceFooDelete(d *schema.ResourceData, meta interface{}) error {
+	return nil
+}
@@ -0,0 +1,53 @@
+package google
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestFooName(t *testing.T) {
+	if got := fooName("Foo"); got != "foo" {
+		t.Errorf("expected foo, got %s", got)
+	}
+}
+
+func TestAccFoo_basic(t *testing.T) {
+	vcrTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccFoo_basic(),
+			},
+		},
+	})
+}
+
+func TestAccDataSourceFoo_basic(t *testing.T) {
+	vcrTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceFoo_basic(),
+			},
+		},
+	})
+}
+
+func testAccFoo_basic() string {
+	return `
+resource "google_foo" "foo" {
+  name = "tf-test-foo"
+}
+`
+}
+
+func testAccDataSourceFoo_basic() string {
+	return testAccFoo_basic() + `
+data "google_foo" "foo" {
+  name = google_foo.foo.name
+}
+`
+}
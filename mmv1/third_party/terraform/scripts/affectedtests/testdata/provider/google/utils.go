@@ -0,0 +1,9 @@
+package google
+
+import (
+	"strings"
+)
+
+func fooName(name string) string {
+	return strings.ToLower(name)
+}
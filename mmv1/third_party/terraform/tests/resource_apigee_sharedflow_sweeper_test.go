@@ -35,6 +35,9 @@ func testSweepApigeeSharedFlow(region string) error {
 	resourceName := "ApigeeSharedFlow"
 	log.Printf("[INFO][SWEEPER_LOG] Starting sweeper for %s", resourceName)
 
+	sweep := NewSweepRun(resourceName, region)
+	defer sweep.Finish()
+
 	config, err := SharedConfigForRegion(region)
 	if err != nil {
 		log.Printf("[INFO][SWEEPER_LOG] error getting shared config for region: %s", err)
@@ -83,8 +86,6 @@ func testSweepApigeeSharedFlow(region string) error {
 	rl := resourceList.([]interface{})
 
 	log.Printf("[INFO][SWEEPER_LOG] Found %d items in %s list response.", len(rl), resourceName)
-	// Keep count of items that aren't sweepable for logging.
-	nonPrefixCount := 0
 	for _, ri := range rl {
 		obj := ri.(map[string]interface{})
 		var name string
@@ -98,8 +99,7 @@ func testSweepApigeeSharedFlow(region string) error {
 			return nil
 		}
 		// Skip resources that shouldn't be sweeped
-		if !IsSweepableTestResource(name) {
-			nonPrefixCount++
+		if !sweep.ShouldDelete(name, SweeperObjectLabels(obj), SweeperObjectCreateTime(obj)) {
 			continue
 		}
 
@@ -115,14 +115,12 @@ func testSweepApigeeSharedFlow(region string) error {
 		_, err = SendRequest(config, "DELETE", config.Project, deleteUrl, config.UserAgent, nil)
 		if err != nil {
 			log.Printf("[INFO][SWEEPER_LOG] Error deleting for url %s : %s", deleteUrl, err)
+			sweep.RecordFailed(name, err)
 		} else {
 			log.Printf("[INFO][SWEEPER_LOG] Sent delete request for %s resource: %s", resourceName, name)
+			sweep.RecordDeleted(name)
 		}
 	}
 
-	if nonPrefixCount > 0 {
-		log.Printf("[INFO][SWEEPER_LOG] %d items were non-sweepable and skipped.", nonPrefixCount)
-	}
-
 	return nil
 }
@@ -22,6 +22,9 @@ func testSweepBigqueryReservation(region string) error {
 	resourceName := "BigqueryReservation"
 	log.Printf("[INFO][SWEEPER_LOG] Starting sweeper for %s", resourceName)
 
+	sweep := NewSweepRun(resourceName, region)
+	defer sweep.Finish()
+
 	config, err := SharedConfigForRegion(region)
 	if err != nil {
 		log.Printf("[INFO][SWEEPER_LOG] error getting shared config for region: %s", err)
@@ -49,8 +52,6 @@ func testSweepBigqueryReservation(region string) error {
 	rl := resourceList.([]interface{})
 
 	log.Printf("[INFO][SWEEPER_LOG] Found %d items in %s list response.", len(rl), resourceName)
-	// Count items that weren't sweeped.
-	nonPrefixCount := 0
 	for _, ri := range rl {
 		obj := ri.(map[string]interface{})
 		if obj["name"] == nil {
@@ -61,9 +62,8 @@ func testSweepBigqueryReservation(region string) error {
 		reservationName := obj["name"].(string)
 		reservationNameParts := strings.Split(reservationName, "/")
 		reservationShortName := reservationNameParts[len(reservationNameParts)-1]
-		// Increment count and skip if resource is not sweepable.
-		if !IsSweepableTestResource(reservationShortName) {
-			nonPrefixCount++
+		// Skip resources that shouldn't be sweeped
+		if !sweep.ShouldDelete(reservationShortName, SweeperObjectLabels(obj), SweeperObjectCreateTime(obj)) {
 			continue
 		}
 
@@ -74,15 +74,13 @@ func testSweepBigqueryReservation(region string) error {
 		_, err = SendRequest(config, "DELETE", config.Project, deleteUrl, config.UserAgent, nil)
 		if err != nil {
 			log.Printf("[INFO][SWEEPER_LOG] Error deleting for url %s : %s", deleteUrl, err)
+			sweep.RecordFailed(reservationShortName, err)
 		} else {
 			log.Printf("[INFO][SWEEPER_LOG] Sent delete request for %s resource: %s", resourceName, reservationShortName)
+			sweep.RecordDeleted(reservationShortName)
 		}
 	}
 
-	if nonPrefixCount > 0 {
-		log.Printf("[INFO][SWEEPER_LOG] %d items without tf-test prefix remain.", nonPrefixCount)
-	}
-
 	return nil
 }
 
@@ -22,6 +22,9 @@ func testSweepBigtableInstance(region string) error {
 	resourceName := "BigtableInstance"
 	log.Printf("[INFO][SWEEPER_LOG] Starting sweeper for %s", resourceName)
 
+	sweep := NewSweepRun(resourceName, region)
+	defer sweep.Finish()
+
 	config, err := SharedConfigForRegion(region)
 	if err != nil {
 		log.Printf("[INFO][SWEEPER_LOG] error getting shared config for region: %s", err)
@@ -49,8 +52,6 @@ func testSweepBigtableInstance(region string) error {
 	rl := resourceList.([]interface{})
 
 	log.Printf("[INFO][SWEEPER_LOG] Found %d items in %s list response.", len(rl), resourceName)
-	// Count items that weren't sweeped.
-	nonPrefixCount := 0
 	for _, ri := range rl {
 		obj := ri.(map[string]interface{})
 		if obj["name"] == nil {
@@ -59,9 +60,8 @@ func testSweepBigtableInstance(region string) error {
 		}
 
 		id := obj["displayName"].(string)
-		// Increment count and skip if resource is not sweepable.
-		if !IsSweepableTestResource(id) {
-			nonPrefixCount++
+		// Skip resources that shouldn't be sweeped
+		if !sweep.ShouldDelete(id, SweeperObjectLabels(obj), SweeperObjectCreateTime(obj)) {
 			continue
 		}
 
@@ -70,14 +70,12 @@ func testSweepBigtableInstance(region string) error {
 		_, err = SendRequest(config, "DELETE", config.Project, deleteUrl, config.UserAgent, nil)
 		if err != nil {
 			log.Printf("[INFO][SWEEPER_LOG] Error deleting for url %s : %s", deleteUrl, err)
+			sweep.RecordFailed(id, err)
 		} else {
 			log.Printf("[INFO][SWEEPER_LOG] Sent delete request for %s resource: %s", resourceName, id)
+			sweep.RecordDeleted(id)
 		}
 	}
 
-	if nonPrefixCount > 0 {
-		log.Printf("[INFO][SWEEPER_LOG] %d items without tf-test prefix remain.", nonPrefixCount)
-	}
-
 	return nil
 }
@@ -20,6 +20,9 @@ func testSweepComputeInstanceTemplate(region string) error {
 	resourceName := "ComputeInstanceTemplate"
 	log.Printf("[INFO][SWEEPER_LOG] Starting sweeper for %s", resourceName)
 
+	sweep := NewSweepRun(resourceName, region)
+	defer sweep.Finish()
+
 	config, err := SharedConfigForRegion(region)
 	if err != nil {
 		log.Printf("[INFO][SWEEPER_LOG] error getting shared config for region: %s", err)
@@ -45,12 +48,13 @@ func testSweepComputeInstanceTemplate(region string) error {
 	}
 
 	log.Printf("[INFO][SWEEPER_LOG] Found %d items in %s list response.", numTemplates, resourceName)
-	// Count items that weren't sweeped.
-	nonPrefixCount := 0
 	for _, instanceTemplate := range instanceTemplates.Items {
-		// Increment count and skip if resource is not sweepable.
-		if !IsSweepableTestResource(instanceTemplate.Name) {
-			nonPrefixCount++
+		var labels map[string]string
+		if instanceTemplate.Properties != nil {
+			labels = instanceTemplate.Properties.Labels
+		}
+		// Skip resources that shouldn't be sweeped
+		if !sweep.ShouldDelete(instanceTemplate.Name, labels, SweeperParseTime(&instanceTemplate.CreationTimestamp)) {
 			continue
 		}
 
@@ -58,14 +62,12 @@ func testSweepComputeInstanceTemplate(region string) error {
 		_, err := config.NewComputeClient(config.UserAgent).InstanceTemplates.Delete(config.Project, instanceTemplate.Name).Do()
 		if err != nil {
 			log.Printf("[INFO][SWEEPER_LOG] Error deleting instance template: %s", instanceTemplate.Name)
+			sweep.RecordFailed(instanceTemplate.Name, err)
 		} else {
 			log.Printf("[INFO][SWEEPER_LOG] Sent delete request for %s resource: %s", resourceName, instanceTemplate.Name)
+			sweep.RecordDeleted(instanceTemplate.Name)
 		}
 	}
 
-	if nonPrefixCount > 0 {
-		log.Printf("[INFO][SWEEPER_LOG] %d items without tf-test prefix remain.", nonPrefixCount)
-	}
-
 	return nil
 }
@@ -28,6 +28,9 @@ func testSweepFirebaseAndroidApp(region string) error {
 	resourceName := "FirebaseAndroidApp"
 	log.Printf("[INFO][SWEEPER_LOG] Starting sweeper for %s", resourceName)
 
+	sweep := NewSweepRun(resourceName, region)
+	defer sweep.Finish()
+
 	config, err := SharedConfigForRegion(region)
 	if err != nil {
 		log.Printf("[INFO][SWEEPER_LOG] error getting shared config for region: %s", err)
@@ -76,8 +79,6 @@ func testSweepFirebaseAndroidApp(region string) error {
 	rl := resourceList.([]interface{})
 
 	log.Printf("[INFO][SWEEPER_LOG] Found %d items in %s list response.", len(rl), resourceName)
-	// Keep count of items that aren't sweepable for logging.
-	nonPrefixCount := 0
 	for _, ri := range rl {
 		obj := ri.(map[string]interface{})
 		if obj["displayName"] == nil {
@@ -86,8 +87,7 @@ func testSweepFirebaseAndroidApp(region string) error {
 		}
 
 		// Skip resources that shouldn't be sweeped
-		if !IsSweepableTestResource(obj["displayName"].(string)) {
-			nonPrefixCount++
+		if !sweep.ShouldDelete(obj["displayName"].(string), SweeperObjectLabels(obj), SweeperObjectCreateTime(obj)) {
 			continue
 		}
 
@@ -101,14 +101,12 @@ func testSweepFirebaseAndroidApp(region string) error {
 		_, err = SendRequest(config, "POST", config.Project, deleteUrl, config.UserAgent, body)
 		if err != nil {
 			log.Printf("[INFO][SWEEPER_LOG] Error deleting for url %s : %s", deleteUrl, err)
+			sweep.RecordFailed(name, err)
 		} else {
 			log.Printf("[INFO][SWEEPER_LOG] Sent delete request for %s resource: %s", resourceName, name)
+			sweep.RecordDeleted(name)
 		}
 	}
 
-	if nonPrefixCount > 0 {
-		log.Printf("[INFO][SWEEPER_LOG] %d items were non-sweepable and skipped.", nonPrefixCount)
-	}
-
 	return nil
 }
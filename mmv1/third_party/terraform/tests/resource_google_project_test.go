@@ -36,6 +36,9 @@ func init() {
 }
 
 func testSweepProject(region string) error {
+	sweep := NewSweepRun("GoogleProject", region)
+	defer sweep.Finish()
+
 	config, err := SharedConfigForRegion(region)
 	if err != nil {
 		log.Printf("[INFO][SWEEPER_LOG] error getting shared config for region: %s", err)
@@ -65,12 +68,17 @@ func testSweepProject(region string) error {
 		}
 
 		for _, project := range found.Projects {
+			if !sweep.ShouldDelete(project.ProjectId, project.Labels, SweeperParseTime(&project.CreateTime)) {
+				continue
+			}
 			log.Printf("[INFO][SWEEPER_LOG] Sweeping Project id: %s", project.ProjectId)
 			_, err := config.NewResourceManagerClient(config.UserAgent).Projects.Delete(project.ProjectId).Do()
 			if err != nil {
 				log.Printf("[INFO][SWEEPER_LOG] Error, failed to delete project %s: %s", project.Name, err)
+				sweep.RecordFailed(project.ProjectId, err)
 				continue
 			}
+			sweep.RecordDeleted(project.ProjectId)
 		}
 		token = found.NextPageToken
 		paginate = token != ""
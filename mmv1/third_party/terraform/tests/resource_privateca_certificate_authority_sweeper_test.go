@@ -21,6 +21,9 @@ func testSweepCertificateAuthority(region string) error {
 	resourceName := "CertificateAuthority"
 	log.Printf("[INFO][SWEEPER_LOG] Starting sweeper for %s", resourceName)
 
+	sweep := NewSweepRun(resourceName, region)
+	defer sweep.Finish()
+
 	config, err := SharedConfigForRegion(region)
 	if err != nil {
 		log.Printf("[INFO][SWEEPER_LOG] error getting shared config for region: %s", err)
@@ -61,8 +64,6 @@ func testSweepCertificateAuthority(region string) error {
 	rl := resourceList.([]interface{})
 
 	log.Printf("[INFO][SWEEPER_LOG] Found %d items in %s list response.", len(rl), resourceName)
-	// Count items that weren't sweeped.
-	nonPrefixCount := 0
 	for _, ri := range rl {
 		obj := ri.(map[string]interface{})
 
@@ -87,15 +88,14 @@ func testSweepCertificateAuthority(region string) error {
 			obj := cai.(map[string]interface{})
 			caName := obj["name"].(string)
 
-			// Increment count and skip if resource is not sweepable.
-			nameParts := strings.Split(caName, "/")
-			id := nameParts[len(nameParts)-1]
-			if !IsSweepableTestResource(id) {
-				nonPrefixCount++
+			if obj["state"] == "DELETED" {
 				continue
 			}
 
-			if obj["state"] == "DELETED" {
+			// Skip resources that shouldn't be sweeped
+			nameParts := strings.Split(caName, "/")
+			id := nameParts[len(nameParts)-1]
+			if !sweep.ShouldDelete(id, SweeperObjectLabels(obj), SweeperObjectCreateTime(obj)) {
 				continue
 			}
 
@@ -113,15 +113,13 @@ func testSweepCertificateAuthority(region string) error {
 			_, err = SendRequest(config, "DELETE", config.Project, deleteUrl, config.UserAgent, nil)
 			if err != nil {
 				log.Printf("[INFO][SWEEPER_LOG] Error deleting for url %s : %s", deleteUrl, err)
+				sweep.RecordFailed(caName, err)
 			} else {
 				log.Printf("[INFO][SWEEPER_LOG] Deleted a %s resource: %s", resourceName, caName)
+				sweep.RecordDeleted(caName)
 			}
 		}
 	}
 
-	if nonPrefixCount > 0 {
-		log.Printf("[INFO][SWEEPER_LOG] %d items without tf-test prefix remain.", nonPrefixCount)
-	}
-
 	return nil
 }
@@ -20,6 +20,9 @@ func testSweepSpannerInstance(region string) error {
 	resourceName := "SpannerInstance"
 	log.Printf("[INFO][SWEEPER_LOG] Starting sweeper for %s", resourceName)
 
+	sweep := NewSweepRun(resourceName, region)
+	defer sweep.Finish()
+
 	config, err := SharedConfigForRegion(region)
 	if err != nil {
 		log.Printf("[INFO][SWEEPER_LOG] error getting shared config for region: %s", err)
@@ -49,8 +52,6 @@ func testSweepSpannerInstance(region string) error {
 	rl := resourceList.([]interface{})
 
 	log.Printf("[INFO][SWEEPER_LOG] Found %d items in %s list response.", len(rl), resourceName)
-	// Count items that weren't sweeped.
-	nonPrefixCount := 0
 	for _, ri := range rl {
 		obj := ri.(map[string]interface{})
 		if obj["name"] == nil {
@@ -61,9 +62,8 @@ func testSweepSpannerInstance(region string) error {
 		name := obj["name"].(string)
 		shortName := name[strings.LastIndex(name, "/")+1:]
 
-		// Increment count and skip if resource is not sweepable.
-		if !IsSweepableTestResource(shortName) {
-			nonPrefixCount++
+		// Skip resources that shouldn't be sweeped
+		if !sweep.ShouldDelete(shortName, SweeperObjectLabels(obj), SweeperObjectCreateTime(obj)) {
 			continue
 		}
 
@@ -72,14 +72,12 @@ func testSweepSpannerInstance(region string) error {
 		_, err = SendRequest(config, "DELETE", config.Project, deleteUrl, config.UserAgent, nil)
 		if err != nil {
 			log.Printf("[INFO][SWEEPER_LOG] Error deleting for url %s : %s", deleteUrl, err)
+			sweep.RecordFailed(shortName, err)
 		} else {
 			log.Printf("[INFO][SWEEPER_LOG] Sent delete request for %s resource: %s", resourceName, shortName)
+			sweep.RecordDeleted(shortName)
 		}
 	}
 
-	if nonPrefixCount > 0 {
-		log.Printf("[INFO][SWEEPER_LOG] %d items without tf_test prefix remain.", nonPrefixCount)
-	}
-
 	return nil
 }
@@ -2,6 +2,7 @@ package transport
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -24,6 +25,60 @@ type RequestBatcher struct {
 	parentCtx context.Context
 	batches   map[string]*startedBatch
 	debugId   string
+	// service provides defaults for requests that don't set CombineF or SendF
+	service *BatchedService
+	// trace receives an event for every sent batch, see BatcherTraceFileEnvVar
+	trace *batchTrace
+}
+
+// BatchedService declares a service whose requests are grouped by a
+// dedicated RequestBatcher. Every provider configuration creates a batcher
+// for each service registered with RegisterBatchedService.
+type BatchedService struct {
+	// Name identifies the service batcher, and is used as its debugId.
+	Name string
+
+	// CombineF is used for requests to the service with a nil CombineF.
+	CombineF BatcherCombineFunc
+
+	// SendF is used for requests to the service with a nil SendF.
+	SendF BatcherSendFunc
+}
+
+var batchedServices = make(map[string]*BatchedService)
+
+// Services batched since before services could be registered. Their
+// requests set their own CombineF and SendF.
+var (
+	ServiceUsageBatchedService = RegisterBatchedService(&BatchedService{Name: "Service Usage"})
+	IamBatchedService          = RegisterBatchedService(&BatchedService{Name: "IAM"})
+)
+
+// RegisterBatchedService registers a service to get its own RequestBatcher.
+// It should be called during package initialization, e.g. in a package-level
+// var declaration, so the service is registered before the provider is
+// configured.
+func RegisterBatchedService(service *BatchedService) *BatchedService {
+	if service.Name == "" {
+		panic("cannot register a batched service without a name")
+	}
+	if _, ok := batchedServices[service.Name]; ok {
+		panic(fmt.Sprintf("batched service %q is already registered", service.Name))
+	}
+	batchedServices[service.Name] = service
+	return service
+}
+
+// NewRequestBatchers initializes a batcher for every registered service,
+// keyed by service name.
+func NewRequestBatchers(ctx context.Context, config *batchingConfig) map[string]*RequestBatcher {
+	batchers := make(map[string]*RequestBatcher, len(batchedServices))
+	for name, service := range batchedServices {
+		batcher := NewRequestBatcher(name, ctx, config)
+		batcher.service = service
+		batchers[name] = batcher
+	}
+	return batchers
 }
 
 // These types are meant to be the public interface to batchers. They define
@@ -62,6 +117,22 @@ type (
 	BatcherSendFunc func(resourceName string, body interface{}) (interface{}, error)
 )
 
+// BatchAppliedError is returned by a BatcherSendFunc when the batch was
+// applied but a later step failed, such as waiting for an operation. Resending
+// the requests in the batch separately would apply them a second time, so
+// they all fail with this error instead.
+type BatchAppliedError struct {
+	Err error
+}
+
+func (e *BatchAppliedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *BatchAppliedError) Unwrap() error {
+	return e.Err
+}
+
 // batchResponse bundles an API response (data, error) tuple.
 type batchResponse struct {
 	body interface{}
@@ -85,6 +156,13 @@ type startedBatch struct {
 	subscribers []batchSubscriber
 
 	timer *time.Timer
+
+	// idleTimer sends the batch once no request has joined it for
+	// SendAfterIdle, if set
+	idleTimer *time.Timer
+
+	// created is when the first request of the batch was registered
+	created time.Time
 }
 
 // batchSubscriber contains information required for a single request for a startedBatch.
@@ -100,8 +178,24 @@ type batchSubscriber struct {
 type batchingConfig struct {
 	SendAfter      time.Duration
 	EnableBatching bool
+
+	// MaxBatchSize is the number of requests after which a batch is sent
+	// without waiting. 0 doesn't limit batch size.
+	MaxBatchSize int
+
+	// SendAfterIdle enables adaptive batch windows: a batch is sent once no
+	// request has joined it for SendAfterIdle, or after SendAfter, whichever
+	// comes first. 0 always waits for SendAfter.
+	SendAfterIdle time.Duration
 }
 
+// Reasons a batch was sent, recorded in BatchEvent.Trigger
+const (
+	batchTriggerSendAfter    = "send_after"
+	batchTriggerIdle         = "send_after_idle"
+	batchTriggerMaxBatchSize = "max_batch_size"
+)
+
 // Initializes a new batcher.
 func NewRequestBatcher(debugId string, ctx context.Context, config *batchingConfig) *RequestBatcher {
 	batcher := &RequestBatcher{
@@ -109,6 +203,7 @@ func NewRequestBatcher(debugId string, ctx context.Context, config *batchingConf
 		parentCtx:      ctx,
 		batchingConfig: config,
 		batches:        make(map[string]*startedBatch),
+		trace:          batchTraceFromEnv(),
 	}
 
 	// Start goroutine to managing stopping the batcher if the provider-level parent context is closed.
@@ -130,7 +225,7 @@ func (b *RequestBatcher) stop() {
 	log.Printf("[DEBUG] Stopping batcher %q", b.debugId)
 	for batchKey, batch := range b.batches {
 		log.Printf("[DEBUG] Cancelling started batch for batchKey %q", batchKey)
-		batch.timer.Stop()
+		batch.stopTimers()
 		for _, l := range batch.subscribers {
 			close(l.respCh)
 		}
@@ -155,6 +250,16 @@ func (b *RequestBatcher) SendRequestWithTimeout(batchKey string, request *BatchR
 	if request == nil {
 		return nil, fmt.Errorf("error, cannot request batching for nil BatchRequest")
 	}
+	if b.service != nil && (request.CombineF == nil || request.SendF == nil) {
+		withDefaults := *request
+		if withDefaults.CombineF == nil {
+			withDefaults.CombineF = b.service.CombineF
+		}
+		if withDefaults.SendF == nil {
+			withDefaults.SendF = b.service.SendF
+		}
+		request = &withDefaults
+	}
 	if request.CombineF == nil {
 		return nil, fmt.Errorf("error, cannot request batching for BatchRequest with nil CombineF")
 	}
@@ -215,7 +320,19 @@ func (b *RequestBatcher) registerBatchRequest(batchKey string, newRequest *Batch
 
 	// If batch already exists, combine this request into existing request.
 	if batch, ok := b.batches[batchKey]; ok {
-		return batch.addRequest(newRequest)
+		respCh, err := batch.addRequest(newRequest)
+		if err != nil {
+			return nil, err
+		}
+		if b.MaxBatchSize > 0 && len(batch.subscribers) >= b.MaxBatchSize {
+			log.Printf("[DEBUG] Batch %q reached max batch size %d, sending now", batchKey, b.MaxBatchSize)
+			delete(b.batches, batchKey)
+			batch.stopTimers()
+			go b.sendBatchWithSingleRetry(batchKey, batch, batchTriggerMaxBatchSize)
+		} else if batch.idleTimer != nil {
+			batch.idleTimer.Reset(b.SendAfterIdle)
+		}
+		return respCh, nil
 	}
 
 	// Batch doesn't exist for given batch key - create a new batch.
@@ -230,7 +347,7 @@ func (b *RequestBatcher) registerBatchRequest(batchKey string, newRequest *Batch
 	}
 
 	// Create a new batch with copy of the given batch request.
-	b.batches[batchKey] = &startedBatch{
+	batch := &startedBatch{
 		BatchRequest: &BatchRequest{
 			ResourceName: newRequest.ResourceName,
 			Body:         newRequest.Body,
@@ -240,37 +357,79 @@ func (b *RequestBatcher) registerBatchRequest(batchKey string, newRequest *Batch
 		},
 		batchKey:    batchKey,
 		subscribers: []batchSubscriber{sub},
+		created:     time.Now(),
+	}
+
+	if b.MaxBatchSize == 1 {
+		go b.sendBatchWithSingleRetry(batchKey, batch, batchTriggerMaxBatchSize)
+		return respCh, nil
 	}
+	b.batches[batchKey] = batch
 
 	// Start a timer to send the request
-	b.batches[batchKey].timer = time.AfterFunc(b.SendAfter, func() {
-		batch := b.popBatch(batchKey)
-		if batch == nil {
-			log.Printf("[ERROR] batch should have been added to saved batches - just run as single request %q", newRequest.DebugId)
-			respCh <- newRequest.send()
-			close(respCh)
-		} else {
-			b.sendBatchWithSingleRetry(batchKey, batch)
-		}
+	batch.timer = time.AfterFunc(b.SendAfter, func() {
+		b.sendStartedBatch(batchKey, batch, batchTriggerSendAfter)
 	})
+	// In adaptive mode, also send the batch once no request has joined it for SendAfterIdle
+	if b.SendAfterIdle > 0 && b.SendAfterIdle < b.SendAfter {
+		batch.idleTimer = time.AfterFunc(b.SendAfterIdle, func() {
+			b.sendStartedBatch(batchKey, batch, batchTriggerIdle)
+		})
+	}
 
 	return respCh, nil
 }
 
-func (b *RequestBatcher) sendBatchWithSingleRetry(batchKey string, batch *startedBatch) {
-	log.Printf("[DEBUG] Sending batch %q combining %d requests)", batchKey, len(batch.subscribers))
+// sendStartedBatch sends a batch if it is still waiting to be sent, i.e. it
+// wasn't sent by another trigger already.
+func (b *RequestBatcher) sendStartedBatch(batchKey string, batch *startedBatch, trigger string) {
+	b.Lock()
+	if b.batches[batchKey] != batch {
+		b.Unlock()
+		log.Printf("[DEBUG] Batch %q was already sent, ignoring %s trigger", batchKey, trigger)
+		return
+	}
+	delete(b.batches, batchKey)
+	batch.stopTimers()
+	b.Unlock()
+
+	b.sendBatchWithSingleRetry(batchKey, batch, trigger)
+}
+
+func (b *RequestBatcher) sendBatchWithSingleRetry(batchKey string, batch *startedBatch, trigger string) {
+	log.Printf("[DEBUG] Sending batch %q combining %d requests after %s trigger", batchKey, len(batch.subscribers), trigger)
+	event := &BatchEvent{
+		BatchKey: batchKey,
+		Trigger:  trigger,
+		Requests: len(batch.subscribers),
+		WaitMs:   durationMs(time.Since(batch.created)),
+	}
+	start := time.Now()
+	defer func() {
+		event.SendLatencyMs = durationMs(time.Since(start))
+		b.recordBatchEvent(b.parentCtx, event)
+	}()
+
 	resp := batch.send()
+	if resp.IsError() {
+		event.Error = resp.err.Error()
+		event.RequestErrors = make(map[string]string)
+	}
 
-	// If the batch failed and combines more than one request, retry each single request.
-	if resp.IsError() && len(batch.subscribers) > 1 {
+	// If the batch failed and combines more than one request, retry each single
+	// request unless the batch was already applied.
+	var appliedErr *BatchAppliedError
+	if resp.IsError() && len(batch.subscribers) > 1 && !errors.As(resp.err, &appliedErr) {
 		log.Printf("[DEBUG] Batch failed with error: %v", resp.err)
 		log.Printf("[DEBUG] Sending each request in batch separately")
+		event.SingleRetryFallback = true
 		for _, sub := range batch.subscribers {
 			log.Printf("[DEBUG] Retrying single request %q", sub.singleRequest.DebugId)
 			singleResp := sub.singleRequest.send()
 			log.Printf("[DEBUG] Retried single request %q returned response: %v", sub.singleRequest.DebugId, singleResp)
 
 			if singleResp.IsError() {
+				event.RequestErrors[sub.singleRequest.DebugId] = singleResp.err.Error()
 				singleResp.err = errwrap.Wrapf(
 					fmt.Sprintf("Batch request and retried single request %q both failed. Final error: {{err}}", sub.singleRequest.DebugId),
 					singleResp.err)
@@ -281,26 +440,23 @@ func (b *RequestBatcher) sendBatchWithSingleRetry(batchKey string, batch *starte
 	} else {
 		// Send result to all subscribers
 		for _, sub := range batch.subscribers {
+			if resp.IsError() {
+				event.RequestErrors[sub.singleRequest.DebugId] = resp.err.Error()
+			}
 			sub.respCh <- resp
 			close(sub.respCh)
 		}
 	}
 }
 
-// popBatch safely gets and removes a batch with given batchkey from the
-// RequestBatcher's started batches.
-func (b *RequestBatcher) popBatch(batchKey string) *startedBatch {
-	b.Lock()
-	defer b.Unlock()
-
-	batch, ok := b.batches[batchKey]
-	if !ok {
-		log.Printf("[DEBUG] Batch with ID %q not found in batcher", batchKey)
-		return nil
+// stopTimers stops the timers sending the batch.
+func (batch *startedBatch) stopTimers() {
+	if batch.timer != nil {
+		batch.timer.Stop()
+	}
+	if batch.idleTimer != nil {
+		batch.idleTimer.Stop()
 	}
-
-	delete(b.batches, batchKey)
-	return batch
 }
 
 func (batch *startedBatch) addRequest(newRequest *BatchRequest) (<-chan batchResponse, error) {
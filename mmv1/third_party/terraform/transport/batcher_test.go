@@ -200,6 +200,61 @@ func TestRequestBatcher_errInSend(t *testing.T) {
 	wg.Wait()
 }
 
+func TestRequestBatcher_errAppliedInSend(t *testing.T) {
+	testBatcher := NewRequestBatcher(
+		"testBatcher",
+		context.Background(),
+		&batchingConfig{
+			SendAfter:      time.Duration(5) * time.Second,
+			EnableBatching: true,
+		})
+
+	testCombine := func(body interface{}, toAdd interface{}) (interface{}, error) {
+		return append(body.([]int), toAdd.([]int)...), nil
+	}
+
+	// The batch is applied but fails afterwards, so it must not be resent
+	var sendsMu sync.Mutex
+	sends := 0
+	expectedErrMsg := "Error waiting for batch to be applied"
+	testSendBatch := func(resourceName string, body interface{}) (interface{}, error) {
+		sendsMu.Lock()
+		defer sendsMu.Unlock()
+		sends++
+		return nil, &BatchAppliedError{Err: fmt.Errorf(expectedErrMsg)}
+	}
+
+	numRequests := 3
+
+	wg := sync.WaitGroup{}
+	wg.Add(numRequests)
+
+	for i := 0; i < numRequests; i++ {
+		go func(idx int) {
+			defer wg.Done()
+
+			req := &BatchRequest{
+				DebugId:      fmt.Sprintf("appliedError %d", idx),
+				ResourceName: "RESOURCE-APPLIED-ERROR",
+				Body:         []int{idx},
+				CombineF:     testCombine,
+				SendF:        testSendBatch,
+			}
+
+			_, err := testBatcher.SendRequestWithTimeout("batchAppliedError", req, time.Duration(10)*time.Second)
+			if err == nil || !strings.Contains(err.Error(), expectedErrMsg) {
+				t.Errorf("expected error for request %d to contain %q, got: %v", idx, expectedErrMsg, err)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if sends != 1 {
+		t.Errorf("expected the applied batch to be sent once, got %d sends", sends)
+	}
+}
+
 func TestRequestBatcher_errTimeout(t *testing.T) {
 	testBatcher := NewRequestBatcher(
 		"testBatcher",
@@ -292,3 +347,147 @@ func testBasicCountBatches(t *testing.T, testName string, numBatches int) {
 		}(i)
 	}
 }
+
+func TestRequestBatcher_registeredService(t *testing.T) {
+	service := RegisterBatchedService(&BatchedService{
+		Name: "testRegisteredService",
+		CombineF: func(currV interface{}, toAddV interface{}) (interface{}, error) {
+			return currV.(int) + toAddV.(int), nil
+		},
+		SendF: func(name string, body interface{}) (interface{}, error) {
+			return fmt.Sprintf("%s: %d", name, body), nil
+		},
+	})
+	defer delete(batchedServices, service.Name)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	batchers := NewRequestBatchers(ctx, &batchingConfig{
+		SendAfter:      time.Duration(500) * time.Millisecond,
+		EnableBatching: true,
+	})
+	testBatcher, ok := batchers[service.Name]
+	if !ok {
+		t.Fatalf("expected a batcher for registered service %q", service.Name)
+	}
+	for _, name := range []string{ServiceUsageBatchedService.Name, IamBatchedService.Name} {
+		if _, ok := batchers[name]; !ok {
+			t.Errorf("expected a batcher for built-in service %q", name)
+		}
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(3)
+	for i := 0; i < 3; i++ {
+		go func(idx int) {
+			defer wg.Done()
+
+			// CombineF and SendF come from the registered service
+			req := &BatchRequest{
+				DebugId:      fmt.Sprintf("Test Registered Service Request #%d", idx),
+				ResourceName: "testRegisteredService",
+				Body:         1,
+			}
+
+			respV, err := testBatcher.SendRequestWithTimeout("testRegisteredService", req, time.Duration(5)*time.Second)
+			if err != nil {
+				t.Errorf("got unexpected error %s", err)
+				return
+			}
+			if expected := "testRegisteredService: 3"; respV != expected {
+				t.Errorf("expected response %s, got %v", expected, respV)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestRegisterBatchedService_duplicate(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("expected registering a duplicate service name to panic")
+		}
+	}()
+	RegisterBatchedService(&BatchedService{Name: IamBatchedService.Name})
+}
+
+func TestRequestBatcher_maxBatchSize(t *testing.T) {
+	testSendsEarly(t, "testMaxBatchSize", 3, 0, &batchingConfig{
+		SendAfter:      time.Duration(10) * time.Second,
+		EnableBatching: true,
+		MaxBatchSize:   3,
+	})
+}
+
+func TestRequestBatcher_maxBatchSizeOne(t *testing.T) {
+	testSendsEarly(t, "testMaxBatchSizeOne", 1, 0, &batchingConfig{
+		SendAfter:      time.Duration(10) * time.Second,
+		EnableBatching: true,
+		MaxBatchSize:   1,
+	})
+}
+
+func TestRequestBatcher_sendAfterIdle(t *testing.T) {
+	testSendsEarly(t, "testSendAfterIdle", 3, 0, &batchingConfig{
+		SendAfter:      time.Duration(10) * time.Second,
+		EnableBatching: true,
+		SendAfterIdle:  time.Duration(200) * time.Millisecond,
+	})
+}
+
+// Requests joining a batch before it is idle reset the idle timer, so they
+// all end up in the same batch.
+func TestRequestBatcher_sendAfterIdleReset(t *testing.T) {
+	testSendsEarly(t, "testSendAfterIdleReset", 4, time.Duration(100)*time.Millisecond, &batchingConfig{
+		SendAfter:      time.Duration(10) * time.Second,
+		EnableBatching: true,
+		SendAfterIdle:  time.Duration(500) * time.Millisecond,
+	})
+}
+
+// testSendsEarly sends numRequests requests, spaced by interval, and checks
+// they are combined into a single batch sent well before SendAfter.
+func testSendsEarly(t *testing.T, testName string, numRequests int, interval time.Duration, config *batchingConfig) {
+	testBatcher := NewRequestBatcher(testName, context.Background(), config)
+
+	testCombine := func(currV interface{}, toAddV interface{}) (interface{}, error) {
+		return currV.(int) + toAddV.(int), nil
+	}
+
+	testSendBatch := func(name string, body interface{}) (interface{}, error) {
+		return fmt.Sprintf("%s: %d", name, body), nil
+	}
+
+	start := time.Now()
+	wg := sync.WaitGroup{}
+	wg.Add(numRequests)
+	for i := 0; i < numRequests; i++ {
+		go func(idx int) {
+			defer wg.Done()
+
+			req := &BatchRequest{
+				DebugId:      fmt.Sprintf("Test '%s' Request #%d", testName, idx),
+				ResourceName: testName,
+				Body:         1,
+				CombineF:     testCombine,
+				SendF:        testSendBatch,
+			}
+
+			respV, err := testBatcher.SendRequestWithTimeout(testName, req, time.Duration(15)*time.Second)
+			if err != nil {
+				t.Errorf("got unexpected error %s", err)
+				return
+			}
+			expected := fmt.Sprintf("%s: %d", testName, numRequests)
+			if respV != expected {
+				t.Errorf("expected response %s, got %v", expected, respV)
+			}
+		}(i)
+		time.Sleep(interval)
+	}
+	wg.Wait()
+
+	if elapsed := time.Since(start); elapsed >= config.SendAfter/2 {
+		t.Errorf("expected batch to be sent before send_after of %s, took %s", config.SendAfter, elapsed)
+	}
+}
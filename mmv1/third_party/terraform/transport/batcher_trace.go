@@ -0,0 +1,132 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// BatcherTraceFileEnvVar names a file that every sent batch is appended to
+// as a JSON-lines BatchEvent, e.g. to tune `batching.send_after` from the
+// wait times and batch sizes of real runs.
+const BatcherTraceFileEnvVar = "GOOGLE_BATCHER_TRACE_FILE"
+
+// BatchEvent describes a sent batch. It is logged through tflog with the JSON
+// names of its fields and written to the trace file, if any.
+type BatchEvent struct {
+	Time    time.Time `json:"time"`
+	Batcher string    `json:"batcher"`
+	// BatchKey is the key requests in the batch were grouped by
+	BatchKey string `json:"batch_key"`
+	// Requests is the number of requests combined into the batch
+	Requests int `json:"requests"`
+	// Trigger is why the batch was sent: send_after, send_after_idle or
+	// max_batch_size
+	Trigger string `json:"trigger"`
+	// SendAfterMs is the configured `batching.send_after`
+	SendAfterMs float64 `json:"send_after_ms"`
+	// WaitMs is how long the first request in the batch waited before the
+	// batch was sent
+	WaitMs float64 `json:"wait_ms"`
+	// SendLatencyMs is how long sending the batch took, including the
+	// single-request fallback
+	SendLatencyMs float64 `json:"send_latency_ms"`
+	// SingleRetryFallback is set if the batch failed and its requests were
+	// retried one at a time
+	SingleRetryFallback bool `json:"single_retry_fallback"`
+	// Error is the error returned for the batch
+	Error string `json:"error,omitempty"`
+	// RequestErrors maps the DebugId of failed requests to their error
+	RequestErrors map[string]string `json:"request_errors,omitempty"`
+}
+
+func (e *BatchEvent) fields() map[string]interface{} {
+	fields := map[string]interface{}{
+		"batcher":               e.Batcher,
+		"batch_key":             e.BatchKey,
+		"requests":              e.Requests,
+		"trigger":               e.Trigger,
+		"send_after_ms":         e.SendAfterMs,
+		"wait_ms":               e.WaitMs,
+		"send_latency_ms":       e.SendLatencyMs,
+		"single_retry_fallback": e.SingleRetryFallback,
+	}
+	if e.Error != "" {
+		fields["error"] = e.Error
+	}
+	if len(e.RequestErrors) > 0 {
+		fields["request_errors"] = e.RequestErrors
+	}
+	return fields
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// batchTrace appends events to a JSON-lines trace file. Batchers tracing to
+// the same file share a batchTrace.
+type batchTrace struct {
+	sync.Mutex
+	f *os.File
+}
+
+var batchTraces = struct {
+	sync.Mutex
+	byPath map[string]*batchTrace
+}{byPath: make(map[string]*batchTrace)}
+
+// batchTraceFromEnv returns the trace for the file named by
+// BatcherTraceFileEnvVar, or nil if it is unset or can't be opened.
+func batchTraceFromEnv() *batchTrace {
+	path := os.Getenv(BatcherTraceFileEnvVar)
+	if path == "" {
+		return nil
+	}
+
+	batchTraces.Lock()
+	defer batchTraces.Unlock()
+	if trace, ok := batchTraces.byPath[path]; ok {
+		return trace
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Printf("[WARN] Unable to open batcher trace file %q set by %s: %s", path, BatcherTraceFileEnvVar, err)
+		return nil
+	}
+	trace := &batchTrace{f: f}
+	batchTraces.byPath[path] = trace
+	return trace
+}
+
+func (t *batchTrace) write(e *BatchEvent) {
+	if t == nil {
+		return
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("[WARN] Unable to encode batch event for batch %q: %s", e.BatchKey, err)
+		return
+	}
+
+	t.Lock()
+	defer t.Unlock()
+	if _, err := t.f.Write(append(line, '\n')); err != nil {
+		log.Printf("[WARN] Unable to write batch event for batch %q to trace file: %s", e.BatchKey, err)
+	}
+}
+
+// recordBatchEvent logs the event and writes it to the trace file, if any.
+func (b *RequestBatcher) recordBatchEvent(ctx context.Context, e *BatchEvent) {
+	e.Time = time.Now()
+	e.Batcher = b.debugId
+	e.SendAfterMs = durationMs(b.SendAfter)
+
+	tflog.Debug(ctx, "Sent batch", e.fields())
+	b.trace.write(e)
+}
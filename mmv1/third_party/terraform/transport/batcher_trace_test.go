@@ -0,0 +1,143 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+)
+
+func TestRequestBatcher_traceFile(t *testing.T) {
+	tracePath := filepath.Join(t.TempDir(), "batches.jsonl")
+	t.Setenv(BatcherTraceFileEnvVar, tracePath)
+
+	testBatcher := NewRequestBatcher(
+		"testTraceBatcher",
+		context.Background(),
+		&batchingConfig{
+			SendAfter:      time.Duration(500) * time.Millisecond,
+			EnableBatching: true,
+		})
+
+	// Combined requests fail so each request is retried on its own, and
+	// the request with index 0 fails again
+	testCombine := func(body interface{}, toAdd interface{}) (interface{}, error) {
+		return append(body.([]int), toAdd.([]int)...), nil
+	}
+	testSendBatch := func(resourceName string, body interface{}) (interface{}, error) {
+		for _, v := range body.([]int) {
+			if v == 0 {
+				return nil, fmt.Errorf("batch contains idx 0")
+			}
+		}
+		return nil, nil
+	}
+
+	numRequests := 3
+	wg := sync.WaitGroup{}
+	wg.Add(numRequests)
+	for i := 0; i < numRequests; i++ {
+		go func(idx int) {
+			defer wg.Done()
+			req := &BatchRequest{
+				DebugId:      fmt.Sprintf("traced %d", idx),
+				ResourceName: "RESOURCE-TRACE",
+				Body:         []int{idx},
+				CombineF:     testCombine,
+				SendF:        testSendBatch,
+			}
+			testBatcher.SendRequestWithTimeout("batchTrace", req, time.Duration(5)*time.Second)
+		}(i)
+	}
+	wg.Wait()
+
+	events := readBatchEvents(t, tracePath, 1)
+	e := events[0]
+	if e.Batcher != "testTraceBatcher" || e.BatchKey != "batchTrace" {
+		t.Errorf("expected event for batch batchTrace of testTraceBatcher, got %q of %q", e.BatchKey, e.Batcher)
+	}
+	if e.Requests != numRequests {
+		t.Errorf("expected %d combined requests, got %d", numRequests, e.Requests)
+	}
+	if e.SendAfterMs != 500 {
+		t.Errorf("expected send_after_ms of 500, got %v", e.SendAfterMs)
+	}
+	if e.WaitMs < 400 {
+		t.Errorf("expected the batch to wait for about send_after, got %vms", e.WaitMs)
+	}
+	if !e.SingleRetryFallback {
+		t.Errorf("expected the single retry fallback to fire")
+	}
+	if e.Error == "" {
+		t.Errorf("expected the batch error to be recorded")
+	}
+	if len(e.RequestErrors) != 1 || e.RequestErrors["traced 0"] == "" {
+		t.Errorf("expected an error only for request %q, got %v", "traced 0", e.RequestErrors)
+	}
+}
+
+func TestRequestBatcher_recordBatchEventLogsFields(t *testing.T) {
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+
+	testBatcher := &RequestBatcher{
+		debugId:        "testLogBatcher",
+		batchingConfig: &batchingConfig{SendAfter: time.Second, EnableBatching: true},
+	}
+	testBatcher.recordBatchEvent(ctx, &BatchEvent{BatchKey: "batchLog", Requests: 2, WaitMs: 1000})
+
+	entries, err := tflogtest.MultilineJSONDecode(&output)
+	if err != nil {
+		t.Fatalf("unable to decode log output: %s", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 log entry, got %d", len(entries))
+	}
+	for field, expected := range map[string]interface{}{
+		"batcher":               "testLogBatcher",
+		"batch_key":             "batchLog",
+		"requests":              float64(2),
+		"send_after_ms":         float64(1000),
+		"wait_ms":               float64(1000),
+		"single_retry_fallback": false,
+	} {
+		if entries[0][field] != expected {
+			t.Errorf("expected log field %s to be %v, got %v", field, expected, entries[0][field])
+		}
+	}
+}
+
+// readBatchEvents waits for the trace file to contain count events, as
+// events are recorded after responses are returned to the requests.
+func readBatchEvents(t *testing.T, path string, count int) []BatchEvent {
+	var events []BatchEvent
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		events = nil
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var e BatchEvent
+			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+				t.Fatalf("unable to decode trace line %q: %s", scanner.Text(), err)
+			}
+			events = append(events, e)
+		}
+		f.Close()
+		if len(events) >= count {
+			return events
+		}
+	}
+	t.Fatalf("expected %d events in trace file, got %d", count, len(events))
+	return nil
+}
@@ -2,6 +2,7 @@ package transport_test
 
 import (
 	"context"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -454,6 +455,48 @@ func TestHandleSDKDefaults_RequestReason(t *testing.T) {
 	}
 }
 
+// Each environment variable listed in ProviderEnvVars is a fallback for its
+// field, in the listed order of precedence
+func TestHandleSDKDefaults_ProviderEnvVars(t *testing.T) {
+	// credentials and access_token are read in providerConfigure instead
+	fields := []string{
+		"impersonate_service_account",
+		"project",
+		"billing_project",
+		"region",
+		"zone",
+		"request_reason",
+	}
+
+	for _, field := range fields {
+		envVars := transport_tpg.ProviderEnvVars[field]
+		if len(envVars) == 0 {
+			t.Fatalf("expected environment variables for %s", field)
+		}
+		for i, k := range envVars {
+			t.Run(fmt.Sprintf("%s/%s", field, k), func(t *testing.T) {
+				for _, other := range envVars {
+					t.Setenv(other, "")
+				}
+				t.Setenv(k, "value-from-"+k)
+				// lower precedence variables are ignored
+				for _, other := range envVars[i+1:] {
+					t.Setenv(other, "value-from-"+other)
+				}
+
+				d := schema.TestResourceDataRaw(t, google_tpg.Provider().Schema, map[string]interface{}{})
+				if err := transport_tpg.HandleSDKDefaults(d); err != nil {
+					t.Fatalf("error: %v", err)
+				}
+
+				if v := d.Get(field); v != "value-from-"+k {
+					t.Fatalf("unexpected value: wanted %v, got, %v", "value-from-"+k, v)
+				}
+			})
+		}
+	}
+}
+
 func TestConfigLoadAndValidate_accountFilePath(t *testing.T) {
 	config := &transport_tpg.Config{
 		Credentials: transport_tpg.TestFakeCredentialsPath,
@@ -692,6 +735,8 @@ func TestConfigLoadAndValidate_customBatchingConfig(t *testing.T) {
 		map[string]interface{}{
 			"send_after":      "1s",
 			"enable_batching": false,
+			"max_batch_size":  50,
+			"send_after_idle": "200ms",
 		},
 	})
 	if err != nil {
@@ -703,6 +748,12 @@ func TestConfigLoadAndValidate_customBatchingConfig(t *testing.T) {
 	if batchCfg.EnableBatching {
 		t.Fatalf("expected EnableBatching to be false")
 	}
+	if batchCfg.MaxBatchSize != 50 {
+		t.Fatalf("expected batchCfg MaxBatchSize to be 50, got %d", batchCfg.MaxBatchSize)
+	}
+	if batchCfg.SendAfterIdle != 200*time.Millisecond {
+		t.Fatalf("expected batchCfg SendAfterIdle to be 200ms, got %v", batchCfg.SendAfterIdle)
+	}
 
 	config := &transport_tpg.Config{
 		Credentials:    transport_tpg.TestFakeCredentialsPath,
@@ -2,6 +2,7 @@ package transport
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/diag"
@@ -39,5 +40,116 @@ func GetBatchingConfig(ctx context.Context, data types.List, diags *diag.Diagnos
 		bc.EnableBatching = pbConfigs[0].EnableBatching.ValueBool()
 	}
 
+	if !pbConfigs[0].MaxBatchSize.IsNull() {
+		maxBatchSize := pbConfigs[0].MaxBatchSize.ValueInt64()
+		if maxBatchSize < 0 {
+			diags.AddError("invalid max batch size", fmt.Sprintf("'max_batch_size' must not be negative, got %d", maxBatchSize))
+			return bc
+		}
+		bc.MaxBatchSize = int(maxBatchSize)
+	}
+
+	if !pbConfigs[0].SendAfterIdle.IsNull() && pbConfigs[0].SendAfterIdle.ValueString() != "" {
+		sendAfterIdle, err := time.ParseDuration(pbConfigs[0].SendAfterIdle.ValueString())
+		if err != nil {
+			diags.AddError("error parsing send after idle time duration", err.Error())
+			return bc
+		}
+		bc.SendAfterIdle = sendAfterIdle
+	}
+
 	return bc
 }
+
+// GetRetryBudget returns the retry budget given the provider
+// configuration set for retry_budget, or nil if it is unset
+func GetRetryBudget(ctx context.Context, data types.List, diags *diag.Diagnostics) *RetryBudget {
+	if data.IsNull() {
+		return nil
+	}
+
+	var prbConfigs []ProviderRetryBudget
+	d := data.ElementsAs(ctx, &prbConfigs, true)
+	diags.Append(d...)
+	if diags.HasError() || len(prbConfigs) == 0 {
+		return nil
+	}
+
+	maxRetries := prbConfigs[0].MaxRetries.ValueInt64()
+	if maxRetries < 0 {
+		diags.AddError("invalid retry budget", fmt.Sprintf("'max_retries' must not be negative, got %d", maxRetries))
+		return nil
+	}
+
+	period := DefaultRetryBudgetPeriod
+	if !prbConfigs[0].Period.IsNull() && prbConfigs[0].Period.ValueString() != "" {
+		var err error
+		period, err = time.ParseDuration(prbConfigs[0].Period.ValueString())
+		if err != nil {
+			diags.AddError("error parsing retry budget period duration", err.Error())
+			return nil
+		}
+	}
+
+	return NewRetryBudget(int(maxRetries), period)
+}
+
+// GetRequestRateLimits returns the requests per second allowed to each API
+// host given the provider configuration set for request_rate_limits
+func GetRequestRateLimits(ctx context.Context, data types.Map, diags *diag.Diagnostics) map[string]float64 {
+	limits := make(map[string]float64)
+	if data.IsNull() {
+		return limits
+	}
+
+	var qpsByKey map[string]float64
+	d := data.ElementsAs(ctx, &qpsByKey, false)
+	diags.Append(d...)
+	if diags.HasError() {
+		return limits
+	}
+
+	for key, qps := range qpsByKey {
+		if qps <= 0 {
+			diags.AddError("invalid request rate limit", fmt.Sprintf("the request rate limit of %q must be positive, got %v", key, qps))
+			return limits
+		}
+		limits[rateLimitHost(key)] = qps
+	}
+
+	return limits
+}
+
+// GetRetryMatches returns the retry conditions given the
+// provider configuration set for retry
+func GetRetryMatches(ctx context.Context, data types.List, diags *diag.Diagnostics) []RetryMatch {
+	if data.IsNull() {
+		return nil
+	}
+
+	var prConfigs []ProviderRetry
+	d := data.ElementsAs(ctx, &prConfigs, true)
+	diags.Append(d...)
+	if diags.HasError() || len(prConfigs) == 0 || prConfigs[0].Match.IsNull() {
+		return nil
+	}
+
+	var prmConfigs []ProviderRetryMatch
+	d = prConfigs[0].Match.ElementsAs(ctx, &prmConfigs, true)
+	diags.Append(d...)
+	if diags.HasError() {
+		return nil
+	}
+
+	matches := make([]RetryMatch, 0, len(prmConfigs))
+	for _, prm := range prmConfigs {
+		m, err := NewRetryMatch(int(prm.Code.ValueInt64()), prm.MessageRegex.ValueString(), int(prm.MaxRetries.ValueInt64()))
+		if err != nil {
+			diags.AddError("invalid retry match", err.Error())
+			return nil
+		}
+		matches = append(matches, m)
+	}
+
+	return matches
+}
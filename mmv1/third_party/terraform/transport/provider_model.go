@@ -7,4 +7,21 @@ import (
 type ProviderBatching struct {
 	SendAfter      types.String `tfsdk:"send_after"`
 	EnableBatching types.Bool   `tfsdk:"enable_batching"`
+	MaxBatchSize   types.Int64  `tfsdk:"max_batch_size"`
+	SendAfterIdle  types.String `tfsdk:"send_after_idle"`
+}
+
+type ProviderRetry struct {
+	Match types.List `tfsdk:"match"`
+}
+
+type ProviderRetryMatch struct {
+	Code         types.Int64  `tfsdk:"code"`
+	MessageRegex types.String `tfsdk:"message_regex"`
+	MaxRetries   types.Int64  `tfsdk:"max_retries"`
+}
+
+type ProviderRetryBudget struct {
+	MaxRetries types.Int64  `tfsdk:"max_retries"`
+	Period     types.String `tfsdk:"period"`
 }
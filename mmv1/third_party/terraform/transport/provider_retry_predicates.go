@@ -0,0 +1,132 @@
+package transport
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"google.golang.org/api/googleapi"
+)
+
+// RetryMatch is a retryable error condition configured through a `match`
+// block of the provider `retry` block. It lets users retry transient errors
+// that aren't covered by the default retry predicates yet.
+type RetryMatch struct {
+	// Code is the HTTP status code of matching errors. 0 matches any error.
+	Code int
+	// MessageRegex matches the error message. nil matches any message.
+	MessageRegex *regexp.Regexp
+	// MaxRetries limits how many times a single request is retried because
+	// of this match. 0 leaves retries bounded only by the request timeout.
+	MaxRetries int
+}
+
+// NewRetryMatch validates and compiles a `match` block.
+func NewRetryMatch(code int, messageRegex string, maxRetries int) (RetryMatch, error) {
+	m := RetryMatch{Code: code, MaxRetries: maxRetries}
+	if code == 0 && messageRegex == "" {
+		return m, fmt.Errorf("at least one of 'code' or 'message_regex' must be set in a retry match")
+	}
+	if code < 0 {
+		return m, fmt.Errorf("'code' must not be negative, got %d", code)
+	}
+	if maxRetries < 0 {
+		return m, fmt.Errorf("'max_retries' must not be negative, got %d", maxRetries)
+	}
+	if messageRegex != "" {
+		re, err := regexp.Compile(messageRegex)
+		if err != nil {
+			return m, fmt.Errorf("unable to compile 'message_regex' %q: %s", messageRegex, err)
+		}
+		m.MessageRegex = re
+	}
+	return m, nil
+}
+
+func (m RetryMatch) String() string {
+	var conditions []string
+	if m.Code != 0 {
+		conditions = append(conditions, fmt.Sprintf("code %d", m.Code))
+	}
+	if m.MessageRegex != nil {
+		conditions = append(conditions, fmt.Sprintf("message matching %q", m.MessageRegex))
+	}
+	return strings.Join(conditions, " and ")
+}
+
+func (m RetryMatch) matches(err error) bool {
+	if m.Code != 0 {
+		gerr, ok := err.(*googleapi.Error)
+		if !ok || gerr.Code != m.Code {
+			return false
+		}
+	}
+	if m.MessageRegex != nil && !m.MessageRegex.MatchString(err.Error()) {
+		return false
+	}
+	return true
+}
+
+// retryMatchCounter applies the provider retry matches to the attempts of a
+// single request, counting the retries each match allowed against its
+// MaxRetries. Each attempt is checked once, against the error it returned.
+type retryMatchCounter struct {
+	matches []RetryMatch
+	retries []int
+}
+
+func newRetryMatchCounter(matches []RetryMatch) *retryMatchCounter {
+	return &retryMatchCounter{
+		matches: matches,
+		retries: make([]int, len(matches)),
+	}
+}
+
+// retry returns whether err matches a provider retry match with retries
+// left, counting the retry against the first such match.
+func (c *retryMatchCounter) retry(err error) (bool, string) {
+	for i, m := range c.matches {
+		if !m.matches(err) {
+			continue
+		}
+		if m.MaxRetries > 0 && c.retries[i] >= m.MaxRetries {
+			log.Printf("[DEBUG] Not retrying error matching provider retry configuration for %s, already retried %d times", m, c.retries[i])
+			continue
+		}
+		c.retries[i]++
+		return true, fmt.Sprintf("Matched provider retry configuration for %s", m)
+	}
+	return false, ""
+}
+
+// ExpandProviderRetryMatches returns the retry conditions configured in the
+// provider `retry` block.
+func ExpandProviderRetryMatches(v interface{}) ([]RetryMatch, error) {
+	if v == nil {
+		return nil, nil
+	}
+	ls := v.([]interface{})
+	if len(ls) == 0 || ls[0] == nil {
+		return nil, nil
+	}
+
+	cfgV := ls[0].(map[string]interface{})
+	matchesV, _ := cfgV["match"].([]interface{})
+	matches := make([]RetryMatch, 0, len(matchesV))
+	for _, matchV := range matchesV {
+		raw, ok := matchV.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		code, _ := raw["code"].(int)
+		messageRegex, _ := raw["message_regex"].(string)
+		maxRetries, _ := raw["max_retries"].(int)
+		m, err := NewRetryMatch(code, messageRegex, maxRetries)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, m)
+	}
+	return matches, nil
+}
@@ -0,0 +1,163 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+func TestNewRetryMatch(t *testing.T) {
+	cases := map[string]struct {
+		code         int
+		messageRegex string
+		maxRetries   int
+		wantErr      bool
+	}{
+		"code":             {code: 400},
+		"message regex":    {messageRegex: "try again"},
+		"code and regex":   {code: 400, messageRegex: "try again", maxRetries: 5},
+		"no condition":     {maxRetries: 5, wantErr: true},
+		"negative code":    {code: -1, wantErr: true},
+		"negative retries": {code: 400, maxRetries: -1, wantErr: true},
+		"invalid regex":    {messageRegex: "(", wantErr: true},
+	}
+
+	for tn, tc := range cases {
+		_, err := NewRetryMatch(tc.code, tc.messageRegex, tc.maxRetries)
+		if tc.wantErr && err == nil {
+			t.Errorf("%s: expected error, got none", tn)
+		}
+		if !tc.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tn, err)
+		}
+	}
+}
+
+func TestRetryMatchCounter(t *testing.T) {
+	m, err := NewRetryMatch(400, "resource is not ready", 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	matching := &googleapi.Error{Code: 400, Message: "The resource is not ready yet"}
+
+	c := newRetryMatchCounter([]RetryMatch{m})
+	if isRetryable, _ := c.retry(&googleapi.Error{Code: 404, Message: "The resource is not ready yet"}); isRetryable {
+		t.Errorf("expected an error with a different code not to be retryable")
+	}
+	if isRetryable, _ := c.retry(&googleapi.Error{Code: 400, Message: "Invalid argument"}); isRetryable {
+		t.Errorf("expected an error with a different message not to be retryable")
+	}
+	if isRetryable, _ := c.retry(fmt.Errorf("The resource is not ready yet")); isRetryable {
+		t.Errorf("expected a non-googleapi error not to match a code")
+	}
+	for i := 0; i < 2; i++ {
+		if isRetryable, _ := c.retry(matching); !isRetryable {
+			t.Fatalf("expected retry %d to be allowed", i)
+		}
+	}
+	if isRetryable, _ := c.retry(matching); isRetryable {
+		t.Errorf("expected retries to stop after max_retries")
+	}
+
+	// Every request gets a new count
+	if isRetryable, _ := newRetryMatchCounter([]RetryMatch{m}).retry(matching); !isRetryable {
+		t.Errorf("expected a new counter to allow retries")
+	}
+}
+
+func TestRetryTransport_ProviderRetryMatches(t *testing.T) {
+	m, err := NewRetryMatch(testRetryTransportCodeFailure, "", 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var attempts int
+	ts, client := setUpRetryTransportServerClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		testRetryTransportHandler_noRetries(t, testRetryTransportCodeFailure).ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	client.Transport = client.Transport.(*retryTransport).WithProviderRetries(nil, []RetryMatch{m})
+
+	resp, err := client.Get(ts.URL)
+	testRetryTransport_checkFailure(t, resp, err, testRetryTransportCodeFailure)
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts with max_retries of 2, got %d", attempts)
+	}
+}
+
+// Check requests retried by RetryTimeDuration, as SendRequest does, are only
+// retried max_retries times for the provider retry configuration, rather
+// than max_retries times by the transport for each RetryTimeDuration attempt
+func TestRetryTimeDuration_ProviderRetryMatchesOnlyInTransport(t *testing.T) {
+	m, err := NewRetryMatch(testRetryTransportCodeFailure, "", 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var attempts int
+	ts, client := setUpRetryTransportServerClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		testRetryTransportHandler_noRetries(t, testRetryTransportCodeFailure).ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	client.Transport = client.Transport.(*retryTransport).WithProviderRetries(nil, []RetryMatch{m})
+
+	err = RetryTimeDuration(func() error {
+		resp, err := client.Get(ts.URL)
+		if err != nil {
+			return err
+		}
+		return googleapi.CheckResponse(resp)
+	}, time.Minute)
+	if err == nil {
+		t.Fatalf("expected an error after retries stopped")
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts with max_retries of 2, got %d", attempts)
+	}
+}
+
+func TestExpandProviderRetryMatches(t *testing.T) {
+	matches, err := ExpandProviderRetryMatches([]interface{}{
+		map[string]interface{}{
+			"match": []interface{}{
+				map[string]interface{}{"code": 400, "message_regex": "try again", "max_retries": 5},
+				map[string]interface{}{"code": 0, "message_regex": "backend unavailable", "max_retries": 0},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 matches, got %d", len(matches))
+	}
+	if got := matches[0].String(); got != `code 400 and message matching "try again"` {
+		t.Errorf("unexpected first match: %s", got)
+	}
+	if matches[0].MaxRetries != 5 {
+		t.Errorf("expected max_retries of 5, got %d", matches[0].MaxRetries)
+	}
+	if got := matches[1].String(); got != `message matching "backend unavailable"` {
+		t.Errorf("unexpected second match: %s", got)
+	}
+
+	if matches, err := ExpandProviderRetryMatches([]interface{}{}); err != nil || matches != nil {
+		t.Errorf("expected no matches for an unset block, got %v, %v", matches, err)
+	}
+
+	_, err = ExpandProviderRetryMatches([]interface{}{
+		map[string]interface{}{
+			"match": []interface{}{
+				map[string]interface{}{"code": 0, "message_regex": "", "max_retries": 5},
+			},
+		},
+	})
+	if err == nil {
+		t.Errorf("expected an error for a match without conditions")
+	}
+}
@@ -0,0 +1,146 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket allowing qps requests per second, with bursts
+// of up to qps requests (at least one).
+type rateLimiter struct {
+	qps   float64
+	burst float64
+
+	mu         sync.Mutex
+	tokens     float64
+	lastRefill time.Time
+	now        func() time.Time
+}
+
+func newRateLimiter(qps float64) *rateLimiter {
+	burst := math.Max(1, math.Ceil(qps))
+	return &rateLimiter{
+		qps:    qps,
+		burst:  burst,
+		tokens: burst,
+		now:    time.Now,
+	}
+}
+
+// reserve takes a token from the bucket and returns how long the caller must
+// wait before using it. The bucket goes into debt so concurrent callers are
+// queued behind each other.
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if !l.lastRefill.IsZero() {
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.lastRefill).Seconds()*l.qps)
+	}
+	l.lastRefill = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.qps * float64(time.Second))
+}
+
+// cancel returns a token taken by reserve that was never used.
+func (l *rateLimiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = math.Min(l.burst, l.tokens+1)
+}
+
+// Wait blocks until a request is allowed or ctx is done.
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	wait := l.reserve()
+	if wait == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.cancel()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// NewTransportWithRateLimits constructs a transport throttling requests to
+// each API host in limits to its requests per second. Every client sharing
+// the transport shares its limits, so each configured provider has its own.
+func NewTransportWithRateLimits(t http.RoundTripper, limits map[string]float64) *rateLimitTransport {
+	if t == nil {
+		t = http.DefaultTransport
+	}
+	limiters := make(map[string]*rateLimiter, len(limits))
+	for host, qps := range limits {
+		limiters[host] = newRateLimiter(qps)
+	}
+	return &rateLimitTransport{internal: t, limiters: limiters}
+}
+
+type rateLimitTransport struct {
+	internal http.RoundTripper
+	// limiters are keyed by API host, and only read after construction.
+	limiters map[string]*rateLimiter
+}
+
+// RoundTrip implements the RoundTripper interface method.
+// It waits for the rate limiter of the request host, if any, before sending
+// the request. When wrapped by a retryTransport every attempt is throttled.
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if limiter := t.limiters[req.URL.Hostname()]; limiter != nil {
+		start := time.Now()
+		if err := limiter.Wait(req.Context()); err != nil {
+			return nil, fmt.Errorf("waiting for the request rate limit of %s: %w", req.URL.Hostname(), err)
+		}
+		if waited := time.Since(start); waited > time.Second {
+			log.Printf("[DEBUG] Request to %s was throttled for %s by the provider request rate limit", req.URL.Hostname(), waited)
+		}
+	}
+	return t.internal.RoundTrip(req)
+}
+
+// rateLimitHost returns the API host limited by a `request_rate_limits` key.
+// Keys are either a service name, such as "compute" for
+// compute.googleapis.com, or a full host name for custom endpoints.
+func rateLimitHost(key string) string {
+	if strings.Contains(key, ".") {
+		return key
+	}
+	return key + ".googleapis.com"
+}
+
+// ExpandProviderRequestRateLimits returns the requests per second allowed to
+// each API host given the provider `request_rate_limits` map.
+func ExpandProviderRequestRateLimits(v interface{}) (map[string]float64, error) {
+	limits := make(map[string]float64)
+	if v == nil {
+		return limits, nil
+	}
+
+	for key, qpsV := range v.(map[string]interface{}) {
+		qps, ok := qpsV.(float64)
+		if !ok {
+			return nil, fmt.Errorf("unable to parse the request rate limit of %q from %v", key, qpsV)
+		}
+		if qps <= 0 {
+			return nil, fmt.Errorf("the request rate limit of %q must be positive, got %v", key, qps)
+		}
+		limits[rateLimitHost(key)] = qps
+	}
+	return limits, nil
+}
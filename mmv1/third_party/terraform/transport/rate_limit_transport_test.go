@@ -0,0 +1,176 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Reserve(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newRateLimiter(2)
+	l.now = func() time.Time { return now }
+
+	// The burst allows qps requests immediately
+	for i := 0; i < 2; i++ {
+		if wait := l.reserve(); wait != 0 {
+			t.Fatalf("expected request %d to be allowed immediately, got wait %s", i, wait)
+		}
+	}
+
+	// Later requests are queued behind each other
+	if wait := l.reserve(); wait != 500*time.Millisecond {
+		t.Errorf("expected wait of 500ms, got %s", wait)
+	}
+	if wait := l.reserve(); wait != time.Second {
+		t.Errorf("expected wait of 1s, got %s", wait)
+	}
+
+	// The bucket refills over time, up to the burst
+	now = now.Add(time.Minute)
+	for i := 0; i < 2; i++ {
+		if wait := l.reserve(); wait != 0 {
+			t.Fatalf("expected request %d to be allowed immediately after refill, got wait %s", i, wait)
+		}
+	}
+	if wait := l.reserve(); wait == 0 {
+		t.Errorf("expected the burst to be capped at 2 requests")
+	}
+}
+
+func TestRateLimiter_SubOneQPS(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newRateLimiter(0.5)
+	l.now = func() time.Time { return now }
+
+	if wait := l.reserve(); wait != 0 {
+		t.Fatalf("expected the first request to be allowed immediately, got wait %s", wait)
+	}
+	if wait := l.reserve(); wait != 2*time.Second {
+		t.Errorf("expected wait of 2s, got %s", wait)
+	}
+}
+
+func TestRateLimiter_WaitCancelled(t *testing.T) {
+	l := newRateLimiter(0.1)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("expected the first request to be allowed, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestRateLimitTransport_ThrottlesLimitedHost(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(testRetryTransportCodeSuccess)
+	}))
+	defer ts.Close()
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("unable to parse server url: %v", err)
+	}
+
+	client := ts.Client()
+	client.Transport = NewTransportWithRateLimits(client.Transport, map[string]float64{u.Hostname(): 10})
+
+	// 10 requests fit in the burst, the next 5 have to wait for 500ms
+	start := time.Now()
+	for i := 0; i < 15; i++ {
+		resp, err := client.Get(ts.URL)
+		if err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+		resp.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("expected requests to be throttled, 15 requests took %s", elapsed)
+	}
+	if requests != 15 {
+		t.Errorf("expected 15 requests, got %d", requests)
+	}
+}
+
+func TestRateLimitTransport_UnlimitedHost(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(testRetryTransportCodeSuccess)
+	}))
+	defer ts.Close()
+
+	client := ts.Client()
+	client.Transport = NewTransportWithRateLimits(client.Transport, map[string]float64{"compute.googleapis.com": 0.001})
+
+	ctx, cc := context.WithTimeout(context.Background(), time.Second*5)
+	defer cc()
+	for i := 0; i < 5; i++ {
+		req, err := http.NewRequestWithContext(ctx, "GET", ts.URL, nil)
+		if err != nil {
+			t.Fatalf("unable to construct request: %v", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+		resp.Body.Close()
+	}
+}
+
+func TestExpandProviderRequestRateLimits(t *testing.T) {
+	cases := map[string]struct {
+		input    interface{}
+		expected map[string]float64
+		wantErr  bool
+	}{
+		"unset": {
+			input:    nil,
+			expected: map[string]float64{},
+		},
+		"service names and hosts": {
+			input: map[string]interface{}{
+				"compute":             20.0,
+				"dns":                 0.5,
+				"compute.example.com": 5.0,
+			},
+			expected: map[string]float64{
+				"compute.googleapis.com": 20,
+				"dns.googleapis.com":     0.5,
+				"compute.example.com":    5,
+			},
+		},
+		"zero": {
+			input:   map[string]interface{}{"compute": 0.0},
+			wantErr: true,
+		},
+		"negative": {
+			input:   map[string]interface{}{"compute": -1.0},
+			wantErr: true,
+		},
+	}
+
+	for tn, tc := range cases {
+		limits, err := ExpandProviderRequestRateLimits(tc.input)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error, got limits %v", tn, limits)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tn, err)
+			continue
+		}
+		if !reflect.DeepEqual(limits, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tn, tc.expected, limits)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package transport
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const DefaultRetryBudgetPeriod = time.Minute
+
+// RetryBudget limits the number of retries made by the retryTransport of a
+// configured provider, shared across all of its clients. Retries consume tokens from a
+// bucket holding at most MaxRetries tokens, refilled at MaxRetries per Period.
+// Once the bucket is empty, requests fail fast instead of piling more load
+// onto an API that is already rejecting requests.
+type RetryBudget struct {
+	MaxRetries int
+	Period     time.Duration
+
+	mu         sync.Mutex
+	tokens     float64
+	lastRefill time.Time
+	now        func() time.Time
+}
+
+// NewRetryBudget returns a budget allowing maxRetries retries per period.
+func NewRetryBudget(maxRetries int, period time.Duration) *RetryBudget {
+	if period <= 0 {
+		period = DefaultRetryBudgetPeriod
+	}
+	return &RetryBudget{
+		MaxRetries: maxRetries,
+		Period:     period,
+		tokens:     float64(maxRetries),
+		now:        time.Now,
+	}
+}
+
+// TryAcquire consumes a retry from the budget, returning false if the budget
+// is exhausted. A nil budget is unlimited.
+func (b *RetryBudget) TryAcquire() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if !b.lastRefill.IsZero() {
+		elapsed := now.Sub(b.lastRefill)
+		b.tokens += float64(b.MaxRetries) * elapsed.Seconds() / b.Period.Seconds()
+		if b.tokens > float64(b.MaxRetries) {
+			b.tokens = float64(b.MaxRetries)
+		}
+	}
+	b.lastRefill = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (b *RetryBudget) String() string {
+	return fmt.Sprintf("%d retries per %s", b.MaxRetries, b.Period)
+}
+
+// RetryBudgetExhaustedError is returned by retryTransport instead of retrying
+// a request once the retry budget of the provider is exhausted.
+type RetryBudgetExhaustedError struct {
+	Budget *RetryBudget
+	// Err is the retryable error returned by the last attempt.
+	Err error
+}
+
+func (e *RetryBudgetExhaustedError) Error() string {
+	return fmt.Sprintf("provider retry budget of %s exhausted, not retrying request. "+
+		"Many requests are failing with retryable errors, check for quota exhaustion or "+
+		"increase `retry_budget.max_retries` in the provider configuration. Last error: %s", e.Budget, e.Err)
+}
+
+func (e *RetryBudgetExhaustedError) Unwrap() error {
+	return e.Err
+}
+
+// ExpandProviderRetryBudget returns the retry budget configured in the
+// provider `retry_budget` block, or nil if the block is unset.
+func ExpandProviderRetryBudget(v interface{}) (*RetryBudget, error) {
+	if v == nil {
+		return nil, nil
+	}
+	ls := v.([]interface{})
+	if len(ls) == 0 || ls[0] == nil {
+		return nil, nil
+	}
+
+	cfgV := ls[0].(map[string]interface{})
+	maxRetries, _ := cfgV["max_retries"].(int)
+	if maxRetries < 0 {
+		return nil, fmt.Errorf("'max_retries' must not be negative, got %d", maxRetries)
+	}
+
+	period := DefaultRetryBudgetPeriod
+	if periodV, ok := cfgV["period"]; ok && periodV != "" {
+		var err error
+		period, err = time.ParseDuration(periodV.(string))
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse duration from 'period' value %q", periodV)
+		}
+	}
+
+	return NewRetryBudget(maxRetries, period), nil
+}
@@ -32,12 +32,15 @@ package transport
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"math/rand"
 	"net/http"
 	"net/http/httputil"
+	"strconv"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
@@ -71,9 +74,32 @@ func (t *retryTransport) WithAddedPredicates(predicates ...RetryErrorPredicateFu
 	return &copyT
 }
 
+// Returns a shallow copy of the retry transport limited by the retry budget
+// and also retrying the retry matches of a configured provider
+func (t *retryTransport) WithProviderRetries(budget *RetryBudget, matches []RetryMatch) *retryTransport {
+	copyT := *t
+	copyT.budget = budget
+	copyT.retryMatches = matches
+	return &copyT
+}
+
 type retryTransport struct {
 	retryPredicates []RetryErrorPredicateFunc
 	internal        http.RoundTripper
+	// budget limits retries for this transport. A nil budget is unlimited.
+	budget *RetryBudget
+	// retryMatches are the retry conditions from the provider configuration,
+	// checked once per attempt when no retry predicate matched.
+	retryMatches []RetryMatch
+	// jitter returns how long to wait before a retry given the current
+	// backoff. If nil, fullJitter is used.
+	jitter func(backoff time.Duration) time.Duration
+}
+
+// fullJitter waits a random duration up to the backoff, which keeps
+// concurrent workers retrying a failing API from doing so in lockstep.
+func fullJitter(backoff time.Duration) time.Duration {
+	return time.Duration(rand.Int63n(int64(backoff)) + 1)
 }
 
 // RoundTrip implements the RoundTripper interface method.
@@ -93,6 +119,9 @@ func (t *retryTransport) RoundTrip(req *http.Request) (resp *http.Response, resp
 		}()
 	}
 
+	// Retries allowed by the provider retry configuration are counted per request.
+	matchRetries := newRetryMatchCounter(t.retryMatches)
+
 	attempts := 0
 	backoff := time.Millisecond * 500
 	nextBackoff := time.Millisecond * 500
@@ -124,7 +153,7 @@ Retry:
 		resp, respErr = t.internal.RoundTrip(newRequest)
 		attempts++
 
-		retryErr := t.checkForRetryableError(resp, respErr)
+		retryErr := t.checkForRetryableError(resp, respErr, matchRetries)
 		if retryErr == nil {
 			log.Printf("[DEBUG] Retry Transport: Stopping retries, last request was successful")
 			break Retry
@@ -134,13 +163,34 @@ Retry:
 			break Retry
 		}
 
-		log.Printf("[DEBUG] Retry Transport: Waiting %s before trying request again", backoff)
+		budget := t.budget
+		if !budget.TryAcquire() {
+			log.Printf("[WARN] Retry Transport: Stopping retries, retry budget of %s exhausted", budget)
+			if resp != nil && resp.Body != nil {
+				resp.Body.Close()
+			}
+			resp, respErr = nil, &RetryBudgetExhaustedError{Budget: budget, Err: retryErr.Err}
+			break Retry
+		}
+
+		// Server-provided retry hints are honored as the minimum wait.
+		jitter := t.jitter
+		if jitter == nil {
+			jitter = fullJitter
+		}
+		wait := jitter(backoff)
+		if serverDelay := serverRetryDelay(resp, respErr); serverDelay > wait {
+			log.Printf("[DEBUG] Retry Transport: Server requested waiting %s before retrying", serverDelay)
+			wait = serverDelay
+		}
+
+		log.Printf("[DEBUG] Retry Transport: Waiting %s before trying request again", wait)
 		select {
 		case <-ctx.Done():
 			log.Printf("[DEBUG] Retry Transport: Stopping retries, context done: %v", ctx.Err())
 			break Retry
-		case <-time.After(backoff):
-			log.Printf("[DEBUG] Retry Transport: Finished waiting %s before next retry", backoff)
+		case <-time.After(wait):
+			log.Printf("[DEBUG] Retry Transport: Finished waiting %s before next retry", wait)
 
 			// Fibonnaci backoff - 0.5, 1, 1.5, 2.5, 4, 6.5, 10.5, ...
 			lastBackoff := backoff
@@ -178,8 +228,9 @@ func copyHttpRequest(req *http.Request) (*http.Request, error) {
 
 // checkForRetryableError uses the googleapi.CheckResponse util to check for
 // errors in the response, and determines whether there is a retryable error.
-// in response/response error.
-func (t *retryTransport) checkForRetryableError(resp *http.Response, respErr error) *resource.RetryError {
+// in response/response error. Errors not matching a retry predicate are
+// retried if they match the provider retry configuration, counted in matchRetries.
+func (t *retryTransport) checkForRetryableError(resp *http.Response, respErr error, matchRetries *retryMatchCounter) *resource.RetryError {
 	var errToCheck error
 
 	if respErr != nil {
@@ -207,5 +258,84 @@ func (t *retryTransport) checkForRetryableError(resp *http.Response, respErr err
 	if IsRetryableError(errToCheck, t.retryPredicates...) {
 		return resource.RetryableError(errToCheck)
 	}
+	if isRetryable, reason := matchRetries.retry(errToCheck); isRetryable {
+		log.Printf("[DEBUG] Dismissed an error as retryable. %s - %s", reason, errToCheck)
+		return resource.RetryableError(errToCheck)
+	}
 	return resource.NonRetryableError(errToCheck)
 }
+
+const retryInfoType = "type.googleapis.com/google.rpc.RetryInfo"
+
+// serverRetryDelay returns how long the server asked clients to wait before
+// retrying, from the Retry-After header or a google.rpc.RetryInfo error detail.
+// Returns 0 if the server gave no hint.
+func serverRetryDelay(resp *http.Response, respErr error) time.Duration {
+	if resp != nil {
+		if delay := parseRetryAfter(resp.Header.Get("Retry-After")); delay > 0 {
+			return delay
+		}
+		if resp.Body != nil && resp.Body != http.NoBody {
+			// Restore the body so the response can still be returned if the
+			// context is done before the next attempt.
+			body, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+			if err == nil {
+				var errResp struct {
+					Error struct {
+						Details []interface{} `json:"details"`
+					} `json:"error"`
+				}
+				if json.Unmarshal(body, &errResp) == nil {
+					if delay := retryInfoDelay(errResp.Error.Details); delay > 0 {
+						return delay
+					}
+				}
+			}
+		}
+	}
+	var gerr *googleapi.Error
+	if errors.As(respErr, &gerr) {
+		if delay := parseRetryAfter(gerr.Header.Get("Retry-After")); delay > 0 {
+			return delay
+		}
+		return retryInfoDelay(gerr.Details)
+	}
+	return 0
+}
+
+// parseRetryAfter parses a Retry-After header value given either in seconds
+// or as an HTTP date.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(v); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
+
+// retryInfoDelay returns the retryDelay of the first google.rpc.RetryInfo
+// error detail, e.g. {"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": "30s"}
+func retryInfoDelay(details []interface{}) time.Duration {
+	for _, detail := range details {
+		m, ok := detail.(map[string]interface{})
+		if !ok || m["@type"] != retryInfoType {
+			continue
+		}
+		if retryDelay, ok := m["retryDelay"].(string); ok {
+			if delay, err := time.ParseDuration(retryDelay); err == nil && delay > 0 {
+				return delay
+			}
+		}
+	}
+	return 0
+}
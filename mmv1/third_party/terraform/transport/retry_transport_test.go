@@ -3,6 +3,7 @@ package transport
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"google.golang.org/api/googleapi"
 	"io/ioutil"
@@ -25,6 +26,8 @@ func setUpRetryTransportServerClient(hf http.Handler) (*httptest.Server, *http.C
 	client.Transport = &retryTransport{
 		internal:        http.DefaultTransport,
 		retryPredicates: []RetryErrorPredicateFunc{testRetryTransportRetryPredicate},
+		// Wait the full backoff so retries happen at predictable times.
+		jitter: func(backoff time.Duration) time.Duration { return backoff },
 	}
 	return ts, client
 }
@@ -168,6 +171,185 @@ func TestRetryTransport_DoesNotRetryEmptyGetBody(t *testing.T) {
 	testRetryTransport_checkFailedWhileRetrying(t, resp, err)
 }
 
+// Check the server-provided Retry-After is waited for before retrying
+func TestRetryTransport_HonorsRetryAfter(t *testing.T) {
+	var attempts int
+	var firstReqTime, secondReqTime time.Time
+	ts, client := setUpRetryTransportServerClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			firstReqTime = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(testRetryTransportCodeRetry)
+			return
+		}
+		secondReqTime = time.Now()
+		w.WriteHeader(testRetryTransportCodeSuccess)
+	}))
+	defer ts.Close()
+
+	resp, err := client.Get(ts.URL)
+	testRetryTransport_checkSuccess(t, resp, err)
+	if waited := secondReqTime.Sub(firstReqTime); waited < time.Second {
+		t.Errorf("expected to wait at least 1s before retrying, waited %s", waited)
+	}
+}
+
+// Check requests fail fast with a clear error once the retry budget is exhausted
+func TestRetryTransport_RetryBudgetExhausted(t *testing.T) {
+	var attempts int
+	ts, client := setUpRetryTransportServerClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(testRetryTransportCodeRetry)
+	}))
+	defer ts.Close()
+	client.Transport.(*retryTransport).budget = NewRetryBudget(2, time.Hour)
+
+	_, err := client.Get(ts.URL)
+	var budgetErr *RetryBudgetExhaustedError
+	if !errors.As(err, &budgetErr) {
+		t.Fatalf("expected retry budget exhausted error, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "retry budget of 2 retries per 1h0m0s exhausted") {
+		t.Errorf("expected error to describe the exhausted budget, got: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts with a budget of 2 retries, got %d", attempts)
+	}
+}
+
+func TestRetryBudget_Refill(t *testing.T) {
+	now := time.Now()
+	budget := NewRetryBudget(2, time.Minute)
+	budget.now = func() time.Time { return now }
+
+	if !budget.TryAcquire() || !budget.TryAcquire() {
+		t.Fatalf("expected the budget to allow 2 retries")
+	}
+	if budget.TryAcquire() {
+		t.Fatalf("expected the budget to be exhausted")
+	}
+
+	now = now.Add(30 * time.Second)
+	if !budget.TryAcquire() {
+		t.Fatalf("expected the budget to refill a retry after 30s")
+	}
+	if budget.TryAcquire() {
+		t.Fatalf("expected the budget to be exhausted after using the refilled retry")
+	}
+
+	var unlimited *RetryBudget
+	if !unlimited.TryAcquire() {
+		t.Errorf("expected a nil budget to be unlimited")
+	}
+}
+
+// Check each configured provider keeps its own retry budget
+func TestRetryTransport_WithProviderRetries(t *testing.T) {
+	base := NewTransportWithDefaultRetries(http.DefaultTransport)
+	first := base.WithProviderRetries(NewRetryBudget(1, time.Hour), nil)
+	second := base.WithProviderRetries(NewRetryBudget(1, time.Hour), nil)
+
+	if base.budget != nil {
+		t.Errorf("expected the base transport to keep no budget, got %s", base.budget)
+	}
+	if !first.budget.TryAcquire() {
+		t.Fatalf("expected the budget to allow a retry")
+	}
+	if !second.budget.TryAcquire() {
+		t.Errorf("expected exhausting one provider's budget to leave the other's")
+	}
+}
+
+func TestFullJitter(t *testing.T) {
+	backoff := 500 * time.Millisecond
+	for i := 0; i < 100; i++ {
+		if wait := fullJitter(backoff); wait <= 0 || wait > backoff {
+			t.Fatalf("expected a wait in (0, %s], got %s", backoff, wait)
+		}
+	}
+}
+
+func TestServerRetryDelay(t *testing.T) {
+	cases := map[string]struct {
+		resp     *http.Response
+		err      error
+		expected time.Duration
+	}{
+		"no hint": {
+			resp:     &http.Response{Header: http.Header{}, Body: http.NoBody},
+			expected: 0,
+		},
+		"retry-after seconds": {
+			resp:     &http.Response{Header: http.Header{"Retry-After": []string{"7"}}, Body: http.NoBody},
+			expected: 7 * time.Second,
+		},
+		"invalid retry-after": {
+			resp:     &http.Response{Header: http.Header{"Retry-After": []string{"soon"}}, Body: http.NoBody},
+			expected: 0,
+		},
+		"retry info in response body": {
+			resp: &http.Response{
+				Header: http.Header{},
+				Body: ioutil.NopCloser(strings.NewReader(`{"error": {"code": 429, "details": [
+					{"@type": "type.googleapis.com/google.rpc.ErrorInfo", "reason": "RATE_LIMIT_EXCEEDED"},
+					{"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": "1.5s"}
+				]}}`)),
+			},
+			expected: 1500 * time.Millisecond,
+		},
+		"retry info in googleapi error": {
+			err: &googleapi.Error{
+				Code: 503,
+				Details: []interface{}{
+					map[string]interface{}{"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": "3s"},
+				},
+			},
+			expected: 3 * time.Second,
+		},
+	}
+
+	for tn, tc := range cases {
+		if delay := serverRetryDelay(tc.resp, tc.err); delay != tc.expected {
+			t.Errorf("%s: expected delay %s, got %s", tn, tc.expected, delay)
+		}
+		if tc.resp != nil && tc.resp.Body != http.NoBody {
+			if _, err := ioutil.ReadAll(tc.resp.Body); err != nil {
+				t.Errorf("%s: expected response body to remain readable, got: %v", tn, err)
+			}
+		}
+	}
+}
+
+func TestExpandProviderRetryBudget(t *testing.T) {
+	budget, err := ExpandProviderRetryBudget(nil)
+	if err != nil || budget != nil {
+		t.Errorf("expected no budget for an unset block, got %v, %v", budget, err)
+	}
+
+	budget, err = ExpandProviderRetryBudget([]interface{}{
+		map[string]interface{}{
+			"max_retries": 50,
+			"period":      "30s",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if budget.MaxRetries != 50 || budget.Period != 30*time.Second {
+		t.Errorf("expected a budget of 50 retries per 30s, got %s", budget)
+	}
+
+	if _, err := ExpandProviderRetryBudget([]interface{}{
+		map[string]interface{}{
+			"max_retries": 50,
+			"period":      "soon",
+		},
+	}); err == nil {
+		t.Errorf("expected an error for an invalid period")
+	}
+}
+
 // handlers
 func testRetryTransportHandler_noRetries(t *testing.T, code int) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
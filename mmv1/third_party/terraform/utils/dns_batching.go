@@ -0,0 +1,96 @@
+package google
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	transport_tpg "github.com/hashicorp/terraform-provider-google/google/transport"
+	"google.golang.org/api/dns/v1"
+)
+
+const batchKeyTmplDnsChange = "projects/%s/managedZones/%s/changes"
+
+// DnsChangeBatchedService batches changes to record sets of the same managed
+// zone into a single dns.Change, as changes are applied atomically per zone.
+var DnsChangeBatchedService = transport_tpg.RegisterBatchedService(&transport_tpg.BatchedService{
+	Name:     "DNS",
+	CombineF: combineDnsChangeBatches,
+	SendF:    sendBatchDnsChange,
+})
+
+// dnsChangeBatch is the body of a batched DNS change request.
+type dnsChangeBatch struct {
+	Service     *dns.Service
+	Project     string
+	ManagedZone string
+	Change      *dns.Change
+}
+
+// BatchRequestDnsChange applies the record set additions and deletions in chg
+// to a managed zone and waits for the change to be done. Changes to the same
+// zone are batched, e.g. when creating several google_dns_record_set(s).
+func BatchRequestDnsChange(chg *dns.Change, project, zone, userAgent string, config *transport_tpg.Config, timeout time.Duration, reqDesc string) error {
+	req := &transport_tpg.BatchRequest{
+		ResourceName: fmt.Sprintf("projects/%s/managedZones/%s", project, zone),
+		Body: &dnsChangeBatch{
+			Service:     config.NewDnsClient(userAgent),
+			Project:     project,
+			ManagedZone: zone,
+			Change:      chg,
+		},
+		DebugId: reqDesc,
+	}
+
+	_, err := config.RequestBatcher(DnsChangeBatchedService).SendRequestWithTimeout(
+		fmt.Sprintf(batchKeyTmplDnsChange, project, zone),
+		req,
+		timeout)
+	return err
+}
+
+func combineDnsChangeBatches(batchRaw interface{}, toAddRaw interface{}) (interface{}, error) {
+	batch, ok := batchRaw.(*dnsChangeBatch)
+	if !ok {
+		return nil, fmt.Errorf("Expected batch body type to be *dnsChangeBatch, got %v. This is a provider error.", batchRaw)
+	}
+	toAdd, ok := toAddRaw.(*dnsChangeBatch)
+	if !ok {
+		return nil, fmt.Errorf("Expected new request body type to be *dnsChangeBatch, got %v. This is a provider error.", toAddRaw)
+	}
+
+	// Copy the change so the body of the first request in the batch can
+	// still be sent on its own if the combined change fails.
+	combined := *batch
+	combined.Change = &dns.Change{
+		Additions: append(append([]*dns.ResourceRecordSet{}, batch.Change.Additions...), toAdd.Change.Additions...),
+		Deletions: append(append([]*dns.ResourceRecordSet{}, batch.Change.Deletions...), toAdd.Change.Deletions...),
+	}
+	return &combined, nil
+}
+
+func sendBatchDnsChange(resourceName string, body interface{}) (interface{}, error) {
+	batch, ok := body.(*dnsChangeBatch)
+	if !ok {
+		return nil, fmt.Errorf("Expected batch body type to be *dnsChangeBatch, got %v. This is a provider error.", body)
+	}
+
+	chg, err := batch.Service.Changes.Create(batch.Project, batch.ManagedZone, batch.Change).Do()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &DnsChangeWaiter{
+		Service:     batch.Service,
+		Change:      chg,
+		Project:     batch.Project,
+		ManagedZone: batch.ManagedZone,
+	}
+	if _, err := w.Conf().WaitForState(); err != nil {
+		// The change was created, so it must not be resent for each request.
+		return nil, &transport_tpg.BatchAppliedError{
+			Err: errwrap.Wrapf("Error waiting for Google DNS change: {{err}}", err),
+		}
+	}
+	return chg, nil
+}
@@ -0,0 +1,48 @@
+package google
+
+import (
+	"testing"
+
+	"google.golang.org/api/dns/v1"
+)
+
+func TestCombineDnsChangeBatches(t *testing.T) {
+	first := &dnsChangeBatch{
+		Project:     "my-project",
+		ManagedZone: "my-zone",
+		Change: &dns.Change{
+			Additions: []*dns.ResourceRecordSet{{Name: "a.example.com.", Type: "A"}},
+		},
+	}
+	second := &dnsChangeBatch{
+		Project:     "my-project",
+		ManagedZone: "my-zone",
+		Change: &dns.Change{
+			Additions: []*dns.ResourceRecordSet{{Name: "b.example.com.", Type: "A"}},
+			Deletions: []*dns.ResourceRecordSet{{Name: "c.example.com.", Type: "CNAME"}},
+		},
+	}
+
+	combinedRaw, err := combineDnsChangeBatches(first, second)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	combined := combinedRaw.(*dnsChangeBatch)
+	if len(combined.Change.Additions) != 2 || len(combined.Change.Deletions) != 1 {
+		t.Errorf("expected 2 additions and 1 deletion, got %d additions and %d deletions",
+			len(combined.Change.Additions), len(combined.Change.Deletions))
+	}
+	if combined.Project != "my-project" || combined.ManagedZone != "my-zone" {
+		t.Errorf("expected combined change for my-project/my-zone, got %s/%s", combined.Project, combined.ManagedZone)
+	}
+
+	// The first request has to be sendable on its own if the batch fails
+	if len(first.Change.Additions) != 1 || len(first.Change.Deletions) != 0 {
+		t.Errorf("expected the first change not to be modified, got %d additions and %d deletions",
+			len(first.Change.Additions), len(first.Change.Deletions))
+	}
+
+	if _, err := combineDnsChangeBatches(first, []string{"not a change"}); err == nil {
+		t.Errorf("expected an error combining a body of the wrong type")
+	}
+}
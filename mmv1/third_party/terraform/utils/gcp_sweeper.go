@@ -7,7 +7,8 @@ import (
 	transport_tpg "github.com/hashicorp/terraform-provider-google/google/transport"
 )
 
-// List of prefixes used for test resource names
+// List of prefixes used for test resource names. Resources with the ownership
+// labels stamped by the test harness are swept whatever their name.
 var testResourcePrefixes = []string{
 	// tf-test and tf_test are automatically prepended to resource ids in examples that
 	// include a "-" or "_" respectively, and they are the preferred prefix for our test resources to use
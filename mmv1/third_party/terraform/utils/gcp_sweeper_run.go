@@ -0,0 +1,233 @@
+package google
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Environment variables controlling all sweepers
+const (
+	// SweeperDryRunEnvVar makes sweepers list what they would delete instead
+	// of deleting it, if set to any value.
+	SweeperDryRunEnvVar = "SWEEPER_DRY_RUN"
+	// SweeperMinAgeEnvVar is a duration string, such as "2h". Sweepers skip
+	// resources created more recently, as they may belong to tests that are
+	// still running.
+	SweeperMinAgeEnvVar = "SWEEPER_MIN_AGE"
+	// SweeperReportFileEnvVar names a file the JSON summary of every sweeper
+	// run is written to.
+	SweeperReportFileEnvVar = "SWEEPER_REPORT_FILE"
+)
+
+// SweepRun tracks which resources a sweeper deleted, skipped or failed to
+// delete in a region, and applies the dry-run and minimum age settings.
+type SweepRun struct {
+	Sweeper string         `json:"sweeper"`
+	Region  string         `json:"region"`
+	DryRun  bool           `json:"dry_run"`
+	MinAge  string         `json:"min_age"`
+	Deleted []string       `json:"deleted"`
+	Skipped []SweepSkipped `json:"skipped"`
+	Failed  []SweepFailure `json:"failed"`
+	// Leaked lists the swept resources with ownership labels, attributing
+	// them to the test run that created them
+	Leaked []SweepLeaked `json:"leaked"`
+
+	lock   sync.Mutex
+	minAge time.Duration
+	now    func() time.Time
+}
+
+type SweepSkipped struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+type SweepFailure struct {
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+type SweepLeaked struct {
+	Name string `json:"name"`
+	Run  string `json:"run"`
+	Test string `json:"test"`
+}
+
+// NewSweepRun starts tracking a sweeper run for a region. Callers must call
+// Finish once the sweeper is done.
+func NewSweepRun(sweeper, region string) *SweepRun {
+	s := &SweepRun{
+		Sweeper: sweeper,
+		Region:  region,
+		DryRun:  os.Getenv(SweeperDryRunEnvVar) != "",
+		Deleted: []string{},
+		Skipped: []SweepSkipped{},
+		Failed:  []SweepFailure{},
+		Leaked:  []SweepLeaked{},
+		now:     time.Now,
+	}
+	if v := os.Getenv(SweeperMinAgeEnvVar); v != "" {
+		minAge, err := time.ParseDuration(v)
+		if err != nil {
+			log.Printf("[INFO][SWEEPER_LOG] Ignoring invalid %s %q: %s", SweeperMinAgeEnvVar, v, err)
+		} else {
+			s.minAge = minAge
+		}
+	}
+	s.MinAge = s.minAge.String()
+	return s
+}
+
+// ShouldDelete returns whether the sweeper should delete the resource: it
+// must be a test resource created at least the minimum age ago. Resources
+// with the ownership labels stamped by the test harness are test resources
+// whatever their name, otherwise the name must have a test prefix. Resources
+// with an unknown (zero) creation time aren't filtered by age, unless it's in
+// their labels. In dry-run mode, the resource is recorded as deleted but false
+// is returned.
+func (s *SweepRun) ShouldDelete(name string, labels map[string]string, createTime time.Time) bool {
+	return s.shouldDelete(name, labels, createTime, false)
+}
+
+// ShouldDeleteOwned is ShouldDelete for resources the sweeper already knows
+// are test resources whatever their name, such as the resources of a
+// dedicated test organization. Only the minimum age and dry-run settings are
+// applied.
+func (s *SweepRun) ShouldDeleteOwned(name string, labels map[string]string, createTime time.Time) bool {
+	return s.shouldDelete(name, labels, createTime, true)
+}
+
+func (s *SweepRun) shouldDelete(name string, labels map[string]string, createTime time.Time, owned bool) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	run := labels[TestRunLabel]
+	if run == "" && !owned && !IsSweepableTestResource(name) {
+		s.Skipped = append(s.Skipped, SweepSkipped{Name: name, Reason: "not a test resource"})
+		return false
+	}
+	if createTime.IsZero() && run != "" {
+		if sec, err := strconv.ParseInt(labels[TestCreatedLabel], 10, 64); err == nil {
+			createTime = time.Unix(sec, 0)
+		}
+	}
+	if !createTime.IsZero() && s.now().Sub(createTime) < s.minAge {
+		s.Skipped = append(s.Skipped, SweepSkipped{Name: name, Reason: "created less than " + s.MinAge + " ago"})
+		return false
+	}
+	if run != "" {
+		log.Printf("[INFO][SWEEPER_LOG] %s resource %s was leaked by test %s in run %s", s.Sweeper, name, labels[TestNameLabel], run)
+		s.Leaked = append(s.Leaked, SweepLeaked{Name: name, Run: run, Test: labels[TestNameLabel]})
+	}
+	if s.DryRun {
+		log.Printf("[INFO][SWEEPER_LOG] Dry run, would delete %s resource: %s", s.Sweeper, name)
+		s.Deleted = append(s.Deleted, name)
+		return false
+	}
+	return true
+}
+
+// RecordDeleted records a resource the sweeper sent a delete request for.
+func (s *SweepRun) RecordDeleted(name string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Deleted = append(s.Deleted, name)
+}
+
+// RecordFailed records a resource the sweeper failed to delete.
+func (s *SweepRun) RecordFailed(name string, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Failed = append(s.Failed, SweepFailure{Name: name, Error: err.Error()})
+}
+
+// Finish logs a summary of the run and adds it to the report file, if any.
+func (s *SweepRun) Finish() {
+	s.lock.Lock()
+	verb := "Deleted"
+	if s.DryRun {
+		verb = "Dry run, would have deleted"
+	}
+	log.Printf("[INFO][SWEEPER_LOG] %s %d %s resources in %s, skipped %d and failed to delete %d.", verb, len(s.Deleted), s.Sweeper, s.Region, len(s.Skipped), len(s.Failed))
+	s.lock.Unlock()
+
+	sweepReport.add(s)
+}
+
+// sweepReport collects the runs of every sweeper in the process, as they are
+// run one after another by resource.TestMain.
+var sweepReport = &sweepRuns{}
+
+type sweepRuns struct {
+	sync.Mutex
+	runs []*SweepRun
+}
+
+func (r *sweepRuns) add(s *SweepRun) {
+	r.Lock()
+	defer r.Unlock()
+	r.runs = append(r.runs, s)
+
+	path := os.Getenv(SweeperReportFileEnvVar)
+	if path == "" {
+		return
+	}
+	sort.SliceStable(r.runs, func(i, j int) bool {
+		if r.runs[i].Sweeper != r.runs[j].Sweeper {
+			return r.runs[i].Sweeper < r.runs[j].Sweeper
+		}
+		return r.runs[i].Region < r.runs[j].Region
+	})
+	data, err := json.MarshalIndent(map[string]interface{}{"sweepers": r.runs}, "", "  ")
+	if err != nil {
+		log.Printf("[INFO][SWEEPER_LOG] Unable to encode sweeper report: %s", err)
+		return
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		log.Printf("[INFO][SWEEPER_LOG] Unable to write sweeper report to %s: %s", path, err)
+	}
+}
+
+// SweeperObjectCreateTime returns the creation time of a resource in a list
+// response, or the zero time if it has none.
+func SweeperObjectCreateTime(obj map[string]interface{}) time.Time {
+	for _, k := range []string{"creationTimestamp", "createTime", "timeCreated", "creationTime"} {
+		if v, ok := obj[k].(string); ok {
+			return SweeperParseTime(&v)
+		}
+	}
+	return time.Time{}
+}
+
+// SweeperObjectLabels returns the labels of a resource in a list response.
+func SweeperObjectLabels(obj map[string]interface{}) map[string]string {
+	labels := make(map[string]string)
+	if m, ok := obj["labels"].(map[string]interface{}); ok {
+		for k, v := range m {
+			if s, ok := v.(string); ok {
+				labels[k] = s
+			}
+		}
+	}
+	return labels
+}
+
+// SweeperParseTime parses an RFC 3339 creation time, returning the zero time
+// if it's unset or invalid.
+func SweeperParseTime(v *string) time.Time {
+	if v == nil || *v == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, *v)
+	if err != nil {
+		log.Printf("[INFO][SWEEPER_LOG] Unable to parse creation time %q: %s", *v, err)
+		return time.Time{}
+	}
+	return t
+}
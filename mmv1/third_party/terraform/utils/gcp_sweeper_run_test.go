@@ -0,0 +1,168 @@
+package google
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSweepRun_ShouldDelete(t *testing.T) {
+	t.Setenv(SweeperDryRunEnvVar, "")
+	t.Setenv(SweeperMinAgeEnvVar, "1h")
+
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	sweep := NewSweepRun("ComputeDisk", "us-central1")
+	sweep.now = func() time.Time { return now }
+
+	oldLabels := testResourceLabels("TestAccComputeDisk_basic", now.Add(-2*time.Hour))
+	newLabels := testResourceLabels("TestAccComputeDisk_basic", now.Add(-time.Minute))
+
+	cases := map[string]struct {
+		name       string
+		labels     map[string]string
+		createTime time.Time
+		want       bool
+	}{
+		"old test resource":        {name: "tf-test-old", createTime: now.Add(-2 * time.Hour), want: true},
+		"new test resource":        {name: "tf-test-new", createTime: now.Add(-time.Minute), want: false},
+		"unknown creation time":    {name: "tf-test-unknown", want: true},
+		"not a test resource":      {name: "production-disk", createTime: now.Add(-2 * time.Hour), want: false},
+		"old labeled resource":     {name: "unprefixed-old", labels: oldLabels, want: true},
+		"new labeled resource":     {name: "unprefixed-new", labels: newLabels, want: false},
+		"labeled with create time": {name: "unprefixed-created", labels: newLabels, createTime: now.Add(-2 * time.Hour), want: true},
+	}
+	for tn, tc := range cases {
+		if got := sweep.ShouldDelete(tc.name, tc.labels, tc.createTime); got != tc.want {
+			t.Errorf("%s: expected %t, got %t", tn, tc.want, got)
+		}
+	}
+	if len(sweep.Skipped) != 3 {
+		t.Errorf("expected 3 skipped resources, got %v", sweep.Skipped)
+	}
+	if len(sweep.Leaked) != 2 || sweep.Leaked[0].Test != "testacccomputedisk_basic" {
+		t.Errorf("expected the labeled resources to be attributed to their test, got %v", sweep.Leaked)
+	}
+}
+
+func TestSweepRun_ShouldDeleteOwned(t *testing.T) {
+	t.Setenv(SweeperDryRunEnvVar, "")
+	t.Setenv(SweeperMinAgeEnvVar, "1h")
+
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	sweep := NewSweepRun("AccessContextManagerPolicy", "us-central1")
+	sweep.now = func() time.Time { return now }
+
+	if !sweep.ShouldDeleteOwned("accessPolicies/123", nil, now.Add(-2*time.Hour)) {
+		t.Errorf("expected an old owned resource to be deleted whatever its name")
+	}
+	if sweep.ShouldDeleteOwned("accessPolicies/456", nil, now.Add(-time.Minute)) {
+		t.Errorf("expected a new owned resource to be skipped")
+	}
+	if len(sweep.Skipped) != 1 || sweep.Skipped[0].Name != "accessPolicies/456" {
+		t.Errorf("expected the new resource to be skipped, got %v", sweep.Skipped)
+	}
+}
+
+func TestSweepRun_dryRun(t *testing.T) {
+	t.Setenv(SweeperDryRunEnvVar, "true")
+	t.Setenv(SweeperMinAgeEnvVar, "")
+
+	sweep := NewSweepRun("ComputeDisk", "us-central1")
+	if sweep.ShouldDelete("tf-test-disk", nil, time.Time{}) {
+		t.Errorf("expected nothing to be deleted in dry-run mode")
+	}
+	if len(sweep.Deleted) != 1 || sweep.Deleted[0] != "tf-test-disk" {
+		t.Errorf("expected the resource to be listed as deleted, got %v", sweep.Deleted)
+	}
+}
+
+func TestSweepRun_report(t *testing.T) {
+	reportPath := filepath.Join(t.TempDir(), "sweepers.json")
+	t.Setenv(SweeperReportFileEnvVar, reportPath)
+	t.Setenv(SweeperDryRunEnvVar, "")
+	t.Setenv(SweeperMinAgeEnvVar, "")
+	sweepReport = &sweepRuns{}
+	defer func() { sweepReport = &sweepRuns{} }()
+
+	for _, region := range []string{"us-west1", "us-central1"} {
+		sweep := NewSweepRun("ComputeDisk", region)
+		sweep.RecordDeleted("tf-test-deleted")
+		sweep.RecordFailed("tf-test-failed", fmt.Errorf("resource is in use"))
+		sweep.ShouldDelete("production-disk", nil, time.Time{})
+		sweep.Finish()
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("unable to read report: %v", err)
+	}
+	var report struct {
+		Sweepers []struct {
+			Sweeper string         `json:"sweeper"`
+			Region  string         `json:"region"`
+			Deleted []string       `json:"deleted"`
+			Skipped []SweepSkipped `json:"skipped"`
+			Failed  []SweepFailure `json:"failed"`
+		} `json:"sweepers"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("unable to decode report: %v", err)
+	}
+	if len(report.Sweepers) != 2 {
+		t.Fatalf("expected a summary per region, got %d", len(report.Sweepers))
+	}
+	s := report.Sweepers[0]
+	if s.Sweeper != "ComputeDisk" || s.Region != "us-central1" {
+		t.Errorf("expected summaries sorted by sweeper and region, got %s in %s first", s.Sweeper, s.Region)
+	}
+	if len(s.Deleted) != 1 || len(s.Skipped) != 1 || len(s.Failed) != 1 {
+		t.Errorf("expected 1 deleted, skipped and failed resource, got %+v", s)
+	}
+	if s.Failed[0].Error != "resource is in use" {
+		t.Errorf("expected the failure to be reported, got %q", s.Failed[0].Error)
+	}
+}
+
+func TestSweeperObjectCreateTime(t *testing.T) {
+	cases := map[string]struct {
+		obj  map[string]interface{}
+		want time.Time
+	}{
+		"compute": {
+			obj:  map[string]interface{}{"creationTimestamp": "2023-01-01T04:00:00.000-08:00"},
+			want: time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC),
+		},
+		"create time": {
+			obj:  map[string]interface{}{"createTime": "2023-01-01T12:00:00.123456Z"},
+			want: time.Date(2023, 1, 1, 12, 0, 0, 123456000, time.UTC),
+		},
+		"none": {
+			obj: map[string]interface{}{"name": "tf-test"},
+		},
+		"invalid": {
+			obj: map[string]interface{}{"createTime": "yesterday"},
+		},
+	}
+	for tn, tc := range cases {
+		if got := SweeperObjectCreateTime(tc.obj); !got.Equal(tc.want) {
+			t.Errorf("%s: expected %s, got %s", tn, tc.want, got)
+		}
+	}
+}
+
+func TestSweeperObjectLabels(t *testing.T) {
+	obj := map[string]interface{}{
+		"name":   "disk",
+		"labels": map[string]interface{}{TestRunLabel: "1234", "env": "test"},
+	}
+	labels := SweeperObjectLabels(obj)
+	if labels[TestRunLabel] != "1234" || labels["env"] != "test" {
+		t.Errorf("expected the labels of the object, got %v", labels)
+	}
+	if labels := SweeperObjectLabels(map[string]interface{}{"name": "disk"}); len(labels) != 0 {
+		t.Errorf("expected no labels, got %v", labels)
+	}
+}
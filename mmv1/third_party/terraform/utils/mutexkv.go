@@ -1,8 +1,14 @@
 package google
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"runtime"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
 // MutexKV is a simple key/value store for arbitrary mutexes. It can be used to
@@ -11,23 +17,64 @@ import (
 //
 // The initial use case is to let aws_security_group_rule resources serialize
 // their access to individual security groups based on SG ID.
+//
+// MutexKV keeps track of who holds and who waits for each key, so a lock that
+// can't be acquired before its context is done fails with a dump of the
+// current lock holders rather than hanging.
 type MutexKV struct {
 	lock  sync.Mutex
-	store map[string]*sync.RWMutex
+	store map[string]*mutexKVEntry
+}
+
+type mutexKVEntry struct {
+	mutex   sync.RWMutex
+	holders []*lockOwner
+	waiters []*lockOwner
+}
+
+// lockOwner is a caller holding or waiting for a key.
+type lockOwner struct {
+	caller string
+	read   bool
+	since  time.Time
+
+	// acquired and abandoned are guarded by MutexKV.lock
+	acquired  bool
+	abandoned bool
+}
+
+func (o *lockOwner) String() string {
+	mode := "lock"
+	if o.read {
+		mode = "read-lock"
+	}
+	return fmt.Sprintf("%s (%s, %s)", o.caller, mode, time.Since(o.since).Round(time.Second))
 }
 
 // Locks the mutex for the given key. Caller is responsible for calling Unlock
 // for the same key
 func (m *MutexKV) Lock(key string) {
 	log.Printf("[DEBUG] Locking %q", key)
-	m.get(key).Lock()
+	m.acquire(context.Background(), key, false)
 	log.Printf("[DEBUG] Locked %q", key)
 }
 
+// LockContext locks the mutex for the given key, or returns an error if ctx is
+// done first. Caller is responsible for calling Unlock for the same key if no
+// error is returned
+func (m *MutexKV) LockContext(ctx context.Context, key string) error {
+	log.Printf("[DEBUG] Locking %q", key)
+	if err := m.acquire(ctx, key, false); err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] Locked %q", key)
+	return nil
+}
+
 // Unlock the mutex for the given key. Caller must have called Lock for the same key first
 func (m *MutexKV) Unlock(key string) {
 	log.Printf("[DEBUG] Unlocking %q", key)
-	m.get(key).Unlock()
+	m.release(key, false)
 	log.Printf("[DEBUG] Unlocked %q", key)
 }
 
@@ -35,32 +82,259 @@ func (m *MutexKV) Unlock(key string) {
 // for the same key
 func (m *MutexKV) RLock(key string) {
 	log.Printf("[DEBUG] RLocking %q", key)
-	m.get(key).RLock()
+	m.acquire(context.Background(), key, true)
+	log.Printf("[DEBUG] RLocked %q", key)
+}
+
+// RLockContext acquires a read-lock on the mutex for the given key, or returns
+// an error if ctx is done first. Caller is responsible for calling RUnlock for
+// the same key if no error is returned
+func (m *MutexKV) RLockContext(ctx context.Context, key string) error {
+	log.Printf("[DEBUG] RLocking %q", key)
+	if err := m.acquire(ctx, key, true); err != nil {
+		return err
+	}
 	log.Printf("[DEBUG] RLocked %q", key)
+	return nil
 }
 
 // Releases a read-lock on the mutex for the given key. Caller must have called RLock for the same key first
 func (m *MutexKV) RUnlock(key string) {
 	log.Printf("[DEBUG] RUnlocking %q", key)
-	m.get(key).RUnlock()
+	m.release(key, true)
 	log.Printf("[DEBUG] RUnlocked %q", key)
 }
 
-// Returns a mutex for the given key, no guarantee of its lock status
-func (m *MutexKV) get(key string) *sync.RWMutex {
+// LockAll locks the mutexes for all the given keys, in sorted order so callers
+// locking overlapping sets of keys can't deadlock each other. If a key can't be
+// locked before ctx is done, the keys locked so far are unlocked and an error
+// is returned. Otherwise, caller is responsible for calling the returned func
+// to unlock all the keys.
+func (m *MutexKV) LockAll(ctx context.Context, keys ...string) (func(), error) {
+	keys = sortedUniqueKeys(keys)
+
+	var locked []string
+	unlock := func() {
+		for i := len(locked) - 1; i >= 0; i-- {
+			m.Unlock(locked[i])
+		}
+	}
+	for _, key := range keys {
+		if err := m.LockContext(ctx, key); err != nil {
+			unlock()
+			return nil, err
+		}
+		locked = append(locked, key)
+	}
+	return unlock, nil
+}
+
+// LockAllWithTimeout is LockAll with a context that expires after timeout,
+// usually the timeout of the resource operation the keys are locked for.
+func (m *MutexKV) LockAllWithTimeout(timeout time.Duration, keys ...string) (func(), error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return m.LockAll(ctx, keys...)
+}
+
+// acquire locks the mutex for key, tracking the caller as a waiter until the
+// lock is acquired and as a holder after.
+//
+// A sync.RWMutex can't be waited for with a deadline, so when ctx can be done
+// the mutex is waited for in a goroutine. If ctx is done first, that goroutine
+// keeps waiting until the holders release the key, then unlocks it straight
+// away. Contexts that are never done, like the one used by Lock and RLock, wait
+// for the mutex directly.
+func (m *MutexKV) acquire(ctx context.Context, key string, read bool) error {
+	owner := &lockOwner{
+		caller: lockCaller(),
+		read:   read,
+		since:  time.Now(),
+	}
+
+	m.lock.Lock()
+	entry := m.getLocked(key)
+	entry.waiters = append(entry.waiters, owner)
+	m.lock.Unlock()
+
+	lockMutex := func() {
+		if read {
+			entry.mutex.RLock()
+		} else {
+			entry.mutex.Lock()
+		}
+	}
+
+	if ctx.Done() == nil {
+		lockMutex()
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		entry.markAcquiredLocked(owner)
+		return nil
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		defer close(acquired)
+		lockMutex()
+
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		if owner.abandoned {
+			// The caller gave up waiting, so nobody will unlock the mutex
+			if read {
+				entry.mutex.RUnlock()
+			} else {
+				entry.mutex.Unlock()
+			}
+			return
+		}
+		entry.markAcquiredLocked(owner)
+	}()
+
+	select {
+	case <-acquired:
+		return nil
+	case <-ctx.Done():
+	}
+
+	m.lock.Lock()
+	if owner.acquired {
+		// The lock was acquired as ctx was done
+		m.lock.Unlock()
+		return nil
+	}
+	owner.abandoned = true
+	entry.waiters = removeLockOwner(entry.waiters, owner)
+	holders := describeLockOwners(entry.holders)
+	dump := m.dumpLocked()
+	m.lock.Unlock()
+
+	log.Printf("[WARN] Timed out waiting for lock %q held by %s. Held and waiting keys:\n%s", key, holders, dump)
+	return fmt.Errorf("Error acquiring lock %q held by %s: %w", key, holders, ctx.Err())
+}
+
+// release unlocks the mutex for key and stops tracking its oldest holder for
+// the lock mode.
+func (m *MutexKV) release(key string, read bool) {
+	m.lock.Lock()
+	entry := m.getLocked(key)
+	for i, owner := range entry.holders {
+		if owner.read == read {
+			entry.holders = append(entry.holders[:i:i], entry.holders[i+1:]...)
+			break
+		}
+	}
+	m.lock.Unlock()
+
+	if read {
+		entry.mutex.RUnlock()
+	} else {
+		entry.mutex.Unlock()
+	}
+}
+
+// Dump describes the holders and waiters of all the keys currently held or
+// waited for, one key per line.
+func (m *MutexKV) Dump() string {
 	m.lock.Lock()
 	defer m.lock.Unlock()
-	mutex, ok := m.store[key]
+	return m.dumpLocked()
+}
+
+func (m *MutexKV) dumpLocked() string {
+	keys := make([]string, 0, len(m.store))
+	for key, entry := range m.store {
+		if len(entry.holders) > 0 || len(entry.waiters) > 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var lines []string
+	for _, key := range keys {
+		entry := m.store[key]
+		line := fmt.Sprintf("%q: held by %s", key, describeLockOwners(entry.holders))
+		if len(entry.waiters) > 0 {
+			line += fmt.Sprintf(", waited for by %s", describeLockOwners(entry.waiters))
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// markAcquiredLocked moves owner from the waiters to the holders of the entry.
+// Caller must hold MutexKV.lock
+func (entry *mutexKVEntry) markAcquiredLocked(owner *lockOwner) {
+	owner.acquired = true
+	owner.since = time.Now()
+	entry.waiters = removeLockOwner(entry.waiters, owner)
+	entry.holders = append(entry.holders, owner)
+}
+
+// Returns the entry for the given key, no guarantee of its lock status. Caller
+// must hold m.lock
+func (m *MutexKV) getLocked(key string) *mutexKVEntry {
+	entry, ok := m.store[key]
 	if !ok {
-		mutex = &sync.RWMutex{}
-		m.store[key] = mutex
+		entry = &mutexKVEntry{}
+		m.store[key] = entry
+	}
+	return entry
+}
+
+func describeLockOwners(owners []*lockOwner) string {
+	if len(owners) == 0 {
+		return "nobody"
+	}
+	descs := make([]string, 0, len(owners))
+	for _, o := range owners {
+		descs = append(descs, o.String())
+	}
+	return strings.Join(descs, "; ")
+}
+
+func removeLockOwner(owners []*lockOwner, owner *lockOwner) []*lockOwner {
+	for i, o := range owners {
+		if o == owner {
+			return append(owners[:i:i], owners[i+1:]...)
+		}
+	}
+	return owners
+}
+
+func sortedUniqueKeys(keys []string) []string {
+	sorted := append([]string{}, keys...)
+	sort.Strings(sorted)
+	unique := sorted[:0]
+	for i, key := range sorted {
+		if i == 0 || key != sorted[i-1] {
+			unique = append(unique, key)
+		}
+	}
+	return unique
+}
+
+// lockCaller returns the first function outside of MutexKV on the stack, to
+// describe who holds or waits for a lock.
+func lockCaller() string {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.Contains(frame.Function, "(*MutexKV)") && !strings.HasSuffix(frame.Function, ".lockedCallContext") {
+			name := frame.Function[strings.LastIndex(frame.Function, "/")+1:]
+			return fmt.Sprintf("%s (%s:%d)", name, frame.File[strings.LastIndex(frame.File, "/")+1:], frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
 	}
-	return mutex
 }
 
 // Returns a properly initialized MutexKV
 func NewMutexKV() *MutexKV {
 	return &MutexKV{
-		store: make(map[string]*sync.RWMutex),
+		store: make(map[string]*mutexKVEntry),
 	}
 }
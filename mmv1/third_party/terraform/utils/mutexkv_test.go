@@ -0,0 +1,169 @@
+package google
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMutexKV_LockContextTimeout(t *testing.T) {
+	m := NewMutexKV()
+	m.Lock("router/us-central1/my-router")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := m.LockContext(ctx, "router/us-central1/my-router")
+	if err == nil {
+		t.Fatalf("expected an error locking a held key")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the error to wrap the context error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "TestMutexKV_LockContextTimeout") {
+		t.Errorf("expected the error to name the holder of the key, got %v", err)
+	}
+
+	// The abandoned waiter must not keep the key locked
+	m.Unlock("router/us-central1/my-router")
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := m.LockContext(ctx, "router/us-central1/my-router"); err != nil {
+		t.Fatalf("expected the key to be unlocked, got %v", err)
+	}
+	m.Unlock("router/us-central1/my-router")
+
+	if dump := m.Dump(); dump != "" {
+		t.Errorf("expected no held or waiting keys, got %q", dump)
+	}
+}
+
+func TestMutexKV_LockWaitsForHolder(t *testing.T) {
+	m := NewMutexKV()
+	m.Lock("cluster")
+
+	locked := make(chan struct{})
+	go func() {
+		m.Lock("cluster")
+		close(locked)
+	}()
+
+	// Lock waits without a deadline, but is still listed as waiting
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(m.Dump(), "waited for by") {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected Lock to wait for the key, got %q", m.Dump())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	m.Unlock("cluster")
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatalf("expected Lock to acquire the key once it was unlocked")
+	}
+	if dump := m.Dump(); strings.Contains(dump, "waited for by") || !strings.Contains(dump, "held by") {
+		t.Errorf("expected the key to be held without waiters, got %q", dump)
+	}
+	m.Unlock("cluster")
+}
+
+func TestMutexKV_RLockContext(t *testing.T) {
+	m := NewMutexKV()
+	m.RLock("cluster")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := m.RLockContext(ctx, "cluster"); err != nil {
+		t.Fatalf("expected read-locks to be shared, got %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := m.LockContext(ctx, "cluster"); err == nil {
+		t.Fatalf("expected an error locking a read-locked key")
+	}
+
+	m.RUnlock("cluster")
+	m.RUnlock("cluster")
+	if dump := m.Dump(); dump != "" {
+		t.Errorf("expected no held or waiting keys, got %q", dump)
+	}
+}
+
+func TestMutexKV_LockAll(t *testing.T) {
+	m := NewMutexKV()
+
+	// Lock overlapping keys in opposite orders, as for both sides of a network
+	// peering, which deadlocks without sorting the keys
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		for _, keys := range [][]string{{"network-a", "network-b"}, {"network-b", "network-a", "network-b"}} {
+			go func(keys []string) {
+				defer wg.Done()
+				unlock, err := m.LockAllWithTimeout(5*time.Second, keys...)
+				if err != nil {
+					errs <- err
+					return
+				}
+				unlock()
+			}(keys)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMutexKV_LockAllTimeoutUnlocksKeys(t *testing.T) {
+	m := NewMutexKV()
+	m.Lock("b")
+
+	if _, err := m.LockAllWithTimeout(100*time.Millisecond, "c", "b", "a"); err == nil {
+		t.Fatalf("expected an error locking a held key")
+	}
+
+	dump := m.Dump()
+	if strings.Contains(dump, `"a"`) || strings.Contains(dump, `"c"`) {
+		t.Errorf("expected keys locked before the timeout to be unlocked, got %q", dump)
+	}
+	if !strings.HasPrefix(dump, `"b": held by `) {
+		t.Errorf("expected the dump to show the holder of b, got %q", dump)
+	}
+	m.Unlock("b")
+}
+
+func TestMutexKV_Dump(t *testing.T) {
+	m := NewMutexKV()
+	m.Lock("held")
+
+	waiting := make(chan struct{})
+	go func() {
+		m.Lock("held")
+		m.Unlock("held")
+		close(waiting)
+	}()
+
+	var dump string
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if dump = m.Dump(); strings.Contains(dump, "waited for by") {
+			break
+		}
+	}
+	if !strings.Contains(dump, `"held": held by `) || !strings.Contains(dump, "waited for by") {
+		t.Errorf("expected the dump to show the holder and waiter of the key, got %q", dump)
+	}
+	if !strings.Contains(dump, "mutexkv_test.go") {
+		t.Errorf("expected the dump to name the callers, got %q", dump)
+	}
+
+	m.Unlock("held")
+	<-waiting
+}
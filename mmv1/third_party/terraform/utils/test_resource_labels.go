@@ -0,0 +1,240 @@
+package google
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// TestRunIdEnvVar identifies the CI run acceptance tests are part of, such as
+// a build ID. It's stamped on the resources tests create so leaked resources
+// can be traced back to the run.
+const TestRunIdEnvVar = "GOOGLE_TEST_RUN_ID"
+
+// Labels stamped on the resources in testLabeledResources created by an
+// acceptance test. Sweepers treat resources with the run label as test
+// resources, whatever their name.
+const (
+	TestRunLabel     = "tf-test-run"
+	TestNameLabel    = "tf-test-name"
+	TestCreatedLabel = "tf-test-created"
+)
+
+var testResourceLabelKeys = []string{TestRunLabel, TestNameLabel, TestCreatedLabel}
+
+// testRunId is the run ID of this test process if TestRunIdEnvVar isn't set.
+var testRunId = fmt.Sprintf("local-%d-%d", time.Now().Unix(), os.Getpid())
+
+var invalidLabelValueChars = regexp.MustCompile(`[^a-z0-9_-]`)
+
+// testLabelValue converts s to a valid label value, made of at most 63
+// lowercase letters, numbers, underscores and dashes.
+func testLabelValue(s string) string {
+	v := invalidLabelValueChars.ReplaceAllString(strings.ToLower(s), "_")
+	if len(v) > 63 {
+		v = v[:63]
+	}
+	return v
+}
+
+// testResourceLabels returns the ownership labels for a resource created now
+// by the test testName.
+func testResourceLabels(testName string, now time.Time) map[string]string {
+	runId := os.Getenv(TestRunIdEnvVar)
+	if runId == "" {
+		runId = testRunId
+	}
+	return map[string]string{
+		TestRunLabel:     testLabelValue(runId),
+		TestNameLabel:    testLabelValue(testName),
+		TestCreatedLabel: strconv.FormatInt(now.Unix(), 10),
+	}
+}
+
+// testLabeledResources lists the resources whose top-level `labels` field holds
+// the GCP resource labels, which can be stamped with the ownership labels.
+// Other resources use `labels` for type-specific settings, such as
+// google_monitoring_notification_channel, so they're only stamped once added
+// here. Resources missing from the provider version are ignored.
+var testLabeledResources = map[string]bool{
+	"google_artifact_registry_repository": true,
+	"google_bigquery_dataset":             true,
+	"google_bigquery_table":               true,
+	"google_bigtable_instance":            true,
+	"google_cloudfunctions_function":      true,
+	"google_composer_environment":         true,
+	"google_compute_address":              true,
+	"google_compute_disk":                 true,
+	"google_compute_forwarding_rule":      true,
+	"google_compute_global_address":       true,
+	"google_compute_image":                true,
+	"google_compute_instance":             true,
+	"google_compute_instance_template":    true,
+	"google_compute_region_disk":          true,
+	"google_compute_snapshot":             true,
+	"google_compute_vpn_tunnel":           true,
+	"google_dataproc_cluster":             true,
+	"google_dns_managed_zone":             true,
+	"google_filestore_instance":           true,
+	"google_kms_crypto_key":               true,
+	"google_memcache_instance":            true,
+	"google_project":                      true,
+	"google_pubsub_subscription":          true,
+	"google_pubsub_topic":                 true,
+	"google_redis_instance":               true,
+	"google_secret_manager_secret":        true,
+	"google_spanner_instance":             true,
+	"google_storage_bucket":               true,
+	"google_workflows_workflow":           true,
+}
+
+// isLabelsSchema returns whether s is a user-settable map of string labels.
+func isLabelsSchema(s *schema.Schema) bool {
+	if s == nil || s.Type != schema.TypeMap || !s.Optional {
+		return false
+	}
+	if e, ok := s.Elem.(*schema.Schema); ok && e.Type != schema.TypeString {
+		return false
+	}
+	return true
+}
+
+// stampTestResourceLabels wraps the resources of prov listed in
+// testLabeledResources so that the resources testName creates carry the
+// ownership labels. The labels are hidden from state, so test configs and
+// plans don't need to know about them. Data sources of the same name hide
+// the labels too.
+func stampTestResourceLabels(prov *schema.Provider, testName string) {
+	for name := range testLabeledResources {
+		if r, ok := prov.ResourcesMap[name]; ok && isLabelsSchema(r.Schema["labels"]) {
+			wrapTestResourceLabels(r, testName)
+		}
+		if r, ok := prov.DataSourcesMap[name]; ok && isLabelsSchema(r.Schema["labels"]) {
+			wrapTestResourceLabelsRead(r)
+		}
+	}
+}
+
+func wrapTestResourceLabels(r *schema.Resource, testName string) {
+	if create := r.Create; create != nil {
+		r.Create = func(d *schema.ResourceData, meta interface{}) error {
+			if err := addTestResourceLabels(d, testName); err != nil {
+				return err
+			}
+			err := create(d, meta)
+			return errorsOr(err, removeTestResourceLabels(d))
+		}
+	}
+	if create := r.CreateContext; create != nil {
+		r.CreateContext = func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+			if err := addTestResourceLabels(d, testName); err != nil {
+				return diag.FromErr(err)
+			}
+			diags := create(ctx, d, meta)
+			if err := removeTestResourceLabels(d); err != nil {
+				diags = append(diags, diag.FromErr(err)...)
+			}
+			return diags
+		}
+	}
+
+	// Labels set in the config replace those on the resource, so they're
+	// stamped again when they change.
+	if update := r.Update; update != nil {
+		r.Update = func(d *schema.ResourceData, meta interface{}) error {
+			if d.HasChange("labels") {
+				if err := addTestResourceLabels(d, testName); err != nil {
+					return err
+				}
+			}
+			err := update(d, meta)
+			return errorsOr(err, removeTestResourceLabels(d))
+		}
+	}
+	if update := r.UpdateContext; update != nil {
+		r.UpdateContext = func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+			if d.HasChange("labels") {
+				if err := addTestResourceLabels(d, testName); err != nil {
+					return diag.FromErr(err)
+				}
+			}
+			diags := update(ctx, d, meta)
+			if err := removeTestResourceLabels(d); err != nil {
+				diags = append(diags, diag.FromErr(err)...)
+			}
+			return diags
+		}
+	}
+
+	wrapTestResourceLabelsRead(r)
+}
+
+func wrapTestResourceLabelsRead(r *schema.Resource) {
+	if read := r.Read; read != nil {
+		r.Read = func(d *schema.ResourceData, meta interface{}) error {
+			err := read(d, meta)
+			return errorsOr(err, removeTestResourceLabels(d))
+		}
+	}
+	if read := r.ReadContext; read != nil {
+		r.ReadContext = func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+			diags := read(ctx, d, meta)
+			if err := removeTestResourceLabels(d); err != nil {
+				diags = append(diags, diag.FromErr(err)...)
+			}
+			return diags
+		}
+	}
+}
+
+func addTestResourceLabels(d *schema.ResourceData, testName string) error {
+	labels := make(map[string]interface{})
+	for k, v := range d.Get("labels").(map[string]interface{}) {
+		labels[k] = v
+	}
+	for k, v := range testResourceLabels(testName, time.Now()) {
+		if _, ok := labels[k]; !ok {
+			labels[k] = v
+		}
+	}
+	if err := d.Set("labels", labels); err != nil {
+		return fmt.Errorf("Error stamping test labels: %s", err)
+	}
+	return nil
+}
+
+func removeTestResourceLabels(d *schema.ResourceData) error {
+	labels, ok := d.Get("labels").(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	found := false
+	for _, k := range testResourceLabelKeys {
+		if _, ok := labels[k]; ok {
+			delete(labels, k)
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+	if err := d.Set("labels", labels); err != nil {
+		return fmt.Errorf("Error hiding test labels: %s", err)
+	}
+	return nil
+}
+
+// errorsOr returns err, or other if err is nil.
+func errorsOr(err, other error) error {
+	if err != nil {
+		return err
+	}
+	return other
+}
@@ -0,0 +1,131 @@
+package google
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestTestLabelValue(t *testing.T) {
+	cases := map[string]string{
+		"TestAccComputeDisk_basic":        "testacccomputedisk_basic",
+		"TestAccLoggingFolderExclusion/a": "testaccloggingfolderexclusion_a",
+		"build 1234.5":                    "build_1234_5",
+		strings.Repeat("a", 70):           strings.Repeat("a", 63),
+	}
+	for in, expected := range cases {
+		if got := testLabelValue(in); got != expected {
+			t.Errorf("testLabelValue(%q): expected %q, got %q", in, expected, got)
+		}
+	}
+}
+
+func TestTestResourceLabels(t *testing.T) {
+	t.Setenv(TestRunIdEnvVar, "Build-1234")
+
+	labels := testResourceLabels("TestAccComputeDisk_basic", time.Unix(1680000000, 0))
+	expected := map[string]string{
+		TestRunLabel:     "build-1234",
+		TestNameLabel:    "testacccomputedisk_basic",
+		TestCreatedLabel: "1680000000",
+	}
+	if !reflect.DeepEqual(labels, expected) {
+		t.Errorf("expected %v, got %v", expected, labels)
+	}
+}
+
+func TestStampTestResourceLabels(t *testing.T) {
+	t.Setenv(TestRunIdEnvVar, "build-1234")
+
+	// apiLabels stands in for the labels of the resource in the API
+	var apiLabels map[string]interface{}
+	r := &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"labels": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+		Create: func(d *schema.ResourceData, meta interface{}) error {
+			apiLabels = d.Get("labels").(map[string]interface{})
+			d.SetId("resource")
+			return d.Set("labels", apiLabels)
+		},
+		Read: func(d *schema.ResourceData, meta interface{}) error {
+			return d.Set("labels", apiLabels)
+		},
+	}
+	unlabeled := &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"name": {Type: schema.TypeString, Optional: true},
+		},
+		Create: func(d *schema.ResourceData, meta interface{}) error {
+			return nil
+		},
+	}
+	// The labels of a notification channel are its type-specific settings
+	var channelLabels map[string]interface{}
+	channel := &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"labels": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+		Create: func(d *schema.ResourceData, meta interface{}) error {
+			channelLabels = d.Get("labels").(map[string]interface{})
+			return nil
+		},
+	}
+	prov := &schema.Provider{
+		ResourcesMap: map[string]*schema.Resource{
+			"google_compute_disk":                    r,
+			"google_compute_network":                 unlabeled,
+			"google_monitoring_notification_channel": channel,
+		},
+	}
+	stampTestResourceLabels(prov, "TestAccLabeled_basic")
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"labels": map[string]interface{}{"env": "test"},
+	})
+	if err := r.Create(d, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if apiLabels["env"] != "test" || apiLabels[TestRunLabel] != "build-1234" || apiLabels[TestNameLabel] != "testacclabeled_basic" {
+		t.Errorf("expected the resource to be created with the ownership labels, got %v", apiLabels)
+	}
+	if _, ok := apiLabels[TestCreatedLabel]; !ok {
+		t.Errorf("expected the resource to be created with its creation time, got %v", apiLabels)
+	}
+
+	expected := map[string]interface{}{"env": "test"}
+	if got := d.Get("labels"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected the ownership labels to be hidden after create, got %v", got)
+	}
+	if err := r.Read(d, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := d.Get("labels"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected the ownership labels to be hidden after read, got %v", got)
+	}
+	if unlabeled.Read != nil || unlabeled.Update != nil {
+		t.Errorf("expected resources without labels to be left as is")
+	}
+
+	d = schema.TestResourceDataRaw(t, channel.Schema, map[string]interface{}{
+		"labels": map[string]interface{}{"email_address": "test@example.com"},
+	})
+	if err := channel.Create(d, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = map[string]interface{}{"email_address": "test@example.com"}
+	if !reflect.DeepEqual(channelLabels, expected) {
+		t.Errorf("expected resources missing from testLabeledResources to be created without the ownership labels, got %v", channelLabels)
+	}
+}
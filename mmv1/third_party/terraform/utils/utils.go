@@ -3,6 +3,7 @@
 package google
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"regexp"
@@ -283,8 +284,12 @@ func extractFirstMapConfig(m []interface{}) map[string]interface{} {
 	return m[0].(map[string]interface{})
 }
 
-func lockedCall(lockKey string, f func() error) error {
-	mutexKV.Lock(lockKey)
+// lockedCallContext calls f while holding the lock for lockKey, or returns an
+// error if the lock can't be acquired before ctx is done.
+func lockedCallContext(ctx context.Context, lockKey string, f func() error) error {
+	if err := mutexKV.LockContext(ctx, lockKey); err != nil {
+		return err
+	}
 	defer mutexKV.Unlock(lockKey)
 
 	return f()
@@ -505,8 +510,10 @@ func checkGoogleIamPolicy(value string) error {
 // cluster. This error can be safely retried until the incompatible operation
 // completes, and the newly requested operation can begin.
 func retryWhileIncompatibleOperation(timeout time.Duration, lockKey string, f func() error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 	return resource.Retry(timeout, func() *resource.RetryError {
-		if err := lockedCall(lockKey, f); err != nil {
+		if err := lockedCallContext(ctx, lockKey, f); err != nil {
 			if isFailedPreconditionError(err) {
 				return resource.RetryableError(err)
 			}
@@ -0,0 +1,293 @@
+package google
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dnaeon/go-vcr/cassette"
+)
+
+// vcrIgnoredQueryParams are compared by presence only, as their values may
+// differ between recording and replaying. Requests for different pages are
+// told apart by their order instead.
+var vcrIgnoredQueryParams = map[string]bool{
+	"pageToken": true,
+}
+
+// vcrTransport records or replays requests, and saves the cassette on Stop.
+type vcrTransport interface {
+	http.RoundTripper
+	Stop() error
+}
+
+// vcrReplayer replays the interactions of a cassette. The Nth request
+// matching a recorded request gets the response of the Nth matching
+// interaction, so polling loops such as OperationWait replay their
+// responses in order.
+type vcrReplayer struct {
+	path     string
+	redactor *vcrRedactor
+
+	lock         sync.Mutex
+	interactions []*cassette.Interaction
+	requests     []vcrRequest
+	replayed     []bool
+	// occurrences counts the requests made per request key
+	occurrences map[string]int
+}
+
+func newVcrReplayer(path string, redactor *vcrRedactor) (*vcrReplayer, error) {
+	c, err := cassette.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return newVcrInteractionReplayer(path, redactor, c.Interactions), nil
+}
+
+// newVcrInteractionReplayer replays the given interactions of the cassette at path.
+func newVcrInteractionReplayer(path string, redactor *vcrRedactor, interactions []*cassette.Interaction) *vcrReplayer {
+	v := &vcrReplayer{
+		path:         path,
+		redactor:     redactor,
+		interactions: interactions,
+		requests:     make([]vcrRequest, 0, len(interactions)),
+		replayed:     make([]bool, len(interactions)),
+		occurrences:  make(map[string]int),
+	}
+	for _, i := range interactions {
+		v.requests = append(v.requests, newCassetteVcrRequest(i.Request))
+	}
+	return v
+}
+
+func (v *vcrReplayer) RoundTrip(r *http.Request) (*http.Response, error) {
+	if err := r.Context().Err(); err != nil {
+		return nil, err
+	}
+	req, err := newHttpVcrRequest(r, v.redactor)
+	if err != nil {
+		return nil, err
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	key := req.key()
+	v.occurrences[key]++
+	for idx, recorded := range v.requests {
+		if !v.replayed[idx] && req.matches(recorded) {
+			v.replayed[idx] = true
+			return newVcrResponse(r, v.interactions[idx]), nil
+		}
+	}
+
+	diagnostic := v.closestLocked(req, v.occurrences[key])
+	log.Printf("[WARN] No recorded interaction in cassette %s matches %s %s. %s", v.path, r.Method, req.url, diagnostic)
+	return nil, fmt.Errorf("%w: no recorded interaction matches %s %s. %s", cassette.ErrInteractionNotFound, r.Method, req.url, diagnostic)
+}
+
+// Stop is a no-op, as replaying doesn't change the cassette.
+func (v *vcrReplayer) Stop() error {
+	return nil
+}
+
+// hasMatch reports whether any recorded request matches req, replayed or not.
+func (v *vcrReplayer) hasMatch(req vcrRequest) bool {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	for _, recorded := range v.requests {
+		if req.matches(recorded) {
+			return true
+		}
+	}
+	return false
+}
+
+// closestLocked describes the recorded request closest to req, to tell why
+// nothing matched. Caller must hold v.lock
+func (v *vcrReplayer) closestLocked(req vcrRequest, occurrence int) string {
+	best := -1
+	var bestDiffs []string
+	recordedCount := 0
+	for idx, recorded := range v.requests {
+		diffs := req.differences(recorded)
+		if len(diffs) == 0 {
+			recordedCount++
+			continue
+		}
+		if best == -1 || len(diffs) < len(bestDiffs) {
+			best, bestDiffs = idx, diffs
+		}
+	}
+
+	if recordedCount > 0 {
+		return fmt.Sprintf("This is request %d of its kind, but only %d were recorded.", occurrence, recordedCount)
+	}
+	if best == -1 {
+		return "The cassette has no recorded interactions."
+	}
+	state := "not replayed yet"
+	if v.replayed[best] {
+		state = "already replayed"
+	}
+	return fmt.Sprintf("The closest recorded request is interaction %d (%s %s, %s), which differs in %s.", best, v.requests[best].method, v.requests[best].url, state, strings.Join(bestDiffs, ", "))
+}
+
+func newVcrResponse(r *http.Request, i *cassette.Interaction) *http.Response {
+	body := []byte(i.Response.Body)
+	return &http.Response{
+		Status:        i.Response.Status,
+		StatusCode:    i.Response.Code,
+		Proto:         "HTTP/1.0",
+		ProtoMajor:    1,
+		ProtoMinor:    0,
+		Request:       r,
+		Header:        i.Response.Headers,
+		Close:         true,
+		ContentLength: int64(len(body)),
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+	}
+}
+
+// vcrRequest is a request normalized for matching.
+type vcrRequest struct {
+	method string
+	// url is the redacted URL with its query parameters sorted and the
+	// values of vcrIgnoredQueryParams removed
+	url string
+	// body is the redacted body, and hasBody is unset if the body shouldn't
+	// be compared
+	body        string
+	hasBody     bool
+	contentType string
+}
+
+func newHttpVcrRequest(r *http.Request, redactor *vcrRedactor) (vcrRequest, error) {
+	req := vcrRequest{
+		method:      r.Method,
+		url:         normalizeVcrUrl(redactor.redactString(r.URL.String())),
+		contentType: r.Header.Get("Content-Type"),
+	}
+	// If body contains media, don't try to compare
+	if r.Body == nil || strings.Contains(req.contentType, "multipart/related") {
+		return req, nil
+	}
+
+	var b bytes.Buffer
+	if _, err := b.ReadFrom(r.Body); err != nil {
+		return req, fmt.Errorf("failed to read request body: %s", err)
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(b.Bytes()))
+	req.body = redactor.redactBody(b.String())
+	req.hasBody = true
+	return req, nil
+}
+
+func newCassetteVcrRequest(i cassette.Request) vcrRequest {
+	return vcrRequest{
+		method:  i.Method,
+		url:     normalizeVcrUrl(i.URL),
+		body:    i.Body,
+		hasBody: true,
+	}
+}
+
+// key identifies identical requests.
+func (r vcrRequest) key() string {
+	return r.method + " " + r.url + "\n" + r.body
+}
+
+// matches reports whether r matches the recorded request.
+func (r vcrRequest) matches(recorded vcrRequest) bool {
+	return r.method == recorded.method && r.url == recorded.url && r.bodyMatches(recorded)
+}
+
+func (r vcrRequest) bodyMatches(recorded vcrRequest) bool {
+	if !r.hasBody || r.body == recorded.body {
+		return true
+	}
+
+	// JSON might be the same, but reordered. Try parsing json and comparing
+	if strings.Contains(r.contentType, "application/json") {
+		var reqJson, cassetteJson interface{}
+		if err := json.Unmarshal([]byte(r.body), &reqJson); err != nil {
+			return false
+		}
+		if err := json.Unmarshal([]byte(recorded.body), &cassetteJson); err != nil {
+			return false
+		}
+		return reflect.DeepEqual(reqJson, cassetteJson)
+	}
+	return false
+}
+
+// differences lists the parts of r that differ from the recorded request.
+func (r vcrRequest) differences(recorded vcrRequest) []string {
+	var diffs []string
+	if r.method != recorded.method {
+		diffs = append(diffs, "method")
+	}
+
+	u, uErr := url.Parse(r.url)
+	ru, ruErr := url.Parse(recorded.url)
+	if uErr != nil || ruErr != nil {
+		if r.url != recorded.url {
+			diffs = append(diffs, "URL")
+		}
+	} else {
+		if u.Scheme != ru.Scheme || u.Host != ru.Host || u.Path != ru.Path {
+			diffs = append(diffs, "path")
+		}
+		q, rq := u.Query(), ru.Query()
+		var params []string
+		for k := range q {
+			if !reflect.DeepEqual(q[k], rq[k]) {
+				params = append(params, k)
+			}
+		}
+		for k := range rq {
+			if _, ok := q[k]; !ok {
+				params = append(params, k)
+			}
+		}
+		sort.Strings(params)
+		for _, k := range params {
+			diffs = append(diffs, fmt.Sprintf("query parameter %q", k))
+		}
+	}
+
+	if !r.bodyMatches(recorded) {
+		diffs = append(diffs, "body")
+	}
+	return diffs
+}
+
+// normalizeVcrUrl sorts the query parameters of rawUrl and removes the values
+// of vcrIgnoredQueryParams.
+func normalizeVcrUrl(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.RawQuery == "" {
+		return rawUrl
+	}
+
+	q := u.Query()
+	for k, vs := range q {
+		if vcrIgnoredQueryParams[k] {
+			q[k] = []string{""}
+			continue
+		}
+		sort.Strings(vs)
+	}
+	// Encode sorts the parameters by key
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,151 @@
+package google
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dnaeon/go-vcr/cassette"
+)
+
+func newTestVcrReplayer(t *testing.T, interactions ...*cassette.Interaction) *vcrReplayer {
+	path := filepath.Join(t.TempDir(), "cassette")
+	c := cassette.New(path)
+	for _, i := range interactions {
+		c.AddInteraction(i)
+	}
+	if err := c.Save(); err != nil {
+		t.Fatalf("unable to save cassette: %v", err)
+	}
+
+	redactor, err := newVcrRedactor(defaultVcrRedactionConfig)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	v, err := newVcrReplayer(path, redactor)
+	if err != nil {
+		t.Fatalf("unable to load cassette: %v", err)
+	}
+	return v
+}
+
+func testVcrInteraction(method, url, body, respBody string) *cassette.Interaction {
+	return &cassette.Interaction{
+		Request:  cassette.Request{Method: method, URL: url, Body: body},
+		Response: cassette.Response{Code: 200, Status: "200 OK", Body: respBody},
+	}
+}
+
+func testVcrReplay(t *testing.T, v *vcrReplayer, method, url, body string) (string, error) {
+	var req *http.Request
+	var err error
+	if body == "" {
+		req, err = http.NewRequest(method, url, nil)
+	} else {
+		req, err = http.NewRequest(method, url, strings.NewReader(body))
+	}
+	if err != nil {
+		t.Fatalf("unable to create request: %v", err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := v.RoundTrip(req)
+	if err != nil {
+		return "", err
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("unable to read response: %v", err)
+	}
+	return string(b), nil
+}
+
+func TestVcrReplayer_sequence(t *testing.T) {
+	opUrl := "https://compute.googleapis.com/compute/v1/projects/p/global/operations/op-1?alt=json"
+	v := newTestVcrReplayer(t,
+		testVcrInteraction("GET", opUrl, "", `{"status":"RUNNING"}`),
+		testVcrInteraction("GET", opUrl, "", `{"status":"RUNNING"}`),
+		testVcrInteraction("GET", opUrl, "", `{"status":"DONE"}`),
+	)
+
+	for _, expected := range []string{`{"status":"RUNNING"}`, `{"status":"RUNNING"}`, `{"status":"DONE"}`} {
+		got, err := testVcrReplay(t, v, "GET", opUrl, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != expected {
+			t.Errorf("expected %s, got %s", expected, got)
+		}
+	}
+
+	_, err := testVcrReplay(t, v, "GET", opUrl, "")
+	if !errors.Is(err, cassette.ErrInteractionNotFound) {
+		t.Fatalf("expected an interaction not found error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "request 4 of its kind, but only 3 were recorded") {
+		t.Errorf("expected the error to count identical requests, got %v", err)
+	}
+}
+
+func TestVcrReplayer_normalizesQuery(t *testing.T) {
+	v := newTestVcrReplayer(t,
+		testVcrInteraction("GET", "https://dns.googleapis.com/dns/v1/projects/p/managedZones?maxResults=2&alt=json", "", "page 1"),
+		testVcrInteraction("GET", "https://dns.googleapis.com/dns/v1/projects/p/managedZones?pageToken=recorded&alt=json&maxResults=2", "", "page 2"),
+	)
+
+	got, err := testVcrReplay(t, v, "GET", "https://dns.googleapis.com/dns/v1/projects/p/managedZones?alt=json&maxResults=2", "")
+	if err != nil || got != "page 1" {
+		t.Fatalf("expected page 1, got %q, %v", got, err)
+	}
+	got, err = testVcrReplay(t, v, "GET", "https://dns.googleapis.com/dns/v1/projects/p/managedZones?alt=json&maxResults=2&pageToken=replayed", "")
+	if err != nil || got != "page 2" {
+		t.Fatalf("expected page 2, got %q, %v", got, err)
+	}
+}
+
+func TestVcrReplayer_jsonBody(t *testing.T) {
+	v := newTestVcrReplayer(t,
+		testVcrInteraction("POST", "https://sqladmin.googleapis.com/sql/v1beta4/projects/p/instances/i/users?alt=json", `{"name":"user","password":"REDACTED"}`, "created"),
+	)
+
+	// The body is redacted before matching and fields may be reordered
+	got, err := testVcrReplay(t, v, "POST", "https://sqladmin.googleapis.com/sql/v1beta4/projects/p/instances/i/users?alt=json", `{"password": "hunter2", "name": "user"}`)
+	if err != nil || got != "created" {
+		t.Fatalf("expected a match, got %q, %v", got, err)
+	}
+}
+
+func TestVcrReplayer_closestDiagnostic(t *testing.T) {
+	v := newTestVcrReplayer(t,
+		testVcrInteraction("GET", "https://compute.googleapis.com/compute/v1/projects/p/zones/z/instances?alt=json&filter=name%3Da", "", "a"),
+		testVcrInteraction("DELETE", "https://compute.googleapis.com/compute/v1/projects/p/zones/z/instances/b?alt=json", "", "b"),
+	)
+
+	_, err := testVcrReplay(t, v, "GET", "https://compute.googleapis.com/compute/v1/projects/p/zones/z/instances?alt=json&filter=name%3Dc", "")
+	if err == nil {
+		t.Fatalf("expected an error for an unrecorded request")
+	}
+	if !strings.Contains(err.Error(), `interaction 0`) || !strings.Contains(err.Error(), `differs in query parameter "filter"`) {
+		t.Errorf("expected the error to describe the closest recorded request, got %v", err)
+	}
+}
+
+func TestNormalizeVcrUrl(t *testing.T) {
+	cases := map[string]string{
+		"https://example.com/v1/things?b=2&a=1":              "https://example.com/v1/things?a=1&b=2",
+		"https://example.com/v1/things?a=2&a=1":              "https://example.com/v1/things?a=1&a=2",
+		"https://example.com/v1/things?pageToken=abc&a=1":    "https://example.com/v1/things?a=1&pageToken=",
+		"https://example.com/v1/things":                      "https://example.com/v1/things",
+		"https://example.com/v1/things/a%2Fb?alt=json&x=%20": "https://example.com/v1/things/a%2Fb?alt=json&x=+",
+	}
+	for in, expected := range cases {
+		if got := normalizeVcrUrl(in); got != expected {
+			t.Errorf("normalizeVcrUrl(%q): expected %q, got %q", in, expected, got)
+		}
+	}
+}
@@ -0,0 +1,425 @@
+package google
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dnaeon/go-vcr/cassette"
+	"github.com/dnaeon/go-vcr/recorder"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gopkg.in/yaml.v2"
+)
+
+// Environment variables that record or replay the HTTP traffic of a provider
+// run when the provider `vcr` block isn't set.
+const (
+	ProviderVcrModeEnvVar     = "GOOGLE_VCR_MODE"
+	ProviderVcrPathEnvVar     = "GOOGLE_VCR_PATH"
+	ProviderVcrCassetteEnvVar = "GOOGLE_VCR_CASSETTE"
+)
+
+// providerVcrRunCassette names the cassette recorded by this provider process
+// if none is configured. It's set once, as the SDK and framework providers are
+// configured separately but record to the same cassette.
+var providerVcrRunCassette = fmt.Sprintf("run-%s-%d", time.Now().UTC().Format("20060102T150405Z"), os.Getpid())
+
+// providerVcrProcess identifies the interactions recorded by this provider
+// process, as every process recording to a named cassette appends to it.
+var providerVcrProcess = fmt.Sprintf("%d-%d", os.Getpid(), time.Now().UnixNano())
+
+// vcrProcessHeader is added to recorded requests, naming the provider process
+// that made them.
+const vcrProcessHeader = "X-Provider-Vcr-Process"
+
+// ProviderVcrConfig records the HTTP traffic of a provider run to a cassette,
+// or replays it from one without network access, e.g. to debug a failing
+// apply with a local build of the provider.
+type ProviderVcrConfig struct {
+	// Mode is RECORDING or REPLAYING
+	Mode string
+	// Path is the directory of the cassettes
+	Path string
+	// Cassette names the cassette. By default, every provider process records
+	// to a new cassette. Every process recording to a named cassette appends
+	// to it, and every process replaying it replays the interactions of one
+	// recording process, see vcrProcessReplayer. Required when replaying, as
+	// Terraform runs several provider processes for a single command.
+	Cassette string
+}
+
+func newProviderVcrConfig(mode, path, cassette string) (*ProviderVcrConfig, error) {
+	if mode != "RECORDING" && mode != "REPLAYING" {
+		return nil, fmt.Errorf("VCR mode must be RECORDING or REPLAYING, got %q", mode)
+	}
+	if path == "" {
+		return nil, fmt.Errorf("a path for VCR cassettes must be set")
+	}
+	if mode == "REPLAYING" && cassette == "" {
+		return nil, fmt.Errorf("a cassette to replay must be set, pick one of the cassettes recorded in %q. Record to a named cassette to replay the several provider processes of a Terraform command", path)
+	}
+	return &ProviderVcrConfig{Mode: mode, Path: path, Cassette: cassette}, nil
+}
+
+// ExpandProviderVcrConfig returns the configuration of the provider `vcr`
+// block, or of the GOOGLE_VCR_* environment variables if the block isn't set.
+// It returns nil if neither is set.
+func ExpandProviderVcrConfig(v interface{}) (*ProviderVcrConfig, error) {
+	ls, _ := v.([]interface{})
+	if len(ls) == 0 || ls[0] == nil {
+		return providerVcrConfigFromEnv()
+	}
+
+	cfgV := ls[0].(map[string]interface{})
+	mode, _ := cfgV["mode"].(string)
+	path, _ := cfgV["path"].(string)
+	cassette, _ := cfgV["cassette"].(string)
+	return newProviderVcrConfig(mode, path, cassette)
+}
+
+// GetProviderVcrConfig returns the configuration of the provider `vcr` block
+// for the plugin-framework provider, falling back to the environment like
+// ExpandProviderVcrConfig.
+func GetProviderVcrConfig(ctx context.Context, data types.List, diags *diag.Diagnostics) *ProviderVcrConfig {
+	var vcrConfigs []ProviderVcr
+	if !data.IsNull() {
+		d := data.ElementsAs(ctx, &vcrConfigs, true)
+		diags.Append(d...)
+		if diags.HasError() {
+			return nil
+		}
+	}
+
+	var cfg *ProviderVcrConfig
+	var err error
+	if len(vcrConfigs) == 0 {
+		cfg, err = providerVcrConfigFromEnv()
+	} else {
+		cfg, err = newProviderVcrConfig(vcrConfigs[0].Mode.ValueString(), vcrConfigs[0].Path.ValueString(), vcrConfigs[0].Cassette.ValueString())
+	}
+	if err != nil {
+		diags.AddError("invalid vcr configuration", err.Error())
+		return nil
+	}
+	return cfg
+}
+
+func providerVcrConfigFromEnv() (*ProviderVcrConfig, error) {
+	mode := os.Getenv(ProviderVcrModeEnvVar)
+	if mode == "" {
+		return nil, nil
+	}
+	return newProviderVcrConfig(mode, os.Getenv(ProviderVcrPathEnvVar), os.Getenv(ProviderVcrCassetteEnvVar))
+}
+
+var providerVcrTransports = struct {
+	sync.Mutex
+	byPath map[string]vcrTransport
+}{byPath: make(map[string]vcrTransport)}
+
+// HandleProviderVcrConfiguration wraps the transport of the provider client to
+// record or replay the run's HTTP traffic, if cfg is set. The SDK and framework
+// providers share the recorder for a cassette.
+func HandleProviderVcrConfiguration(ctx context.Context, cfg *ProviderVcrConfig, rndTripper http.RoundTripper, pollInterval time.Duration) (time.Duration, http.RoundTripper, error) {
+	if cfg == nil {
+		return pollInterval, rndTripper, nil
+	}
+	if isVcrEnabled() {
+		log.Printf("[DEBUG] VCR_PATH and VCR_MODE are set, leaving VCR to the test and ignoring the provider vcr configuration")
+		return pollInterval, rndTripper, nil
+	}
+
+	mode := recorder.ModeRecording
+	if cfg.Mode == "REPLAYING" {
+		mode = recorder.ModeReplaying
+		// Operations are done once their recorded responses say so
+		pollInterval = 10 * time.Millisecond
+	}
+
+	cassette := cfg.Cassette
+	if cassette == "" {
+		cassette = providerVcrRunCassette
+	}
+	path := filepath.Join(cfg.Path, strings.TrimSuffix(cassette, ".yaml"))
+
+	providerVcrTransports.Lock()
+	defer providerVcrTransports.Unlock()
+	if t, ok := providerVcrTransports.byPath[path]; ok {
+		return pollInterval, t, nil
+	}
+
+	redactor, err := vcrRedactorFromEnv()
+	if err != nil {
+		return pollInterval, rndTripper, err
+	}
+	var t vcrTransport
+	if mode == recorder.ModeRecording {
+		t, err = newVcrAppendRecorder(path, redactor, rndTripper)
+	} else {
+		t, err = newVcrProcessReplayer(path, redactor)
+	}
+	if err != nil {
+		return pollInterval, rndTripper, fmt.Errorf("error creating VCR recorder for cassette %q: %s", path, err)
+	}
+	log.Printf("[INFO] VCR %s cassette %s.yaml", strings.ToLower(cfg.Mode), path)
+
+	providerVcrTransports.byPath[path] = t
+	return pollInterval, t, nil
+}
+
+// vcrAppendCassetteHeader starts a cassette in the format saved by go-vcr,
+// with the interactions appended after it.
+const vcrAppendCassetteHeader = "---\nversion: 1\ninteractions:\n"
+
+// vcrAppendRecorder records the interactions of a provider run to a cassette,
+// appending each one to the file as soon as it's made. There's no hook for the
+// end of a provider run, and saving the whole cassette after every request
+// would take time quadratic in the length of the run. Several processes may
+// append to the same cassette, each interaction in a single write.
+type vcrAppendRecorder struct {
+	transport http.RoundTripper
+	redactor  *vcrRedactor
+	// process is recorded in the vcrProcessHeader of every request
+	process string
+
+	// lock serializes writes to file
+	lock sync.Mutex
+	file *os.File
+}
+
+func newVcrAppendRecorder(path string, redactor *vcrRedactor, transport http.RoundTripper) (*vcrAppendRecorder, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := createVcrAppendCassette(path + ".yaml"); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path+".yaml", os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &vcrAppendRecorder{
+		transport: transport,
+		redactor:  redactor,
+		process:   providerVcrProcess,
+		file:      f,
+	}, nil
+}
+
+// createVcrAppendCassette creates the cassette with its header, unless it
+// already exists. The header is written to a temporary file linked into
+// place, so processes recording to the same new cassette can't append to it
+// before the header is written.
+func createVcrAppendCassette(name string) error {
+	if _, err := os.Stat(name); err == nil {
+		return nil
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.WriteString(vcrAppendCassetteHeader)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Link(tmp.Name(), name); err != nil && !os.IsExist(err) {
+		return err
+	}
+	return nil
+}
+
+// RoundTrip sends the request and appends the redacted interaction to the
+// cassette. Failing to record an interaction doesn't fail the request.
+func (r *vcrAppendRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		reqBody, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	var form url.Values
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		form, _ = url.ParseQuery(string(reqBody))
+	}
+	// Headers are copied so redacting them doesn't change the request or response
+	reqHeaders := req.Header.Clone()
+	if reqHeaders == nil {
+		reqHeaders = make(http.Header)
+	}
+	reqHeaders.Set(vcrProcessHeader, r.process)
+	interaction := &cassette.Interaction{
+		Request: cassette.Request{
+			Body:    string(reqBody),
+			Form:    form,
+			Headers: reqHeaders,
+			URL:     req.URL.String(),
+			Method:  req.Method,
+		},
+		Response: cassette.Response{
+			Body:    string(respBody),
+			Headers: resp.Header.Clone(),
+			Status:  resp.Status,
+			Code:    resp.StatusCode,
+		},
+	}
+	if err := r.append(interaction); err != nil {
+		log.Printf("[WARN] Unable to record interaction to VCR cassette %s: %s", r.file.Name(), err)
+	}
+	return resp, nil
+}
+
+func (r *vcrAppendRecorder) append(i *cassette.Interaction) error {
+	r.redactor.redactInteraction(i)
+	data, err := yaml.Marshal([]*cassette.Interaction{i})
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	_, err = r.file.Write(data)
+	return err
+}
+
+// Stop closes the cassette. Every interaction is already written to it.
+func (r *vcrAppendRecorder) Stop() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.file.Close()
+}
+
+// vcrProcessReplayer replays a cassette that several provider processes may
+// have recorded to. Terraform runs the provider in several processes for a
+// single command, such as one to plan and one to apply changes, and each
+// replays the interactions of one recording process. At its first request,
+// a process claims the first recording process, in the order they appear in
+// the cassette, that has a request matching it and isn't claimed by another
+// process of the same Terraform command. Cassettes recorded by a single
+// process are replayed in full by every process.
+type vcrProcessReplayer struct {
+	path     string
+	redactor *vcrRedactor
+	// claimDir holds a file for each recording process claimed by a process
+	// of this Terraform command, which is the parent of the provider processes
+	claimDir string
+	// processes replay the interactions of each recording process
+	processes []*vcrReplayer
+
+	lock    sync.Mutex
+	claimed *vcrReplayer
+}
+
+func newVcrProcessReplayer(path string, redactor *vcrRedactor) (vcrTransport, error) {
+	c, err := cassette.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var order []string
+	byProcess := make(map[string][]*cassette.Interaction)
+	for _, i := range c.Interactions {
+		process := i.Request.Headers.Get(vcrProcessHeader)
+		if _, ok := byProcess[process]; !ok {
+			order = append(order, process)
+		}
+		byProcess[process] = append(byProcess[process], i)
+	}
+	if len(order) <= 1 {
+		return newVcrInteractionReplayer(path, redactor, c.Interactions), nil
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	v := &vcrProcessReplayer{
+		path:     path,
+		redactor: redactor,
+		claimDir: filepath.Join(os.TempDir(), fmt.Sprintf("provider-vcr-%x-%d", sha256.Sum256([]byte(absPath)), os.Getppid())),
+	}
+	for _, process := range order {
+		v.processes = append(v.processes, newVcrInteractionReplayer(path, redactor, byProcess[process]))
+	}
+	return v, nil
+}
+
+func (v *vcrProcessReplayer) RoundTrip(r *http.Request) (*http.Response, error) {
+	replayer, err := v.claim(r)
+	if err != nil {
+		return nil, err
+	}
+	return replayer.RoundTrip(r)
+}
+
+// claim returns the replayer of the recording process claimed by this
+// process, claiming one for its first request.
+func (v *vcrProcessReplayer) claim(r *http.Request) (*vcrReplayer, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if v.claimed != nil {
+		return v.claimed, nil
+	}
+
+	req, err := newHttpVcrRequest(r, v.redactor)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(v.claimDir, 0700); err != nil {
+		return nil, err
+	}
+	for idx, replayer := range v.processes {
+		if !replayer.hasMatch(req) {
+			continue
+		}
+		f, err := os.OpenFile(filepath.Join(v.claimDir, strconv.Itoa(idx)), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		f.Close()
+		log.Printf("[INFO] VCR replaying the interactions of recorded provider process %d of %d in cassette %s", idx+1, len(v.processes), v.path)
+		v.claimed = replayer
+		return replayer, nil
+	}
+	return nil, fmt.Errorf("%w: no recorded provider process in cassette %s that isn't replayed by another process has a request matching %s %s", cassette.ErrInteractionNotFound, v.path, r.Method, req.url)
+}
+
+// Stop is a no-op, as replaying doesn't change the cassette.
+func (v *vcrProcessReplayer) Stop() error {
+	return nil
+}
@@ -0,0 +1,208 @@
+package google
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dnaeon/go-vcr/cassette"
+)
+
+func TestExpandProviderVcrConfig(t *testing.T) {
+	t.Setenv(ProviderVcrModeEnvVar, "")
+
+	cfg, err := ExpandProviderVcrConfig([]interface{}{
+		map[string]interface{}{"mode": "REPLAYING", "path": "/tmp/cassettes", "cassette": "failing-apply"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *cfg != (ProviderVcrConfig{Mode: "REPLAYING", Path: "/tmp/cassettes", Cassette: "failing-apply"}) {
+		t.Errorf("unexpected config from block: %+v", cfg)
+	}
+
+	if cfg, err := ExpandProviderVcrConfig([]interface{}{}); err != nil || cfg != nil {
+		t.Errorf("expected no config without the block or environment, got %+v, %v", cfg, err)
+	}
+
+	t.Setenv(ProviderVcrModeEnvVar, "RECORDING")
+	t.Setenv(ProviderVcrPathEnvVar, "/tmp/env-cassettes")
+	cfg, err = ExpandProviderVcrConfig([]interface{}{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *cfg != (ProviderVcrConfig{Mode: "RECORDING", Path: "/tmp/env-cassettes"}) {
+		t.Errorf("unexpected config from environment: %+v", cfg)
+	}
+
+	if _, err := ExpandProviderVcrConfig([]interface{}{
+		map[string]interface{}{"mode": "RECORD", "path": "/tmp/cassettes"},
+	}); err == nil {
+		t.Errorf("expected an error for an invalid mode")
+	}
+
+	// Terraform runs several provider processes, so which to replay is ambiguous
+	if _, err := ExpandProviderVcrConfig([]interface{}{
+		map[string]interface{}{"mode": "REPLAYING", "path": "/tmp/cassettes"},
+	}); err == nil {
+		t.Errorf("expected an error replaying without a cassette")
+	}
+}
+
+func TestVcrAppendRecorder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		fmt.Fprintf(w, `{"name":%q,"password":"hunter2"}`, body)
+	}))
+	defer ts.Close()
+
+	redactor, err := newVcrRedactor(defaultVcrRedactionConfig)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "cassettes", "run")
+	rec, err := newVcrAppendRecorder(path, redactor, http.DefaultTransport)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"first", "second"} {
+		req, err := http.NewRequest("POST", ts.URL, strings.NewReader(name))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer token")
+		resp, err := rec.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		if !strings.Contains(string(body), "hunter2") || resp.Header.Get("Set-Cookie") != "session=secret" {
+			t.Errorf("expected the response not to be redacted, got %s with headers %v", body, resp.Header)
+		}
+		if req.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("expected the request headers not to be redacted, got %v", req.Header)
+		}
+
+		// Every interaction is saved as soon as it's made
+		c, err := cassette.Load(path)
+		if err != nil {
+			t.Fatalf("unable to load cassette after %s request: %v", name, err)
+		}
+		last := c.Interactions[len(c.Interactions)-1]
+		if last.Request.Body != name {
+			t.Errorf("expected the %s request to be recorded, got %q", name, last.Request.Body)
+		}
+		if strings.Contains(last.Response.Body, "hunter2") || last.Request.Headers.Get("Authorization") != vcrRedacted {
+			t.Errorf("expected the recorded interaction to be redacted, got %+v", last)
+		}
+	}
+
+	if err := rec.Stop(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c, err := cassette.Load(path)
+	if err != nil {
+		t.Fatalf("unable to load cassette: %v", err)
+	}
+	if len(c.Interactions) != 2 {
+		t.Errorf("expected 2 recorded interactions, got %d", len(c.Interactions))
+	}
+}
+
+// Check every process recording to a named cassette appends to it
+func TestVcrAppendRecorder_AppendsToExistingCassette(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "{}")
+	}))
+	defer ts.Close()
+
+	redactor, err := newVcrRedactor(defaultVcrRedactionConfig)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "run")
+	for _, process := range []string{"plan", "apply"} {
+		rec, err := newVcrAppendRecorder(path, redactor, http.DefaultTransport)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		rec.process = process
+		resp, err := rec.RoundTrip(httptest.NewRequest("GET", ts.URL+"/"+process, nil))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+		if err := rec.Stop(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	c, err := cassette.Load(path)
+	if err != nil {
+		t.Fatalf("unable to load cassette: %v", err)
+	}
+	if len(c.Interactions) != 2 {
+		t.Fatalf("expected the interactions of both processes, got %d", len(c.Interactions))
+	}
+	for i, process := range []string{"plan", "apply"} {
+		if got := c.Interactions[i].Request.Headers.Get(vcrProcessHeader); got != process {
+			t.Errorf("expected interaction %d to be recorded by the %s process, got %q", i, process, got)
+		}
+	}
+}
+
+// Check each replaying process replays the interactions of one recording
+// process, so a read after applying changes isn't answered by the plan's read
+func TestVcrProcessReplayer(t *testing.T) {
+	redactor, err := newVcrRedactor(defaultVcrRedactionConfig)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	interaction := func(process, method string, code int) *cassette.Interaction {
+		return &cassette.Interaction{
+			Request: cassette.Request{
+				Method:  method,
+				URL:     "https://example.googleapis.com/v1/things/thing",
+				Headers: http.Header{vcrProcessHeader: []string{process}},
+			},
+			Response: cassette.Response{Code: code, Body: "{}"},
+		}
+	}
+	path := filepath.Join(t.TempDir(), "run")
+	c := cassette.New(path)
+	c.Interactions = []*cassette.Interaction{
+		interaction("plan", "GET", 404),
+		interaction("apply", "POST", 200),
+		interaction("apply", "GET", 200),
+	}
+	if err := c.Save(); err != nil {
+		t.Fatalf("unable to save cassette: %v", err)
+	}
+
+	claimDir := t.TempDir()
+	replay := func(method string) int {
+		replayer, err := newVcrProcessReplayer(path, redactor)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		replayer.(*vcrProcessReplayer).claimDir = claimDir
+		resp, err := replayer.RoundTrip(httptest.NewRequest(method, "https://example.googleapis.com/v1/things/thing", nil))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp.StatusCode
+	}
+
+	if code := replay("GET"); code != 404 {
+		t.Errorf("expected the first process to replay the plan's read, got %d", code)
+	}
+	if code := replay("GET"); code != 200 {
+		t.Errorf("expected the second process to replay the apply's read, got %d", code)
+	}
+}
@@ -0,0 +1,256 @@
+package google
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/dnaeon/go-vcr/cassette"
+)
+
+// VcrRedactionConfigEnvVar names a JSON file in the format of
+// VcrRedactionConfig, listing secrets to redact from cassettes in addition to
+// the defaults.
+const VcrRedactionConfigEnvVar = "VCR_REDACTION_CONFIG"
+
+const vcrRedacted = "REDACTED"
+
+// VcrRedactionConfig lists the secrets to redact from cassettes.
+type VcrRedactionConfig struct {
+	// Headers are the names of request and response headers whose values are
+	// redacted.
+	Headers []string `json:"headers"`
+	// JsonPaths are dot-separated paths to values in JSON request and response
+	// bodies. A "*" segment matches every key of an object or element of an
+	// array.
+	JsonPaths []string `json:"json_paths"`
+	// Regexes match secrets anywhere in URLs, bodies and header values. If a
+	// regex has capture groups, only the groups are redacted.
+	Regexes []string `json:"regexes"`
+}
+
+var defaultVcrRedactionConfig = VcrRedactionConfig{
+	Headers: []string{
+		"Authorization",
+		"Proxy-Authorization",
+		"X-Goog-Api-Key",
+		"Cookie",
+		"Set-Cookie",
+	},
+	JsonPaths: []string{
+		// google_service_account_key.private_key
+		"privateKeyData",
+		// google_sql_user.password and google_sql_database_instance.root_password
+		"password",
+		"rootPassword",
+		// Tokens returned by the IAM Credentials and STS APIs
+		"accessToken",
+		"access_token",
+		"id_token",
+		"token",
+		// Ownership labels stamped by the test harness differ between runs
+		"labels." + TestRunLabel,
+		"labels." + TestCreatedLabel,
+	},
+	Regexes: []string{
+		// OAuth2 access tokens
+		`ya29\.[0-9A-Za-z_\-.]+`,
+		// PEM encoded private keys
+		`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`,
+		// Signatures of signed URLs
+		`[?&](?:X-Goog-Signature|Signature)=([^&"\\\s]+)`,
+	},
+}
+
+// vcrRedactor replaces secrets in recorded interactions. Requests are
+// redacted the same way before being matched against a cassette, so matching
+// keeps working on redacted fields while replaying.
+type vcrRedactor struct {
+	headers   map[string]bool
+	jsonPaths [][]string
+	regexes   []*regexp.Regexp
+}
+
+func newVcrRedactor(cfgs ...VcrRedactionConfig) (*vcrRedactor, error) {
+	r := &vcrRedactor{headers: make(map[string]bool)}
+	for _, cfg := range cfgs {
+		for _, h := range cfg.Headers {
+			r.headers[http.CanonicalHeaderKey(h)] = true
+		}
+		for _, p := range cfg.JsonPaths {
+			r.jsonPaths = append(r.jsonPaths, strings.Split(p, "."))
+		}
+		for _, s := range cfg.Regexes {
+			re, err := regexp.Compile(s)
+			if err != nil {
+				return nil, fmt.Errorf("unable to compile VCR redaction regex %q: %s", s, err)
+			}
+			r.regexes = append(r.regexes, re)
+		}
+	}
+	return r, nil
+}
+
+// vcrRedactorFromEnv returns a redactor for the default secrets and the ones
+// listed in the file named by VcrRedactionConfigEnvVar, if any.
+func vcrRedactorFromEnv() (*vcrRedactor, error) {
+	path := os.Getenv(VcrRedactionConfigEnvVar)
+	if path == "" {
+		return newVcrRedactor(defaultVcrRedactionConfig)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read VCR redaction config %q set by %s: %s", path, VcrRedactionConfigEnvVar, err)
+	}
+	var cfg VcrRedactionConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("unable to parse VCR redaction config %q: %s", path, err)
+	}
+	return newVcrRedactor(defaultVcrRedactionConfig, cfg)
+}
+
+// redactCassette redacts the interactions of the cassette saved at path.
+// Cassette filters aren't used as they would also redact the responses
+// returned to the provider while recording.
+func redactCassette(path string, r *vcrRedactor) error {
+	c, err := cassette.Load(path)
+	if err != nil {
+		return fmt.Errorf("unable to load cassette %q for redaction: %s", path, err)
+	}
+	for _, i := range c.Interactions {
+		r.redactInteraction(i)
+	}
+	if err := c.Save(); err != nil {
+		return fmt.Errorf("unable to save redacted cassette %q: %s", path, err)
+	}
+	return nil
+}
+
+func (r *vcrRedactor) redactInteraction(i *cassette.Interaction) {
+	i.Request.URL = r.redactString(i.Request.URL)
+	i.Request.Body = r.redactBody(i.Request.Body)
+	r.redactHeaders(i.Request.Headers)
+	for k, vs := range i.Request.Form {
+		for idx, v := range vs {
+			vs[idx] = r.redactString(v)
+		}
+		i.Request.Form[k] = vs
+	}
+
+	i.Response.Body = r.redactBody(i.Response.Body)
+	r.redactHeaders(i.Response.Headers)
+}
+
+func (r *vcrRedactor) redactHeaders(h http.Header) {
+	for k, vs := range h {
+		redactAll := r.headers[http.CanonicalHeaderKey(k)]
+		for idx, v := range vs {
+			if redactAll {
+				vs[idx] = vcrRedacted
+			} else {
+				vs[idx] = r.redactString(v)
+			}
+		}
+	}
+}
+
+// redactBody redacts the configured JSON paths if body is JSON, and the
+// regexes in any case. JSON bodies are only re-encoded if a path matched.
+func (r *vcrRedactor) redactBody(body string) string {
+	trimmed := strings.TrimSpace(body)
+	if len(r.jsonPaths) > 0 && (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) {
+		dec := json.NewDecoder(strings.NewReader(body))
+		// Keep numbers as they were recorded
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err == nil {
+			redacted := false
+			for _, path := range r.jsonPaths {
+				if redactJsonPath(v, path) {
+					redacted = true
+				}
+			}
+			if redacted {
+				var b bytes.Buffer
+				enc := json.NewEncoder(&b)
+				enc.SetEscapeHTML(false)
+				if err := enc.Encode(v); err == nil {
+					body = strings.TrimSuffix(b.String(), "\n")
+				}
+			}
+		}
+	}
+	return r.redactString(body)
+}
+
+func (r *vcrRedactor) redactString(s string) string {
+	for _, re := range r.regexes {
+		if re.NumSubexp() == 0 {
+			s = re.ReplaceAllLiteralString(s, vcrRedacted)
+			continue
+		}
+
+		var b strings.Builder
+		last := 0
+		for _, m := range re.FindAllStringSubmatchIndex(s, -1) {
+			for g := 1; g <= re.NumSubexp(); g++ {
+				start, end := m[2*g], m[2*g+1]
+				if start < last {
+					continue
+				}
+				b.WriteString(s[last:start])
+				b.WriteString(vcrRedacted)
+				last = end
+			}
+		}
+		b.WriteString(s[last:])
+		s = b.String()
+	}
+	return s
+}
+
+// redactJsonPath replaces the non-empty values at path in v, returning whether
+// any were replaced.
+func redactJsonPath(v interface{}, path []string) bool {
+	if len(path) == 0 {
+		return false
+	}
+
+	redacted := false
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			if path[0] != "*" && path[0] != k {
+				continue
+			}
+			if len(path) == 1 {
+				if child != nil && child != "" {
+					val[k] = vcrRedacted
+					redacted = true
+				}
+			} else if redactJsonPath(child, path[1:]) {
+				redacted = true
+			}
+		}
+	case []interface{}:
+		if path[0] != "*" {
+			return false
+		}
+		for idx, child := range val {
+			if len(path) == 1 {
+				if child != nil && child != "" {
+					val[idx] = vcrRedacted
+					redacted = true
+				}
+			} else if redactJsonPath(child, path[1:]) {
+				redacted = true
+			}
+		}
+	}
+	return redacted
+}
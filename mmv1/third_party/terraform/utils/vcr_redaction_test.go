@@ -0,0 +1,119 @@
+package google
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dnaeon/go-vcr/cassette"
+)
+
+func TestVcrRedactor_redactInteraction(t *testing.T) {
+	r, err := newVcrRedactor(defaultVcrRedactionConfig)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	i := &cassette.Interaction{
+		Request: cassette.Request{
+			URL:     "https://storage.googleapis.com/bucket/object?X-Goog-Algorithm=GOOG4-RSA-SHA256&X-Goog-Signature=0123abcd&X-Goog-Date=20230101",
+			Method:  "POST",
+			Body:    `{"name":"user","password":"hunter2"}`,
+			Headers: http.Header{"Authorization": {"Bearer ya29.a0AfH6SM"}, "Content-Type": {"application/json"}},
+			Form:    url.Values{"assertion": {"ya29.abc"}},
+		},
+		Response: cassette.Response{
+			Body:    `{"name":"keys/1","privateKeyData":"c2VjcmV0","validAfterTime":"2023-01-01T00:00:00Z","keyAlgorithm":"KEY_ALG_RSA_2048","size":12345678901234567890}`,
+			Headers: http.Header{"Set-Cookie": {"session=abc"}},
+		},
+	}
+	r.redactInteraction(i)
+
+	for name, tc := range map[string]struct{ got, want string }{
+		"url":             {i.Request.URL, "https://storage.googleapis.com/bucket/object?X-Goog-Algorithm=GOOG4-RSA-SHA256&X-Goog-Signature=REDACTED&X-Goog-Date=20230101"},
+		"request body":    {i.Request.Body, `{"name":"user","password":"REDACTED"}`},
+		"authorization":   {i.Request.Headers.Get("Authorization"), "REDACTED"},
+		"content type":    {i.Request.Headers.Get("Content-Type"), "application/json"},
+		"form":            {i.Request.Form.Get("assertion"), "REDACTED"},
+		"response body":   {i.Response.Body, `{"keyAlgorithm":"KEY_ALG_RSA_2048","name":"keys/1","privateKeyData":"REDACTED","size":12345678901234567890,"validAfterTime":"2023-01-01T00:00:00Z"}`},
+		"response cookie": {i.Response.Headers.Get("Set-Cookie"), "REDACTED"},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s: expected %q, got %q", name, tc.want, tc.got)
+		}
+	}
+}
+
+func TestVcrRedactor_redactBody(t *testing.T) {
+	r, err := newVcrRedactor(VcrRedactionConfig{
+		JsonPaths: []string{"items.*.secret", "settings.password"},
+		Regexes:   []string{`key-[0-9]+`},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := map[string]struct {
+		body, want string
+	}{
+		"wildcard path": {
+			body: `{"items":[{"name":"a","secret":"s1"},{"name":"b"}]}`,
+			want: `{"items":[{"name":"a","secret":"REDACTED"},{"name":"b"}]}`,
+		},
+		"nested path": {
+			body: `{"settings":{"password":"p","tier":"db-f1-micro"},"password":"kept"}`,
+			want: `{"password":"kept","settings":{"password":"REDACTED","tier":"db-f1-micro"}}`,
+		},
+		"no match is not re-encoded": {
+			body: `{ "name": "a" }`,
+			want: `{ "name": "a" }`,
+		},
+		"empty value is kept": {
+			body: `{"settings":{"password":""}}`,
+			want: `{"settings":{"password":""}}`,
+		},
+		"regex in non-JSON body": {
+			body: "name=key-123&other=1",
+			want: "name=REDACTED&other=1",
+		},
+	}
+	for tn, tc := range cases {
+		if got := r.redactBody(tc.body); got != tc.want {
+			t.Errorf("%s: expected %s, got %s", tn, tc.want, got)
+		}
+	}
+
+	// Redacting is idempotent, so redacted requests match redacted cassettes
+	once := r.redactBody(cases["wildcard path"].body)
+	if twice := r.redactBody(once); twice != once {
+		t.Errorf("expected redacting twice to be a no-op, got %s and %s", once, twice)
+	}
+}
+
+func TestVcrRedactorFromEnv(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "redaction.json")
+	if err := os.WriteFile(cfgPath, []byte(`{"headers":["X-Custom-Secret"],"json_paths":["apiKey"],"regexes":["sk_[a-z]+"]}`), 0644); err != nil {
+		t.Fatalf("unable to write config: %v", err)
+	}
+	t.Setenv(VcrRedactionConfigEnvVar, cfgPath)
+
+	r, err := vcrRedactorFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := r.redactBody(`{"apiKey":"k","password":"p","note":"sk_live"}`); got != `{"apiKey":"REDACTED","note":"REDACTED","password":"REDACTED"}` {
+		t.Errorf("expected configured and default secrets to be redacted, got %s", got)
+	}
+	if !r.headers["X-Custom-Secret"] || !r.headers["Authorization"] {
+		t.Errorf("expected configured and default headers to be redacted")
+	}
+
+	if err := os.WriteFile(cfgPath, []byte(`{"regexes":["("]}`), 0644); err != nil {
+		t.Fatalf("unable to write config: %v", err)
+	}
+	if _, err := vcrRedactorFromEnv(); err == nil {
+		t.Errorf("expected an error for an invalid regex")
+	}
+}
@@ -3,10 +3,8 @@ package google
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"math/rand"
 	"net/http"
@@ -22,7 +20,6 @@ import (
 
 	transport_tpg "github.com/hashicorp/terraform-provider-google/google/transport"
 
-	"github.com/dnaeon/go-vcr/cassette"
 	"github.com/dnaeon/go-vcr/recorder"
 
 	"github.com/hashicorp/terraform-plugin-framework/attr"
@@ -155,11 +152,14 @@ func closeRecorder(t *testing.T) {
 		// We did not cache the config if it does not use VCR
 		if !t.Failed() && isVcrEnabled() {
 			// If a test succeeds, write new seed/yaml to files
-			err := config.Client.Transport.(*recorder.Recorder).Stop()
+			err := config.Client.Transport.(vcrTransport).Stop()
 			if err != nil {
 				t.Error(err)
 			}
 			envPath := os.Getenv("VCR_PATH")
+			if err := redactRecordedCassette(envPath, t.Name()); err != nil {
+				t.Error(err)
+			}
 
 			sourcesLock.RLock()
 			vcrSource, ok := sources[t.Name()]
@@ -188,11 +188,14 @@ func closeRecorder(t *testing.T) {
 		// We did not cache the config if it does not use VCR
 		if !t.Failed() && isVcrEnabled() {
 			// If a test succeeds, write new seed/yaml to files
-			err := fwProvider.client.Transport.(*recorder.Recorder).Stop()
+			err := fwProvider.client.Transport.(vcrTransport).Stop()
 			if err != nil {
 				t.Error(err)
 			}
 			envPath := os.Getenv("VCR_PATH")
+			if err := redactRecordedCassette(envPath, t.Name()); err != nil {
+				t.Error(err)
+			}
 
 			sourcesLock.RLock()
 			vcrSource, ok := sources[t.Name()]
@@ -215,6 +218,19 @@ func closeRecorder(t *testing.T) {
 	}
 }
 
+// redactRecordedCassette redacts secrets from the cassette of a test that was
+// just recorded. Cassettes are left as is while replaying.
+func redactRecordedCassette(envPath, testName string) error {
+	if os.Getenv("VCR_MODE") != "RECORDING" {
+		return nil
+	}
+	redactor, err := vcrRedactorFromEnv()
+	if err != nil {
+		return err
+	}
+	return redactCassette(filepath.Join(envPath, vcrFileName(testName)), redactor)
+}
+
 func isReleaseDiffEnabled() bool {
 	releaseDiff := os.Getenv("RELEASE_DIFF")
 	return releaseDiff != ""
@@ -318,55 +334,29 @@ func HandleVCRConfiguration(ctx context.Context, testName string, rndTripper htt
 	}
 	path := filepath.Join(envPath, vcrFileName(testName))
 
-	rec, err := recorder.NewAsMode(path, vcrMode, rndTripper)
+	rec, err := newVcrRecorder(path, vcrMode, rndTripper)
 	if err != nil {
 		diags.AddError("error creating record as new mode", err.Error())
 		return pollInterval, rndTripper, diags
 	}
-	// Defines how VCR will match requests to responses.
-	rec.SetMatcher(func(r *http.Request, i cassette.Request) bool {
-		// Default matcher compares method and URL only
-		if !cassette.DefaultMatcher(r, i) {
-			return false
-		}
-		if r.Body == nil {
-			return true
-		}
-		contentType := r.Header.Get("Content-Type")
-		// If body contains media, don't try to compare
-		if strings.Contains(contentType, "multipart/related") {
-			return true
-		}
 
-		var b bytes.Buffer
-		if _, err := b.ReadFrom(r.Body); err != nil {
-			tflog.Debug(ctx, fmt.Sprintf("Failed to read request body from cassette: %v", err))
-			return false
-		}
-		r.Body = ioutil.NopCloser(&b)
-		reqBody := b.String()
-		// If body matches identically, we are done
-		if reqBody == i.Body {
-			return true
-		}
+	return pollInterval, rec, diags
+}
 
-		// JSON might be the same, but reordered. Try parsing json and comparing
-		if strings.Contains(contentType, "application/json") {
-			var reqJson, cassetteJson interface{}
-			if err := json.Unmarshal([]byte(reqBody), &reqJson); err != nil {
-				tflog.Debug(ctx, fmt.Sprintf("Failed to unmarshall request json: %v", err))
-				return false
-			}
-			if err := json.Unmarshal([]byte(i.Body), &cassetteJson); err != nil {
-				tflog.Debug(ctx, fmt.Sprintf("Failed to unmarshall cassette json: %v", err))
-				return false
-			}
-			return reflect.DeepEqual(reqJson, cassetteJson)
-		}
-		return false
-	})
+// newVcrRecorder returns a transport recording to or replaying from the
+// cassette at path, for both tests and provider runs. Requests are matched to
+// recorded interactions by vcrReplayer rather than the go-vcr matcher, which
+// can't tell identical requests apart.
+func newVcrRecorder(path string, vcrMode recorder.Mode, rndTripper http.RoundTripper) (vcrTransport, error) {
+	redactor, err := vcrRedactorFromEnv()
+	if err != nil {
+		return nil, err
+	}
 
-	return pollInterval, rec, diags
+	if vcrMode == recorder.ModeReplaying {
+		return newVcrReplayer(path, redactor)
+	}
+	return recorder.NewAsMode(path, vcrMode, rndTripper)
 }
 
 // MuxedProviders configures the providers, thus, if we want the providers to be configured
@@ -429,9 +419,11 @@ func configureApiClient(ctx context.Context, p *frameworkProvider, diags *fwDiag
 	p.LoadAndValidateFramework(ctx, data, "test", diags)
 }
 
-// GetSDKProvider gets the SDK provider with an overwritten configure function to be called by MuxedProviders
+// GetSDKProvider gets the SDK provider with an overwritten configure function to be called by MuxedProviders.
+// Resources created by the test are stamped with ownership labels for sweepers.
 func GetSDKProvider(testName string) *schema.Provider {
 	prov := Provider()
+	stampTestResourceLabels(prov, testName)
 	if isVcrEnabled() {
 		old := prov.ConfigureContextFunc
 		prov.ConfigureContextFunc = func(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
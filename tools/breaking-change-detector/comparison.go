@@ -10,22 +10,73 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
-func compare() []string {
-	resourceMapOld := oldProvider.ResourceMap()
-	resourceMapNew := newProvider.ResourceMap()
+func compare() report {
+	frameworkOld, frameworkNew := loadFrameworkProviderSchemas()
+
+	resourceMapOld := mergeResourceMaps(oldProvider.ResourceMap(), frameworkSchemasToResourceMap(frameworkOld.ResourceSchemas))
+	resourceMapNew := mergeResourceMaps(newProvider.ResourceMap(), frameworkSchemasToResourceMap(frameworkNew.ResourceSchemas))
+
+	envVarsOld, envVarsNew := loadProviderEnvVars()
+	breakages := compareProviderSchema(
+		rules.ProviderConfig{Schema: oldProvider.Provider().Schema, EnvVars: envVarsOld},
+		rules.ProviderConfig{Schema: newProvider.Provider().Schema, EnvVars: envVarsNew},
+	)
+	breakages = append(breakages, compareResourceMaps(resourceMapOld, resourceMapNew)...)
+
+	datasourceMapOld := mergeResourceMaps(oldProvider.Provider().DataSourcesMap, frameworkSchemasToResourceMap(frameworkOld.DataSourceSchemas))
+	datasourceMapNew := mergeResourceMaps(newProvider.Provider().DataSourcesMap, frameworkSchemasToResourceMap(frameworkNew.DataSourceSchemas))
+	breakages = append(breakages, compareDatasourceMaps(datasourceMapOld, datasourceMapNew)...)
+
+	if *oldResourceIdentities != "" && *newResourceIdentities != "" {
+		identitiesOld := loadResourceIdentities(*oldResourceIdentities)
+		identitiesNew := loadResourceIdentities(*newResourceIdentities)
+		breakages = append(breakages, compareResourceIdentities(identitiesOld, identitiesNew)...)
+	}
+
+	suppressions := loadSuppressions(*suppressionFile)
+	return applySuppressions(breakages, suppressions, *releaseVersion)
+}
+
+// compareProviderSchema runs the provider config
+// rules against the provider block configuration
+func compareProviderSchema(old, new rules.ProviderConfig) []rules.Breakage {
+	breakages := []rules.Breakage{}
+	old.Schema = flattenSchema(old.Schema)
+	new.Schema = flattenSchema(new.Schema)
+
+	for _, rule := range rules.ProviderConfigRules {
+		mc := rules.MessageContext{
+			Version: *providerVersion,
+		}
+		breakages = append(breakages, rule.IsRuleBreak(old, new, mc)...)
+	}
+
+	return breakages
+}
+
+func compareResourceMaps(old, new map[string]*schema.Resource) []rules.Breakage {
+	return compareSchemaMaps(old, new, false)
+}
 
-	return compareResourceMaps(resourceMapOld, resourceMapNew)
+func compareDatasourceMaps(old, new map[string]*schema.Resource) []rules.Breakage {
+	return compareSchemaMaps(old, new, true)
 }
 
-func compareResourceMaps(old, new map[string]*schema.Resource) []string {
-	messages := []string{}
+// compareSchemaMaps runs the inventory, schema and field
+// rules against either the resource or the datasource map
+func compareSchemaMaps(old, new map[string]*schema.Resource, datasource bool) []rules.Breakage {
+	breakages := []rules.Breakage{}
 
 	for _, rule := range rules.ResourceInventoryRules {
 		violatingResources := rule.IsRuleBreak(old, new)
 		if len(violatingResources) > 0 {
 			for _, resourceName := range violatingResources {
-				newMessage := rule.Message(*providerVersion, resourceName)
-				messages = append(messages, newMessage)
+				newBreakage := rule.Breakage(rules.MessageContext{
+					Resource:   resourceName,
+					Version:    *providerVersion,
+					Datasource: datasource,
+				})
+				breakages = append(breakages, newBreakage)
 			}
 		}
 
@@ -34,16 +85,45 @@ func compareResourceMaps(old, new map[string]*schema.Resource) []string {
 	for resourceName, resource := range new {
 		oldResource, ok := old[resourceName]
 		if ok {
-			newMessages := compareResourceSchema(resourceName, oldResource.Schema, resource.Schema)
-			messages = append(messages, newMessages...)
+			mc := rules.MessageContext{
+				Resource:   resourceName,
+				Version:    *providerVersion,
+				Datasource: datasource,
+			}
+			newBreakages := compareResourceSchema(mc, oldResource.Schema, resource.Schema)
+			breakages = append(breakages, newBreakages...)
+		}
+	}
+
+	return breakages
+}
+
+func compareResourceIdentities(old, new map[string]rules.ResourceIdentity) []rules.Breakage {
+	breakages := []rules.Breakage{}
+
+	for resourceName, identity := range new {
+		oldIdentity, ok := old[resourceName]
+		if !ok {
+			continue
+		}
+		for _, rule := range rules.ResourceSchemaRules {
+			violatingFormats := rule.IsIdentityRuleBreak(oldIdentity, identity)
+			for _, format := range violatingFormats {
+				newBreakage := rule.Breakage(rules.MessageContext{
+					Resource: resourceName,
+					Field:    format,
+					Version:  *providerVersion,
+				})
+				breakages = append(breakages, newBreakage)
+			}
 		}
 	}
 
-	return messages
+	return breakages
 }
 
-func compareResourceSchema(resourceName string, old, new map[string]*schema.Schema) []string {
-	messages := []string{}
+func compareResourceSchema(mc rules.MessageContext, old, new map[string]*schema.Schema) []rules.Breakage {
+	breakages := []rules.Breakage{}
 	oldCompressed := flattenSchema(old)
 	newCompressed := flattenSchema(new)
 
@@ -51,42 +131,37 @@ func compareResourceSchema(resourceName string, old, new map[string]*schema.Sche
 		violatingFields := rule.IsRuleBreak(oldCompressed, newCompressed)
 		if len(violatingFields) > 0 {
 			for _, fieldName := range violatingFields {
-				newMessage := rule.Message(*providerVersion, resourceName, fieldName)
-				messages = append(messages, newMessage)
+				mc.Field = fieldName
+				newBreakage := rule.Breakage(mc)
+				breakages = append(breakages, newBreakage)
 			}
 		}
 	}
 
+	mc.OldSchema = oldCompressed
 	for fieldName, field := range newCompressed {
 		oldField, ok := oldCompressed[fieldName]
 		if ok {
-			newMessages := compareField(resourceName, fieldName, oldField, field)
-			messages = append(messages, newMessages...)
+			mc.Field = fieldName
+			newBreakages := compareField(mc, oldField, field)
+			breakages = append(breakages, newBreakages...)
 		}
 	}
 
-	return messages
+	return breakages
 }
 
-func compareField(resourceName, fieldName string, old, new *schema.Schema) []string {
-	messages := []string{}
+func compareField(mc rules.MessageContext, old, new *schema.Schema) []rules.Breakage {
+	breakages := []rules.Breakage{}
 	fieldRules := rules.FieldRules
 
 	for _, rule := range fieldRules {
-		breakageMessage := rule.IsRuleBreak(
-			old,
-			new,
-			rules.MessageContext{
-				Resource: resourceName,
-				Field:    fieldName,
-				Version:  *providerVersion,
-			},
-		)
-		if breakageMessage != "" {
-			messages = append(messages, breakageMessage)
+		breakage := rule.IsRuleBreak(old, new, mc)
+		if breakage != nil {
+			breakages = append(breakages, *breakage)
 		}
 	}
-	return messages
+	return breakages
 }
 
 func flattenSchema(schemaObj map[string]*schema.Schema) map[string]*schema.Schema {
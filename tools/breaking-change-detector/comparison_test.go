@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/GoogleCloudPlatform/magic-modules/.ci/breaking-change-detector/rules"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
@@ -334,11 +335,128 @@ var comparisonEngineTestCases = []comparisonEngineTestCase{
 func (tc *comparisonEngineTestCase) check(t *testing.T) {
 	violations := compareResourceMaps(tc.oldResourceMap, tc.newResourceMap)
 	for _, v := range violations {
-		if strings.Contains(v, "{{") || strings.Contains(v, "}}") {
-			t.Errorf("Test `%s` failed: found unreplaced characters in string - %s", tc.name, v)
+		if strings.Contains(v.Message, "{{") || strings.Contains(v.Message, "}}") {
+			t.Errorf("Test `%s` failed: found unreplaced characters in string - %s", tc.name, v.Message)
 		}
 	}
 	if tc.expectedViolations != len(violations) {
 		t.Errorf("Test `%s` failed: expected %d violations, got %d", tc.name, tc.expectedViolations, len(violations))
 	}
 }
+
+func TestComparisonEngine_ResourceIdentities(t *testing.T) {
+	old := map[string]rules.ResourceIdentity{
+		"google-x": {
+			IdFormat:      "projects/{{project}}/xs/{{name}}",
+			ImportFormats: []string{"projects/{{project}}/xs/{{name}}", "{{name}}"},
+		},
+		"google-y": {
+			IdFormat:      "projects/{{project}}/ys/{{name}}",
+			ImportFormats: []string{"projects/{{project}}/ys/{{name}}"},
+		},
+	}
+	new := map[string]rules.ResourceIdentity{
+		"google-x": {
+			IdFormat:      "{{project}}/{{name}}",
+			ImportFormats: []string{"projects/{{project}}/xs/{{name}}"},
+		},
+	}
+
+	violations := compareResourceIdentities(old, new)
+	for _, v := range violations {
+		if strings.Contains(v.Message, "{{resource}}") || strings.Contains(v.Message, "{{field}}") {
+			t.Errorf("Test `%s` failed: found unreplaced characters in string - %s", "resource identities", v.Message)
+		}
+	}
+	if len(violations) != 2 {
+		t.Errorf("Test `%s` failed: expected %d violations, got %d", "resource identities", 2, len(violations))
+	}
+}
+
+func TestComparisonEngine_Datasources(t *testing.T) {
+	old := map[string]*schema.Resource{
+		"google-x": {
+			Schema: map[string]*schema.Schema{
+				"field-a": {Description: "beep", Optional: true},
+				"field-b": {Description: "beep", Optional: true},
+			},
+		},
+		"google-y": {
+			Schema: map[string]*schema.Schema{
+				"field-a": {Description: "beep", Optional: true},
+			},
+		},
+	}
+	new := map[string]*schema.Resource{
+		"google-x": {
+			Schema: map[string]*schema.Schema{
+				"field-a": {Description: "beep", Required: true},
+			},
+		},
+	}
+
+	violations := compareDatasourceMaps(old, new)
+	if len(violations) != 3 {
+		t.Errorf("Test `%s` failed: expected %d violations, got %d", "datasources", 3, len(violations))
+	}
+	for _, v := range violations {
+		if !v.Datasource {
+			t.Errorf("Test `%s` failed: violation not marked as datasource - %s", "datasources", v.Message)
+		}
+		if strings.Contains(v.Message, "{{") || strings.Contains(v.Message, "}}") {
+			t.Errorf("Test `%s` failed: found unreplaced characters in string - %s", "datasources", v.Message)
+		}
+		if !strings.Contains(v.Message, "atasource `google-") || strings.Contains(v.Message, "esource `google-") {
+			t.Errorf("Test `%s` failed: message does not reference the datasource - %s", "datasources", v.Message)
+		}
+	}
+}
+
+func TestComparisonEngine_ProviderSchema(t *testing.T) {
+	old := map[string]*schema.Schema{
+		"project": {Type: schema.TypeString, Optional: true},
+		"batching": {
+			Type:     schema.TypeList,
+			Optional: true,
+			MaxItems: 1,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"send_after":      {Type: schema.TypeString, Optional: true},
+					"enable_batching": {Type: schema.TypeBool, Optional: true},
+				},
+			},
+		},
+	}
+	new := map[string]*schema.Schema{
+		"project": {Type: schema.TypeString, Required: true},
+		"batching": {
+			Type:     schema.TypeList,
+			Optional: true,
+			MaxItems: 1,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"send_after": {Type: schema.TypeInt, Optional: true},
+				},
+			},
+		},
+	}
+
+	oldEnvVars := map[string][]string{"project": {"GOOGLE_PROJECT", "GOOGLE_CLOUD_PROJECT"}}
+	newEnvVars := map[string][]string{"project": {"GOOGLE_PROJECT"}}
+
+	violations := compareProviderSchema(
+		rules.ProviderConfig{Schema: old, EnvVars: oldEnvVars},
+		rules.ProviderConfig{Schema: new, EnvVars: newEnvVars},
+	)
+	if len(violations) != 4 {
+		t.Errorf("Test `%s` failed: expected %d violations, got %d", "provider schema", 4, len(violations))
+	}
+	for _, v := range violations {
+		if v.Resource != rules.ProviderResource {
+			t.Errorf("Test `%s` failed: violation not reported on the provider - %s", "provider schema", v.Message)
+		}
+		if strings.Contains(v.Message, "{{") || strings.Contains(v.Message, "}}") {
+			t.Errorf("Test `%s` failed: found unreplaced characters in string - %s", "provider schema", v.Message)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"github.com/golang/glog"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// frameworkSchemasToResourceMap normalizes the schemas of plugin-framework
+// resources or datasources into the SDKv2 representation every rule
+// operates on. Framework schemas are read as served over the plugin
+// protocol, which is where their attributes and blocks are flattened into
+// a single shape regardless of the framework version a provider uses.
+//
+// Plan modifiers, defaults and validators are not part of the protocol
+// schema, so rules relying on them don't apply to framework schemas.
+func frameworkSchemasToResourceMap(schemas map[string]*tfprotov5.Schema) map[string]*schema.Resource {
+	resourceMap := make(map[string]*schema.Resource)
+	for name, s := range schemas {
+		if s == nil || s.Block == nil {
+			continue
+		}
+		resourceMap[name] = &schema.Resource{
+			Schema: frameworkBlockToSchemaMap(s.Block),
+		}
+	}
+	return resourceMap
+}
+
+func frameworkBlockToSchemaMap(block *tfprotov5.SchemaBlock) map[string]*schema.Schema {
+	schemaMap := make(map[string]*schema.Schema)
+	if block == nil {
+		return schemaMap
+	}
+
+	for _, attribute := range block.Attributes {
+		field := frameworkTypeToSchema(attribute.Type)
+		field.Description = attribute.Description
+		field.Required = attribute.Required
+		field.Optional = attribute.Optional
+		field.Computed = attribute.Computed
+		field.Sensitive = attribute.Sensitive
+		if attribute.Deprecated {
+			field.Deprecated = "deprecated"
+		}
+		schemaMap[attribute.Name] = field
+	}
+
+	for _, nested := range block.BlockTypes {
+		field := &schema.Schema{
+			Type:     schema.TypeList,
+			MinItems: int(nested.MinItems),
+			MaxItems: int(nested.MaxItems),
+			Required: nested.MinItems > 0,
+			Optional: nested.MinItems == 0,
+			Elem: &schema.Resource{
+				Schema: frameworkBlockToSchemaMap(nested.Block),
+			},
+		}
+		switch nested.Nesting {
+		case tfprotov5.SchemaNestedBlockNestingModeSet:
+			field.Type = schema.TypeSet
+		case tfprotov5.SchemaNestedBlockNestingModeMap:
+			field.Type = schema.TypeMap
+		case tfprotov5.SchemaNestedBlockNestingModeSingle, tfprotov5.SchemaNestedBlockNestingModeGroup:
+			// single nested blocks are the framework
+			// equivalent of a list with MaxItems 1
+			field.MaxItems = 1
+		}
+		schemaMap[nested.TypeName] = field
+	}
+
+	return schemaMap
+}
+
+// frameworkTypeToSchema maps a protocol type to an SDKv2 schema. Numbers
+// are mapped to TypeFloat as the protocol doesn't distinguish integers.
+func frameworkTypeToSchema(t tftypes.Type) *schema.Schema {
+	switch typed := t.(type) {
+	case tftypes.List:
+		return &schema.Schema{Type: schema.TypeList, Elem: frameworkElemToSchema(typed.ElementType)}
+	case tftypes.Set:
+		return &schema.Schema{Type: schema.TypeSet, Elem: frameworkElemToSchema(typed.ElementType)}
+	case tftypes.Map:
+		return &schema.Schema{Type: schema.TypeMap, Elem: frameworkElemToSchema(typed.ElementType)}
+	case tftypes.Object:
+		return &schema.Schema{Type: schema.TypeList, MaxItems: 1, Elem: frameworkElemToSchema(typed)}
+	}
+
+	switch {
+	case t == nil:
+		return &schema.Schema{}
+	case t.Is(tftypes.String):
+		return &schema.Schema{Type: schema.TypeString}
+	case t.Is(tftypes.Bool):
+		return &schema.Schema{Type: schema.TypeBool}
+	case t.Is(tftypes.Number):
+		return &schema.Schema{Type: schema.TypeFloat}
+	}
+
+	glog.Infof("unsupported framework type %s, treating it as undefined", t)
+	return &schema.Schema{}
+}
+
+// frameworkElemToSchema converts collection element types, using a
+// *schema.Resource for objects so their attributes are flattened
+func frameworkElemToSchema(t tftypes.Type) interface{} {
+	object, ok := t.(tftypes.Object)
+	if !ok {
+		return frameworkTypeToSchema(t)
+	}
+
+	objectSchema := make(map[string]*schema.Schema)
+	for name, attributeType := range object.AttributeTypes {
+		field := frameworkTypeToSchema(attributeType)
+		field.Optional = true
+		objectSchema[name] = field
+	}
+	return &schema.Resource{Schema: objectSchema}
+}
+
+// mergeResourceMaps combines SDKv2 and framework entries so resources
+// migrated between the two aren't reported as removed
+func mergeResourceMaps(sdkMap, frameworkMap map[string]*schema.Resource) map[string]*schema.Resource {
+	merged := make(map[string]*schema.Resource)
+	for key, value := range sdkMap {
+		merged[key] = value
+	}
+
+	for key, value := range frameworkMap {
+		if _, alreadyExists := merged[key]; alreadyExists {
+			glog.Errorf("error when trying to merge resource maps key " + key + " was found in both the sdk and framework providers.")
+			continue
+		}
+		merged[key] = value
+	}
+
+	return merged
+}
@@ -0,0 +1,36 @@
+//go:build framework
+
+package main
+
+import (
+	"context"
+	newProvider "google/provider/new/google-beta"
+	oldProvider "google/provider/old/google-beta"
+
+	"github.com/golang/glog"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+)
+
+// loadFrameworkProviderSchemas serves both framework providers
+// in process and reads their schemas over the plugin protocol.
+// Only built with the framework tag, as providers predating the
+// plugin framework don't export a framework provider.
+func loadFrameworkProviderSchemas() (old, new *tfprotov5.GetProviderSchemaResponse) {
+	old = getFrameworkProviderSchema(providerserver.NewProtocol5(oldProvider.New("old"))())
+	new = getFrameworkProviderSchema(providerserver.NewProtocol5(newProvider.New("new"))())
+	return old, new
+}
+
+func getFrameworkProviderSchema(server tfprotov5.ProviderServer) *tfprotov5.GetProviderSchemaResponse {
+	resp, err := server.GetProviderSchema(context.Background(), &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		glog.Exit(err)
+	}
+	for _, d := range resp.Diagnostics {
+		if d.Severity == tfprotov5.DiagnosticSeverityError {
+			glog.Exitf("error reading framework provider schema: %s: %s", d.Summary, d.Detail)
+		}
+	}
+	return resp
+}
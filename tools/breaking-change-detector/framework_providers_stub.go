@@ -0,0 +1,13 @@
+//go:build !framework
+
+package main
+
+import (
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+)
+
+// loadFrameworkProviderSchemas returns empty schemas unless built
+// with the framework tag, see framework_providers.go
+func loadFrameworkProviderSchemas() (old, new *tfprotov5.GetProviderSchemaResponse) {
+	return &tfprotov5.GetProviderSchemaResponse{}, &tfprotov5.GetProviderSchemaResponse{}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestFrameworkSchemaNormalization(t *testing.T) {
+	resourceMap := frameworkSchemasToResourceMap(map[string]*tfprotov5.Schema{
+		"google-x": {
+			Block: &tfprotov5.SchemaBlock{
+				Attributes: []*tfprotov5.SchemaAttribute{
+					{Name: "field-a", Type: tftypes.String, Required: true},
+					{Name: "field-b", Type: tftypes.List{ElementType: tftypes.Number}, Optional: true, Computed: true},
+				},
+				BlockTypes: []*tfprotov5.SchemaNestedBlock{
+					{
+						TypeName: "block-a",
+						Nesting:  tfprotov5.SchemaNestedBlockNestingModeSingle,
+						Block: &tfprotov5.SchemaBlock{
+							Attributes: []*tfprotov5.SchemaAttribute{
+								{Name: "sub-field-1", Type: tftypes.Bool, Optional: true},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	flattened := flattenSchema(resourceMap["google-x"].Schema)
+	if len(flattened) != 4 {
+		t.Fatalf("Test `%s` failed: expected %d flattened fields, got %d", "framework normalization", 4, len(flattened))
+	}
+	if f := flattened["field-a"]; f.Type != schema.TypeString || !f.Required {
+		t.Errorf("Test `%s` failed: unexpected field-a %+v", "framework normalization", f)
+	}
+	if f := flattened["field-b"]; f.Type != schema.TypeList || f.Elem.(*schema.Schema).Type != schema.TypeFloat {
+		t.Errorf("Test `%s` failed: unexpected field-b %+v", "framework normalization", f)
+	}
+	if f := flattened["block-a"]; f.Type != schema.TypeList || f.MaxItems != 1 || !f.Optional {
+		t.Errorf("Test `%s` failed: unexpected block-a %+v", "framework normalization", f)
+	}
+	if f := flattened["block-a.sub-field-1"]; f.Type != schema.TypeBool {
+		t.Errorf("Test `%s` failed: unexpected block-a.sub-field-1 %+v", "framework normalization", f)
+	}
+}
+
+func TestFrameworkComparison(t *testing.T) {
+	old := frameworkSchemasToResourceMap(map[string]*tfprotov5.Schema{
+		"google-x": {
+			Block: &tfprotov5.SchemaBlock{
+				Attributes: []*tfprotov5.SchemaAttribute{
+					{Name: "field-a", Type: tftypes.String, Optional: true},
+					{Name: "field-b", Type: tftypes.String, Optional: true},
+				},
+				BlockTypes: []*tfprotov5.SchemaNestedBlock{
+					{
+						TypeName: "block-a",
+						Nesting:  tfprotov5.SchemaNestedBlockNestingModeList,
+						MaxItems: 3,
+						Block:    &tfprotov5.SchemaBlock{},
+					},
+				},
+			},
+		},
+	})
+	new := frameworkSchemasToResourceMap(map[string]*tfprotov5.Schema{
+		"google-x": {
+			Block: &tfprotov5.SchemaBlock{
+				Attributes: []*tfprotov5.SchemaAttribute{
+					{Name: "field-a", Type: tftypes.String, Required: true},
+				},
+				BlockTypes: []*tfprotov5.SchemaNestedBlock{
+					{
+						TypeName: "block-a",
+						Nesting:  tfprotov5.SchemaNestedBlockNestingModeList,
+						MaxItems: 1,
+						Block:    &tfprotov5.SchemaBlock{},
+					},
+				},
+			},
+		},
+	})
+
+	// field-b removed, field-a optional to required, block-a max shrinking
+	violations := compareResourceMaps(old, new)
+	if len(violations) != 3 {
+		t.Errorf("Test `%s` failed: expected %d violations, got %d", "framework comparison", 3, len(violations))
+	}
+}
+
+func TestMergeResourceMaps(t *testing.T) {
+	sdkMap := map[string]*schema.Resource{
+		"google-x": {Schema: map[string]*schema.Schema{"field-a": {Optional: true}}},
+	}
+	frameworkMap := map[string]*schema.Resource{
+		"google-y": {Schema: map[string]*schema.Schema{"field-a": {Optional: true}}},
+	}
+
+	// a resource migrated from the sdk to the framework isn't a removal
+	violations := compareResourceMaps(mergeResourceMaps(sdkMap, nil), mergeResourceMaps(nil, sdkMap))
+	if len(violations) != 0 {
+		t.Errorf("Test `%s` failed: expected %d violations, got %d", "migrated resource", 0, len(violations))
+	}
+	if merged := mergeResourceMaps(sdkMap, frameworkMap); len(merged) != 2 {
+		t.Errorf("Test `%s` failed: expected %d resources, got %d", "merging resources", 2, len(merged))
+	}
+}
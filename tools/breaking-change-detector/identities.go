@@ -0,0 +1,24 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/GoogleCloudPlatform/magic-modules/.ci/breaking-change-detector/rules"
+	"github.com/golang/glog"
+)
+
+// loadResourceIdentities reads the resource_identities.json
+// metadata exported alongside a generated provider
+func loadResourceIdentities(path string) map[string]rules.ResourceIdentity {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		glog.Exit(err)
+	}
+
+	identities := make(map[string]rules.ResourceIdentity)
+	if err := json.Unmarshal(contents, &identities); err != nil {
+		glog.Exitf("error parsing resource identities from %s: %v", path, err)
+	}
+	return identities
+}
@@ -2,8 +2,7 @@ package main
 
 import (
 	"flag"
-	"fmt"
-	"sort"
+	"os"
 
 	"github.com/GoogleCloudPlatform/magic-modules/.ci/breaking-change-detector/docs"
 	"github.com/golang/glog"
@@ -12,6 +11,11 @@ import (
 var docMode = flag.Bool("docs", false, "Switches the mode from running the comparison to creating a markdown file detailing the breaking change rules")
 var providerFolder = flag.String("providerFolder", "", "The location of the provider folder to output documentation into.. if not provided the documentation will be output to console")
 var providerVersion = flag.String("providerVersion", "google-beta", "The version of provider used, needed for documentation.")
+var outputFormat = flag.String("outputFormat", outputFormatMarkdown, "The format breakages are reported in: markdown, json or sarif.")
+var suppressionFile = flag.String("suppressions", "suppressions.json", "The location of the suppression file acknowledging intentional breakages. No breakages are suppressed if it doesn't exist")
+var releaseVersion = flag.String("releaseVersion", "", "The provider version being released, used to expire suppressions. Suppressions never expire if not provided")
+var oldResourceIdentities = flag.String("oldResourceIdentities", "", "The location of the resource_identities.json exported by the old provider. ID and import format rules are skipped if not provided")
+var newResourceIdentities = flag.String("newResourceIdentities", "", "The location of the resource_identities.json exported by the new provider. ID and import format rules are skipped if not provided")
 
 func main() {
 	flag.Parse()
@@ -20,10 +24,9 @@ func main() {
 	if *docMode {
 		docs.Generate(*providerFolder)
 	} else {
-		breakages := compare()
-		sort.Strings(breakages)
-		for _, breakage := range breakages {
-			fmt.Println(breakage)
+		r := compare()
+		if err := writeReport(os.Stdout, *outputFormat, r); err != nil {
+			glog.Exit(err)
 		}
 	}
 
@@ -36,4 +39,10 @@ func validateParameters() {
 	if *providerVersion != "google" && *providerVersion != "google-beta" {
 		glog.Exitln("only google and google-beta are supported provider versions")
 	}
+	if *outputFormat != outputFormatMarkdown && *outputFormat != outputFormatJSON && *outputFormat != outputFormatSARIF {
+		glog.Exitln("only markdown, json and sarif are supported output formats")
+	}
+	if (*oldResourceIdentities == "") != (*newResourceIdentities == "") {
+		glog.Exitln("parameters -oldResourceIdentities and -newResourceIdentities must be set together")
+	}
 }
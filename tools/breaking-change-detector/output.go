@@ -0,0 +1,266 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/GoogleCloudPlatform/magic-modules/.ci/breaking-change-detector/constants"
+	"github.com/GoogleCloudPlatform/magic-modules/.ci/breaking-change-detector/rules"
+)
+
+const (
+	outputFormatMarkdown = "markdown"
+	outputFormatJSON     = "json"
+	outputFormatSARIF    = "sarif"
+)
+
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+const sarifVersion = "2.1.0"
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	FullDescription  sarifMessage `json:"fullDescription"`
+	HelpURI          string       `json:"helpUri"`
+}
+
+type sarifResult struct {
+	RuleID       string             `json:"ruleId"`
+	Level        string             `json:"level"`
+	Message      sarifMessage       `json:"message"`
+	Locations    []sarifLocation    `json:"locations"`
+	Suppressions []sarifSuppression `json:"suppressions,omitempty"`
+	Properties   map[string]string  `json:"properties,omitempty"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// staleSuppressionIdentifier - the rule id stale
+// suppressions are reported under in SARIF logs
+const staleSuppressionIdentifier = "stale-suppression"
+
+// sortBreakages orders breakages the same way
+// the markdown output has always been sorted
+func sortBreakages(breakages []rules.Breakage) {
+	sort.SliceStable(breakages, func(i, j int) bool {
+		return breakages[i].Markdown() < breakages[j].Markdown()
+	})
+}
+
+func sortSuppressed(suppressed []SuppressedBreakage) {
+	sort.SliceStable(suppressed, func(i, j int) bool {
+		return suppressed[i].Markdown() < suppressed[j].Markdown()
+	})
+}
+
+// writeReport writes the report to w in the given format. Markdown
+// lists the breakages first, one per line, as CI posts each line as a
+// breaking change. Suppressed breakages and stale suppressions follow
+// after a blank line, so CI can post them separately.
+func writeReport(w io.Writer, format string, r report) error {
+	sortBreakages(r.Breakages)
+	sortSuppressed(r.Suppressed)
+	switch format {
+	case outputFormatJSON:
+		return writeJSON(w, r)
+	case outputFormatSARIF:
+		return writeJSON(w, buildSARIF(r))
+	default:
+		writeMarkdown(w, r)
+	}
+	return nil
+}
+
+func writeMarkdown(w io.Writer, r report) {
+	for _, breakage := range r.Breakages {
+		fmt.Fprintln(w, breakage.Markdown())
+	}
+	if len(r.Suppressed) == 0 && len(r.StaleSuppressions) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	if len(r.Suppressed) > 0 {
+		fmt.Fprintln(w, "#### Suppressed breaking change(s)")
+		for _, suppressed := range r.Suppressed {
+			fmt.Fprintf(w, "* %s - suppressed: %s\n", suppressed.Markdown(), suppressed.Justification)
+		}
+	}
+	if len(r.StaleSuppressions) > 0 {
+		fmt.Fprintf(w, "#### Stale suppression(s)\nThese should be removed from `%s`.\n", *suppressionFile)
+		for _, stale := range r.StaleSuppressions {
+			fmt.Fprintf(w, "* %s - %s\n", stale.describe(), stale.Reason)
+		}
+	}
+}
+
+func (s Suppression) describe() string {
+	target := fmt.Sprintf("`%s`", s.Resource)
+	if s.Field != "" {
+		target = fmt.Sprintf("`%s.%s`", s.Resource, s.Field)
+	}
+	if s.Datasource {
+		target = "datasource " + target
+	}
+	return fmt.Sprintf("Suppression of `%s` on %s", s.Identifier, target)
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func buildSARIF(r report) sarifLog {
+	sarifRules := []sarifRule{}
+	seenRules := make(map[string]bool)
+	for _, category := range rules.GetRules().Categories {
+		for _, rule := range category.Rules {
+			if rule.Undetectable() || seenRules[rule.Identifier()] {
+				continue
+			}
+			seenRules[rule.Identifier()] = true
+			sarifRules = append(sarifRules, sarifRule{
+				ID:               rule.Identifier(),
+				Name:             rule.Name(),
+				ShortDescription: sarifMessage{Text: rule.Name()},
+				FullDescription:  sarifMessage{Text: rule.Definition()},
+				HelpURI:          constants.GetFileUrl(*providerVersion, rule.Identifier()),
+			})
+		}
+	}
+	sarifRules = append(sarifRules, sarifRule{
+		ID:               staleSuppressionIdentifier,
+		Name:             "Stale suppression",
+		ShortDescription: sarifMessage{Text: "Stale suppression"},
+		FullDescription:  sarifMessage{Text: "A suppression that has expired or no longer matches any breakage and should be removed from the suppression file."},
+	})
+
+	results := []sarifResult{}
+	for _, breakage := range r.Breakages {
+		results = append(results, sarifBreakageResult(breakage))
+	}
+	for _, suppressed := range r.Suppressed {
+		result := sarifBreakageResult(suppressed.Breakage)
+		result.Suppressions = []sarifSuppression{
+			{
+				Kind:          "external",
+				Justification: suppressed.Justification,
+			},
+		}
+		results = append(results, result)
+	}
+	for _, stale := range r.StaleSuppressions {
+		results = append(results, sarifResult{
+			RuleID:  staleSuppressionIdentifier,
+			Level:   "warning",
+			Message: sarifMessage{Text: stale.describe() + " - " + stale.Reason},
+			Locations: []sarifLocation{
+				{
+					LogicalLocations: []sarifLogicalLocation{
+						{
+							Name:               *suppressionFile,
+							FullyQualifiedName: *suppressionFile,
+							Kind:               "suppression",
+						},
+					},
+				},
+			},
+		})
+	}
+
+	return sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{
+			{
+				Tool: sarifTool{
+					Driver: sarifDriver{
+						Name:           "breaking-change-detector",
+						InformationURI: "https://github.com/GoogleCloudPlatform/magic-modules/tree/main/tools/breaking-change-detector",
+						Rules:          sarifRules,
+					},
+				},
+				Results: results,
+			},
+		},
+	}
+}
+
+func sarifBreakageResult(breakage rules.Breakage) sarifResult {
+	fullyQualifiedName := breakage.Resource
+	kind := "resource"
+	if breakage.Datasource {
+		fullyQualifiedName = "data." + breakage.Resource
+		kind = "datasource"
+	}
+	if breakage.Field != "" {
+		fullyQualifiedName += "." + breakage.Field
+		kind = "field"
+	}
+	properties := make(map[string]string)
+	if breakage.OldValue != "" {
+		properties["oldValue"] = breakage.OldValue
+	}
+	if breakage.NewValue != "" {
+		properties["newValue"] = breakage.NewValue
+	}
+	properties["documentationUrl"] = breakage.DocumentationURL
+	return sarifResult{
+		RuleID:  breakage.Identifier,
+		Level:   breakage.Severity,
+		Message: sarifMessage{Text: breakage.Message},
+		Locations: []sarifLocation{
+			{
+				LogicalLocations: []sarifLogicalLocation{
+					{
+						Name:               breakage.Resource,
+						FullyQualifiedName: fullyQualifiedName,
+						Kind:               kind,
+					},
+				},
+			},
+		},
+		Properties: properties,
+	}
+}
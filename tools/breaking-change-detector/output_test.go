@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/magic-modules/.ci/breaking-change-detector/rules"
+)
+
+var outputTestBreakages = []rules.Breakage{
+	{
+		Identifier:       "field-optional-to-required",
+		Resource:         "google-x",
+		Field:            "field-a",
+		OldValue:         "optional",
+		NewValue:         "required",
+		Severity:         rules.SeverityError,
+		Message:          "Field `field-a` changed from optional to required on `google-x`",
+		DocumentationURL: "https://example.com/#field-optional-to-required",
+	},
+	{
+		Identifier:       "resource-map-resource-removal-or-rename",
+		Resource:         "google-a",
+		Severity:         rules.SeverityError,
+		Message:          "Resource `google-a` was either removed or renamed",
+		DocumentationURL: "https://example.com/#resource-map-resource-removal-or-rename",
+	},
+}
+
+func TestWriteBreakages_Markdown(t *testing.T) {
+	out := bytes.Buffer{}
+	r := applySuppressions(outputTestBreakages, []Suppression{}, "")
+	if err := writeReport(&out, outputFormatMarkdown, r); err != nil {
+		t.Fatalf("Test `%s` failed: %v", "markdown", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Test `%s` failed: expected %d lines, got %d", "markdown", 2, len(lines))
+	}
+	if !strings.HasPrefix(lines[0], "Field `field-a`") || !strings.Contains(lines[0], " - [reference](https://example.com/#field-optional-to-required)") {
+		t.Errorf("Test `%s` failed: unexpected line %s", "markdown", lines[0])
+	}
+}
+
+func TestWriteBreakages_MarkdownSuppressed(t *testing.T) {
+	out := bytes.Buffer{}
+	r := applySuppressions(outputTestBreakages, []Suppression{
+		{
+			Identifier:    "resource-map-resource-removal-or-rename",
+			Resource:      "google-a",
+			Justification: "removed in the major release",
+		},
+		{
+			Identifier:    "field-changing-type",
+			Resource:      "google-z",
+			Justification: "no longer relevant",
+		},
+	}, "")
+	if err := writeReport(&out, outputFormatMarkdown, r); err != nil {
+		t.Fatalf("Test `%s` failed: %v", "markdown suppressed", err)
+	}
+	sections := strings.SplitN(out.String(), "\n\n", 2)
+	if len(sections) != 2 {
+		t.Fatalf("Test `%s` failed: expected breakages and suppressions separated by a blank line, got %q", "markdown suppressed", out.String())
+	}
+	lines := strings.Split(strings.TrimSpace(sections[0]), "\n")
+	if len(lines) != 1 || !strings.HasPrefix(lines[0], "Field `field-a`") {
+		t.Errorf("Test `%s` failed: expected only the unsuppressed breakage, got %q", "markdown suppressed", sections[0])
+	}
+	if !strings.Contains(sections[1], "Suppressed breaking change(s)") || !strings.Contains(sections[1], "suppressed: removed in the major release") {
+		t.Errorf("Test `%s` failed: expected the suppressed breakage, got %q", "markdown suppressed", sections[1])
+	}
+	if !strings.Contains(sections[1], "Stale suppression(s)") || !strings.Contains(sections[1], "`field-changing-type` on `google-z`") {
+		t.Errorf("Test `%s` failed: expected the stale suppression, got %q", "markdown suppressed", sections[1])
+	}
+}
+
+func TestWriteBreakages_MarkdownOnlySuppressed(t *testing.T) {
+	out := bytes.Buffer{}
+	r := applySuppressions(outputTestBreakages[1:], []Suppression{
+		{
+			Identifier:    "resource-map-resource-removal-or-rename",
+			Resource:      "google-a",
+			Justification: "removed in the major release",
+		},
+	}, "")
+	if err := writeReport(&out, outputFormatMarkdown, r); err != nil {
+		t.Fatalf("Test `%s` failed: %v", "markdown only suppressed", err)
+	}
+	if !strings.HasPrefix(out.String(), "\n#### Suppressed breaking change(s)\n") {
+		t.Errorf("Test `%s` failed: expected no breakages before the suppressions, got %q", "markdown only suppressed", out.String())
+	}
+}
+
+func TestWriteBreakages_JSON(t *testing.T) {
+	out := bytes.Buffer{}
+	r := applySuppressions(outputTestBreakages, []Suppression{}, "")
+	if err := writeReport(&out, outputFormatJSON, r); err != nil {
+		t.Fatalf("Test `%s` failed: %v", "json", err)
+	}
+	var decoded report
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("Test `%s` failed: %v", "json", err)
+	}
+	records := decoded.Breakages
+	if len(records) != 2 {
+		t.Fatalf("Test `%s` failed: expected %d records, got %d", "json", 2, len(records))
+	}
+	if records[0].OldValue != "optional" || records[0].NewValue != "required" {
+		t.Errorf("Test `%s` failed: old/new values not preserved - %+v", "json", records[0])
+	}
+}
+
+func TestWriteBreakages_SARIF(t *testing.T) {
+	out := bytes.Buffer{}
+	r := applySuppressions(outputTestBreakages, []Suppression{
+		{
+			Identifier:    "resource-map-resource-removal-or-rename",
+			Resource:      "google-a",
+			Justification: "removed in the major release",
+		},
+		{
+			Identifier:    "field-changing-type",
+			Resource:      "google-z",
+			Justification: "no longer relevant",
+		},
+	}, "")
+	if err := writeReport(&out, outputFormatSARIF, r); err != nil {
+		t.Fatalf("Test `%s` failed: %v", "sarif", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(out.Bytes(), &log); err != nil {
+		t.Fatalf("Test `%s` failed: %v", "sarif", err)
+	}
+	if log.Version != sarifVersion || len(log.Runs) != 1 {
+		t.Fatalf("Test `%s` failed: malformed sarif log %+v", "sarif", log)
+	}
+	results := log.Runs[0].Results
+	if len(results) != 3 {
+		t.Fatalf("Test `%s` failed: expected %d results, got %d", "sarif", 3, len(results))
+	}
+	if len(results[1].Suppressions) != 1 || results[1].Suppressions[0].Justification != "removed in the major release" {
+		t.Errorf("Test `%s` failed: expected suppressed result, got %+v", "sarif", results[1])
+	}
+	if results[2].RuleID != staleSuppressionIdentifier {
+		t.Errorf("Test `%s` failed: expected stale suppression result, got %+v", "sarif", results[2])
+	}
+	location := results[0].Locations[0].LogicalLocations[0]
+	if results[0].RuleID != "field-optional-to-required" || location.FullyQualifiedName != "google-x.field-a" {
+		t.Errorf("Test `%s` failed: unexpected result %+v", "sarif", results[0])
+	}
+	ruleIds := make(map[string]bool)
+	for _, rule := range log.Runs[0].Tool.Driver.Rules {
+		if ruleIds[rule.ID] {
+			t.Errorf("Test `%s` failed: rule %s listed twice", "sarif", rule.ID)
+		}
+		ruleIds[rule.ID] = true
+	}
+	if !ruleIds["field-optional-to-required"] {
+		t.Errorf("Test `%s` failed: rule %s missing from driver", "sarif", "field-optional-to-required")
+	}
+}
@@ -0,0 +1,15 @@
+//go:build provider_env_vars
+
+package main
+
+import (
+	newProvider "google/provider/new/google-beta"
+	oldProvider "google/provider/old/google-beta"
+)
+
+// loadProviderEnvVars reads the environment variables provider
+// attributes fall back to. Only built with the provider_env_vars
+// tag, as providers predating ProviderEnvVars don't export it.
+func loadProviderEnvVars() (old, new map[string][]string) {
+	return oldProvider.ProviderEnvVars(), newProvider.ProviderEnvVars()
+}
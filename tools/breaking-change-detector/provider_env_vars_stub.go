@@ -0,0 +1,9 @@
+//go:build !provider_env_vars
+
+package main
+
+// loadProviderEnvVars returns no environment variables unless built
+// with the provider_env_vars tag, see provider_env_vars.go
+func loadProviderEnvVars() (old, new map[string][]string) {
+	return nil, nil
+}
@@ -2,7 +2,11 @@ package rules
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+
+	"github.com/GoogleCloudPlatform/magic-modules/.ci/breaking-change-detector/constants"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 // MessageContext - is an envelope for additional
@@ -11,14 +15,98 @@ type MessageContext struct {
 	Resource   string
 	Field      string
 	Version    string
+	Datasource bool
+	// OldSchema is the flattened schema of the old resource,
+	// for rules whose fields refer to other fields. When nil,
+	// every referred field is assumed to have existed.
+	OldSchema  map[string]*schema.Schema
 	identifier string
 	message    string
+	oldValue   string
+	newValue   string
+}
+
+// Breakage - a detected rule breakage with the
+// context needed to report it in any output format
+type Breakage struct {
+	Identifier       string `json:"identifier"`
+	Resource         string `json:"resource"`
+	Datasource       bool   `json:"datasource,omitempty"`
+	Field            string `json:"field,omitempty"`
+	OldValue         string `json:"old_value,omitempty"`
+	NewValue         string `json:"new_value,omitempty"`
+	Severity         string `json:"severity"`
+	Message          string `json:"message"`
+	DocumentationURL string `json:"documentation_url"`
+}
+
+// SeverityError - the severity reported for
+// every breakage guarded by the rules
+const SeverityError = "error"
+
+// Markdown - the breakage formatted as a
+// markdown line with a documentation reference
+func (b Breakage) Markdown() string {
+	return b.Message + fmt.Sprintf(" - [reference](%s)", b.DocumentationURL)
+}
+
+// existedInOldSchema informs if the field referred to by
+// path, such as a ConflictsWith entry, was part of the old
+// resource. List indexes in the path are ignored.
+func (mc MessageContext) existedInOldSchema(path string) bool {
+	if mc.OldSchema == nil {
+		return true
+	}
+	_, ok := mc.OldSchema[schemaPath(path)]
+	return ok
+}
+
+// oldFields returns the fields referred to by paths that
+// were part of the old resource
+func (mc MessageContext) oldFields(paths []string) []string {
+	fields := []string{}
+	for _, path := range paths {
+		if mc.existedInOldSchema(path) {
+			fields = append(fields, path)
+		}
+	}
+	return fields
+}
+
+// schemaPath drops the list indexes of a field reference,
+// such as `block.0.field`, to match flattened schema keys
+func schemaPath(path string) string {
+	parts := []string{}
+	for _, part := range strings.Split(path, ".") {
+		if _, err := strconv.Atoi(part); err != nil {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ".")
 }
 
-func populateMessageContext(message string, mc MessageContext) string {
+func populateMessageContext(message string, mc MessageContext) *Breakage {
 	resource := fmt.Sprintf("`%s`", mc.Resource)
+	if mc.Datasource {
+		// reword resource mentions so datasource
+		// breakages are distinguishable
+		message = strings.ReplaceAll(message, "Resource {{resource}}", "Datasource {{datasource}}")
+		message = strings.ReplaceAll(message, "resource {{resource}}", "datasource {{datasource}}")
+		message = strings.ReplaceAll(message, "{{resource}}", "datasource {{datasource}}")
+		message = strings.ReplaceAll(message, "{{datasource}}", resource)
+	}
 	field := fmt.Sprintf("`%s`", mc.Field)
 	message = strings.ReplaceAll(message, "{{resource}}", resource)
 	message = strings.ReplaceAll(message, "{{field}}", field)
-	return message + documentationReference(mc.Version, mc.identifier)
+	return &Breakage{
+		Identifier:       mc.identifier,
+		Resource:         mc.Resource,
+		Datasource:       mc.Datasource,
+		Field:            mc.Field,
+		OldValue:         mc.oldValue,
+		NewValue:         mc.newValue,
+		Severity:         SeverityError,
+		Message:          message,
+		DocumentationURL: constants.GetFileUrl(mc.Version, mc.identifier),
+	}
 }
@@ -14,7 +14,7 @@ type FieldRule struct {
 	definition  string
 	message     string
 	identifier  string
-	isRuleBreak func(old, new *schema.Schema, mc MessageContext) string
+	isRuleBreak func(old, new *schema.Schema, mc MessageContext) *Breakage
 }
 
 // FieldRules is a list of FieldRule
@@ -27,6 +27,11 @@ var FieldRules = []FieldRule{
 	fieldRule_DefaultModification,
 	fieldRule_GrowingMin,
 	fieldRule_ShrinkingMax,
+	fieldRule_BecomingForceNew,
+	fieldRule_AddingConflictsWith,
+	fieldRule_AddingExactlyOneOf,
+	fieldRule_AddingAtLeastOneOf,
+	fieldRule_ShrinkingEnum,
 	fieldRule_ChangingFieldDataFormat,
 }
 
@@ -44,13 +49,14 @@ var fieldRule_ChangingType = FieldRule{
 	isRuleBreak: fieldRule_ChangingType_func,
 }
 
-func fieldRule_ChangingType_func(old, new *schema.Schema, mc MessageContext) string {
+func fieldRule_ChangingType_func(old, new *schema.Schema, mc MessageContext) *Breakage {
 	message := mc.message
 	if old.Type != new.Type {
 		oldType := getValueType(old.Type)
 		newType := getValueType(new.Type)
 		message = strings.ReplaceAll(message, "{{oldType}}", oldType)
 		message = strings.ReplaceAll(message, "{{newType}}", newType)
+		mc.oldValue, mc.newValue = oldType, newType
 		return populateMessageContext(message, mc)
 	}
 
@@ -61,10 +67,11 @@ func fieldRule_ChangingType_func(old, new *schema.Schema, mc MessageContext) str
 		newType := getValueType(new.Type) + "." + getValueType(newCasted.Type)
 		message = strings.ReplaceAll(message, "{{oldType}}", oldType)
 		message = strings.ReplaceAll(message, "{{newType}}", newType)
+		mc.oldValue, mc.newValue = oldType, newType
 		return populateMessageContext(message, mc)
 	}
 
-	return ""
+	return nil
 }
 
 var fieldRule_BecomingRequired = FieldRule{
@@ -75,13 +82,14 @@ var fieldRule_BecomingRequired = FieldRule{
 	isRuleBreak: fieldRule_BecomingRequired_func,
 }
 
-func fieldRule_BecomingRequired_func(old, new *schema.Schema, mc MessageContext) string {
+func fieldRule_BecomingRequired_func(old, new *schema.Schema, mc MessageContext) *Breakage {
 	message := mc.message
 	if !old.Required && new.Required {
+		mc.oldValue, mc.newValue = "optional", "required"
 		return populateMessageContext(message, mc)
 	}
 
-	return ""
+	return nil
 }
 
 var fieldRule_BecomingComputedOnly = FieldRule{
@@ -92,18 +100,19 @@ var fieldRule_BecomingComputedOnly = FieldRule{
 	isRuleBreak: fieldRule_BecomingComputedOnly_func,
 }
 
-func fieldRule_BecomingComputedOnly_func(old, new *schema.Schema, mc MessageContext) string {
+func fieldRule_BecomingComputedOnly_func(old, new *schema.Schema, mc MessageContext) *Breakage {
 	message := mc.message
 	// if the field is computed only already
 	// this rule doesn't apply
 	if old.Computed && !old.Optional {
-		return ""
+		return nil
 	}
 
 	if new.Computed && !new.Optional {
+		mc.newValue = "computed"
 		return populateMessageContext(message, mc)
 	}
-	return ""
+	return nil
 }
 
 var fieldRule_OptionalComputedToOptional = FieldRule{
@@ -114,12 +123,13 @@ var fieldRule_OptionalComputedToOptional = FieldRule{
 	isRuleBreak: fieldRule_OptionalComputedToOptional_func,
 }
 
-func fieldRule_OptionalComputedToOptional_func(old, new *schema.Schema, mc MessageContext) string {
+func fieldRule_OptionalComputedToOptional_func(old, new *schema.Schema, mc MessageContext) *Breakage {
 	message := mc.message
 	if (old.Computed && old.Optional) && (new.Optional && !new.Computed) {
+		mc.oldValue, mc.newValue = "optional+computed", "optional"
 		return populateMessageContext(message, mc)
 	}
-	return ""
+	return nil
 }
 
 var fieldRule_DefaultModification = FieldRule{
@@ -130,17 +140,18 @@ var fieldRule_DefaultModification = FieldRule{
 	isRuleBreak: fieldRule_DefaultModification_func,
 }
 
-func fieldRule_DefaultModification_func(old, new *schema.Schema, mc MessageContext) string {
+func fieldRule_DefaultModification_func(old, new *schema.Schema, mc MessageContext) *Breakage {
 	message := mc.message
 	if old.Default != new.Default {
 		oldDefault := fmt.Sprintf("%v", old.Default)
 		newDefault := fmt.Sprintf("%v", new.Default)
 		message = strings.ReplaceAll(message, "{{oldDefault}}", oldDefault)
 		message = strings.ReplaceAll(message, "{{newDefault}}", newDefault)
+		mc.oldValue, mc.newValue = oldDefault, newDefault
 		return populateMessageContext(message, mc)
 	}
 
-	return ""
+	return nil
 }
 
 var fieldRule_GrowingMin = FieldRule{
@@ -151,36 +162,168 @@ var fieldRule_GrowingMin = FieldRule{
 	isRuleBreak: fieldRule_GrowingMin_func,
 }
 
-func fieldRule_GrowingMin_func(old, new *schema.Schema, mc MessageContext) string {
+func fieldRule_GrowingMin_func(old, new *schema.Schema, mc MessageContext) *Breakage {
 	message := mc.message
 	if old.MinItems < new.MinItems {
 		oldMin := fmt.Sprint(old.MinItems)
 		newMin := fmt.Sprint(new.MinItems)
 		message = strings.ReplaceAll(message, "{{oldMin}}", oldMin)
 		message = strings.ReplaceAll(message, "{{newMin}}", newMin)
+		mc.oldValue, mc.newValue = oldMin, newMin
 		return populateMessageContext(message, mc)
 	}
-	return ""
+	return nil
 }
 
 var fieldRule_ShrinkingMax = FieldRule{
 	name:        "Shrinking Maximum Items",
 	definition:  "MaxItems cannot shrink. Otherwise existing terraform configurations that don't satisfy this rule will break.",
-	message:     "Field {{field}} MinItems went from {{oldMax}} to {{newMax}} on {{resource}}",
+	message:     "Field {{field}} MaxItems went from {{oldMax}} to {{newMax}} on {{resource}}",
 	identifier:  "field-certain-min-max",
 	isRuleBreak: fieldRule_ShrinkingMax_func,
 }
 
-func fieldRule_ShrinkingMax_func(old, new *schema.Schema, mc MessageContext) string {
+func fieldRule_ShrinkingMax_func(old, new *schema.Schema, mc MessageContext) *Breakage {
 	message := mc.message
 	if old.MaxItems > new.MaxItems {
-		oldMax := fmt.Sprint(old.MinItems)
-		newMax := fmt.Sprint(new.MinItems)
+		oldMax := fmt.Sprint(old.MaxItems)
+		newMax := fmt.Sprint(new.MaxItems)
 		message = strings.ReplaceAll(message, "{{oldMax}}", oldMax)
 		message = strings.ReplaceAll(message, "{{newMax}}", newMax)
+		mc.oldValue, mc.newValue = oldMax, newMax
 		return populateMessageContext(message, mc)
 	}
-	return ""
+	return nil
+}
+
+var fieldRule_BecomingForceNew = FieldRule{
+	name:        "Field becoming ForceNew",
+	definition:  "A field cannot become ForceNew. Changes to the field that were previously applied in place will instead silently destroy and recreate the resource on subsequent applies.",
+	message:     "Field {{field}} became ForceNew on {{resource}}",
+	identifier:  "field-becoming-force-new",
+	isRuleBreak: fieldRule_BecomingForceNew_func,
+}
+
+func fieldRule_BecomingForceNew_func(old, new *schema.Schema, mc MessageContext) *Breakage {
+	message := mc.message
+	// computed only fields can't be changed by
+	// users so ForceNew has no effect on them
+	if new.Computed && !new.Optional {
+		return nil
+	}
+	if !old.ForceNew && new.ForceNew {
+		mc.oldValue, mc.newValue = "false", "true"
+		return populateMessageContext(message, mc)
+	}
+	return nil
+}
+
+var fieldRule_AddingConflictsWith = FieldRule{
+	name:        "Adding ConflictsWith constraints",
+	definition:  "A field cannot gain new ConflictsWith entries. Existing configurations may set both fields and will fail validation on subsequent plans. Conflicts with fields added at the same time are allowed, as no existing configuration sets them.",
+	message:     "Field {{field}} now conflicts with {{fields}} on {{resource}}",
+	identifier:  "field-adding-conflicts-with",
+	isRuleBreak: fieldRule_AddingConflictsWith_func,
+}
+
+func fieldRule_AddingConflictsWith_func(old, new *schema.Schema, mc MessageContext) *Breakage {
+	message := mc.message
+	// conflicts with new fields can't break existing configurations
+	added := mc.oldFields(stringSliceDifference(new.ConflictsWith, old.ConflictsWith))
+	if len(added) > 0 {
+		message = strings.ReplaceAll(message, "{{fields}}", formatStringSlice(added))
+		mc.oldValue, mc.newValue = formatStringSlice(old.ConflictsWith), formatStringSlice(new.ConflictsWith)
+		return populateMessageContext(message, mc)
+	}
+	return nil
+}
+
+var fieldRule_AddingExactlyOneOf = FieldRule{
+	name:        "Adding or restricting ExactlyOneOf constraints",
+	definition:  "A field cannot gain an ExactlyOneOf constraint, or lose entries from an existing one. Existing configurations that set none or a no longer listed field will fail validation on subsequent plans. Entries for fields added at the same time are ignored, as no existing configuration sets them.",
+	message:     "Field {{field}} ExactlyOneOf went from {{oldFields}} to {{newFields}} on {{resource}}",
+	identifier:  "field-adding-exactly-one-of",
+	isRuleBreak: fieldRule_AddingExactlyOneOf_func,
+}
+
+func fieldRule_AddingExactlyOneOf_func(old, new *schema.Schema, mc MessageContext) *Breakage {
+	if !isRestrictingOneOf(old, old.ExactlyOneOf, new.ExactlyOneOf, mc) {
+		return nil
+	}
+	return populateOneOfMessage(old.ExactlyOneOf, new.ExactlyOneOf, mc)
+}
+
+var fieldRule_AddingAtLeastOneOf = FieldRule{
+	name:        "Adding or restricting AtLeastOneOf constraints",
+	definition:  "A field cannot gain an AtLeastOneOf constraint, or lose entries from an existing one. Existing configurations that set none or only a no longer listed field will fail validation on subsequent plans. Entries for fields added at the same time are ignored, as no existing configuration sets them.",
+	message:     "Field {{field}} AtLeastOneOf went from {{oldFields}} to {{newFields}} on {{resource}}",
+	identifier:  "field-adding-at-least-one-of",
+	isRuleBreak: fieldRule_AddingAtLeastOneOf_func,
+}
+
+func fieldRule_AddingAtLeastOneOf_func(old, new *schema.Schema, mc MessageContext) *Breakage {
+	if !isRestrictingOneOf(old, old.AtLeastOneOf, new.AtLeastOneOf, mc) {
+		return nil
+	}
+	return populateOneOfMessage(old.AtLeastOneOf, new.AtLeastOneOf, mc)
+}
+
+// isRestrictingOneOf informs if a one-of style constraint
+// was added or lost any of its previously allowed fields.
+// Only fields of the old resource are considered, as new
+// fields aren't set by existing configurations.
+func isRestrictingOneOf(oldField *schema.Schema, old, new []string, mc MessageContext) bool {
+	new = mc.oldFields(new)
+	if len(new) == 0 {
+		return false
+	}
+	if len(old) == 0 {
+		// a required field now alternating with new fields
+		// is still set by every existing configuration
+		onlySelf := len(new) == 1 && schemaPath(new[0]) == mc.Field
+		return !(oldField.Required && onlySelf)
+	}
+	return len(stringSliceDifference(old, new)) > 0
+}
+
+func populateOneOfMessage(old, new []string, mc MessageContext) *Breakage {
+	message := mc.message
+	oldFields := formatStringSlice(old)
+	newFields := formatStringSlice(new)
+	message = strings.ReplaceAll(message, "{{oldFields}}", oldFields)
+	message = strings.ReplaceAll(message, "{{newFields}}", newFields)
+	mc.oldValue, mc.newValue = oldFields, newFields
+	return populateMessageContext(message, mc)
+}
+
+var fieldRule_ShrinkingEnum = FieldRule{
+	name:        "Removing enum values",
+	definition:  "A field cannot stop accepting a value it previously accepted. Existing configurations using a removed value will fail validation on subsequent plans. Detection probes the new validation, through `ValidateFunc` or `ValidateDiagFunc`, with the values the old field accepted: those its validation lists, such as `validation.StringInSlice` does, or documents as possible values. Fields without validation are compared through the possible values their descriptions document.",
+	message:     "Field {{field}} no longer accepts {{values}} on {{resource}}",
+	identifier:  "field-shrinking-enum",
+	isRuleBreak: fieldRule_ShrinkingEnum_func,
+}
+
+func fieldRule_ShrinkingEnum_func(old, new *schema.Schema, mc MessageContext) *Breakage {
+	message := mc.message
+	removed := []string{}
+	for _, value := range getPossibleValues(old) {
+		// only values the old field actually accepted are
+		// relevant, descriptions may be out of date
+		if !acceptsValue(old, value) {
+			continue
+		}
+		if !acceptsValue(new, value) {
+			removed = append(removed, value)
+		}
+	}
+
+	if len(removed) > 0 {
+		message = strings.ReplaceAll(message, "{{values}}", formatStringSlice(removed))
+		mc.oldValue = formatStringSlice(removed)
+		return populateMessageContext(message, mc)
+	}
+	return nil
 }
 
 func fieldRulesToRuleArray(frs []FieldRule) []Rule {
@@ -218,10 +361,10 @@ func (fr FieldRule) messages(version, resource, field string) string {
 }
 
 // IsRuleBreak - compares the fields and returns
-// a string defining the rule breakage if detected
-func (fr FieldRule) IsRuleBreak(old, new *schema.Schema, mc MessageContext) string {
+// the rule breakage if detected
+func (fr FieldRule) IsRuleBreak(old, new *schema.Schema, mc MessageContext) *Breakage {
 	if fr.isRuleBreak == nil {
-		return ""
+		return nil
 	}
 	mc.identifier = fr.identifier
 	mc.message = fr.message
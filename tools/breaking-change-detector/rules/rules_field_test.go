@@ -5,15 +5,29 @@ import (
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 type fieldTestCase struct {
-	name              string
-	oldField          *schema.Schema
-	newField          *schema.Schema
+	name     string
+	oldField *schema.Schema
+	newField *schema.Schema
+	// field and oldSchema locate the field in the old
+	// resource, for rules referring to other fields
+	field             string
+	oldSchema         map[string]*schema.Schema
 	expectedViolation bool
 }
 
+// oneOfTestSchema is an old resource with the fields
+// referred to by the cross-field constraint test cases
+var oneOfTestSchema = map[string]*schema.Schema{
+	"field-a":       {Optional: true},
+	"field-b":       {Optional: true},
+	"block":         {Optional: true},
+	"block.field-c": {Optional: true},
+}
+
 func TestFieldRule_BecomingRequired(t *testing.T) {
 	for _, tc := range fieldRule_BecomingRequiredTestCases {
 		tc.check(fieldRule_BecomingRequired, t)
@@ -428,11 +442,476 @@ var fieldRule_ShrinkingMaxTestCases = []fieldTestCase{
 	},
 }
 
+func TestFieldRule_BecomingForceNew(t *testing.T) {
+	for _, tc := range fieldRule_BecomingForceNewTestCases {
+		tc.check(fieldRule_BecomingForceNew, t)
+	}
+}
+
+var fieldRule_BecomingForceNewTestCases = []fieldTestCase{
+	{
+		name: "control",
+		oldField: &schema.Schema{
+			Optional: true,
+			ForceNew: true,
+		},
+		newField: &schema.Schema{
+			Optional: true,
+			ForceNew: true,
+		},
+		expectedViolation: false,
+	},
+	{
+		name: "control - no longer ForceNew",
+		oldField: &schema.Schema{
+			Optional: true,
+			ForceNew: true,
+		},
+		newField: &schema.Schema{
+			Optional: true,
+		},
+		expectedViolation: false,
+	},
+	{
+		name: "control - computed only",
+		oldField: &schema.Schema{
+			Computed: true,
+		},
+		newField: &schema.Schema{
+			Computed: true,
+			ForceNew: true,
+		},
+		expectedViolation: false,
+	},
+	{
+		name: "becoming ForceNew",
+		oldField: &schema.Schema{
+			Optional: true,
+		},
+		newField: &schema.Schema{
+			Optional: true,
+			ForceNew: true,
+		},
+		expectedViolation: true,
+	},
+}
+
+func TestFieldRule_AddingConflictsWith(t *testing.T) {
+	for _, tc := range fieldRule_AddingConflictsWithTestCases {
+		tc.check(fieldRule_AddingConflictsWith, t)
+	}
+}
+
+var fieldRule_AddingConflictsWithTestCases = []fieldTestCase{
+	{
+		name: "control",
+		oldField: &schema.Schema{
+			ConflictsWith: []string{"field-b"},
+		},
+		newField: &schema.Schema{
+			ConflictsWith: []string{"field-b"},
+		},
+		expectedViolation: false,
+	},
+	{
+		name: "control - removing a conflict",
+		oldField: &schema.Schema{
+			ConflictsWith: []string{"field-b", "field-c"},
+		},
+		newField: &schema.Schema{
+			ConflictsWith: []string{"field-b"},
+		},
+		expectedViolation: false,
+	},
+	{
+		name:     "adding ConflictsWith",
+		oldField: &schema.Schema{},
+		newField: &schema.Schema{
+			ConflictsWith: []string{"field-b"},
+		},
+		expectedViolation: true,
+	},
+	{
+		name: "adding a conflict",
+		oldField: &schema.Schema{
+			ConflictsWith: []string{"field-b"},
+		},
+		newField: &schema.Schema{
+			ConflictsWith: []string{"field-b", "field-c"},
+		},
+		expectedViolation: true,
+	},
+	{
+		name:     "control - conflicting with a new field",
+		oldField: &schema.Schema{},
+		newField: &schema.Schema{
+			ConflictsWith: []string{"field-new"},
+		},
+		field:             "field-a",
+		oldSchema:         oneOfTestSchema,
+		expectedViolation: false,
+	},
+	{
+		name:     "conflicting with an existing nested field",
+		oldField: &schema.Schema{},
+		newField: &schema.Schema{
+			ConflictsWith: []string{"field-new", "block.0.field-c"},
+		},
+		field:             "field-a",
+		oldSchema:         oneOfTestSchema,
+		expectedViolation: true,
+	},
+}
+
+func TestFieldRule_AddingExactlyOneOf(t *testing.T) {
+	for _, tc := range fieldRule_AddingExactlyOneOfTestCases {
+		tc.check(fieldRule_AddingExactlyOneOf, t)
+	}
+}
+
+var fieldRule_AddingExactlyOneOfTestCases = []fieldTestCase{
+	{
+		name: "control",
+		oldField: &schema.Schema{
+			ExactlyOneOf: []string{"field-a", "field-b"},
+		},
+		newField: &schema.Schema{
+			ExactlyOneOf: []string{"field-a", "field-b"},
+		},
+		expectedViolation: false,
+	},
+	{
+		name: "control - allowing another field",
+		oldField: &schema.Schema{
+			ExactlyOneOf: []string{"field-a", "field-b"},
+		},
+		newField: &schema.Schema{
+			ExactlyOneOf: []string{"field-a", "field-b", "field-c"},
+		},
+		expectedViolation: false,
+	},
+	{
+		name: "control - removing the constraint",
+		oldField: &schema.Schema{
+			ExactlyOneOf: []string{"field-a", "field-b"},
+		},
+		newField:          &schema.Schema{},
+		expectedViolation: false,
+	},
+	{
+		name:     "adding ExactlyOneOf",
+		oldField: &schema.Schema{},
+		newField: &schema.Schema{
+			ExactlyOneOf: []string{"field-a", "field-b"},
+		},
+		expectedViolation: true,
+	},
+	{
+		name: "control - required field alternating with a new field",
+		oldField: &schema.Schema{
+			Required: true,
+		},
+		newField: &schema.Schema{
+			Optional:     true,
+			ExactlyOneOf: []string{"field-a", "field-new"},
+		},
+		field:             "field-a",
+		oldSchema:         oneOfTestSchema,
+		expectedViolation: false,
+	},
+	{
+		name: "optional field alternating with a new field",
+		oldField: &schema.Schema{
+			Optional: true,
+		},
+		newField: &schema.Schema{
+			Optional:     true,
+			ExactlyOneOf: []string{"field-a", "field-new"},
+		},
+		field:             "field-a",
+		oldSchema:         oneOfTestSchema,
+		expectedViolation: true,
+	},
+	{
+		name: "removing an allowed field",
+		oldField: &schema.Schema{
+			ExactlyOneOf: []string{"field-a", "field-b", "field-c"},
+		},
+		newField: &schema.Schema{
+			ExactlyOneOf: []string{"field-a", "field-b"},
+		},
+		expectedViolation: true,
+	},
+}
+
+func TestFieldRule_AddingAtLeastOneOf(t *testing.T) {
+	for _, tc := range fieldRule_AddingAtLeastOneOfTestCases {
+		tc.check(fieldRule_AddingAtLeastOneOf, t)
+	}
+}
+
+var fieldRule_AddingAtLeastOneOfTestCases = []fieldTestCase{
+	{
+		name: "control",
+		oldField: &schema.Schema{
+			AtLeastOneOf: []string{"field-a", "field-b"},
+		},
+		newField: &schema.Schema{
+			AtLeastOneOf: []string{"field-a", "field-b"},
+		},
+		expectedViolation: false,
+	},
+	{
+		name:     "adding AtLeastOneOf",
+		oldField: &schema.Schema{},
+		newField: &schema.Schema{
+			AtLeastOneOf: []string{"field-a", "field-b"},
+		},
+		expectedViolation: true,
+	},
+	{
+		name:     "control - constraint between new fields",
+		oldField: &schema.Schema{},
+		newField: &schema.Schema{
+			AtLeastOneOf: []string{"field-new", "field-other-new"},
+		},
+		field:             "field-a",
+		oldSchema:         oneOfTestSchema,
+		expectedViolation: false,
+	},
+	{
+		name: "control - allowing a new field",
+		oldField: &schema.Schema{
+			AtLeastOneOf: []string{"field-a", "field-b"},
+		},
+		newField: &schema.Schema{
+			AtLeastOneOf: []string{"field-a", "field-b", "field-new"},
+		},
+		field:             "field-a",
+		oldSchema:         oneOfTestSchema,
+		expectedViolation: false,
+	},
+	{
+		name: "removing an allowed field",
+		oldField: &schema.Schema{
+			AtLeastOneOf: []string{"field-a", "field-b"},
+		},
+		newField: &schema.Schema{
+			AtLeastOneOf: []string{"field-a"},
+		},
+		expectedViolation: true,
+	},
+}
+
+func TestFieldRule_ShrinkingEnum(t *testing.T) {
+	for _, tc := range fieldRule_ShrinkingEnumTestCases {
+		tc.check(fieldRule_ShrinkingEnum, t)
+	}
+}
+
+var fieldRule_ShrinkingEnumTestCases = []fieldTestCase{
+	{
+		name: "control",
+		oldField: &schema.Schema{
+			Description:  `beep Possible values: ["A", "B"]`,
+			ValidateFunc: validation.StringInSlice([]string{"A", "B", ""}, false),
+		},
+		newField: &schema.Schema{
+			Description:  `beep Possible values: ["A", "B"]`,
+			ValidateFunc: validation.StringInSlice([]string{"A", "B", ""}, false),
+		},
+		expectedViolation: false,
+	},
+	{
+		name: "control - growing enum",
+		oldField: &schema.Schema{
+			Description:  `beep Possible values: ["A", "B"]`,
+			ValidateFunc: validation.StringInSlice([]string{"A", "B", ""}, false),
+		},
+		newField: &schema.Schema{
+			Description:  `beep Possible values: ["A", "B", "C"]`,
+			ValidateFunc: validation.StringInSlice([]string{"A", "B", "C", ""}, false),
+		},
+		expectedViolation: false,
+	},
+	{
+		name: "control - no validation",
+		oldField: &schema.Schema{
+			Description: `beep Possible values: ["A", "B"]`,
+		},
+		newField: &schema.Schema{
+			Description: `beep Possible values: ["A", "B"]`,
+		},
+		expectedViolation: false,
+	},
+	{
+		name: "control - stale description",
+		oldField: &schema.Schema{
+			Description:  `beep Possible values: ["A", "B", "Z"]`,
+			ValidateFunc: validation.StringInSlice([]string{"A", "B", ""}, false),
+		},
+		newField: &schema.Schema{
+			Description:  `beep Possible values: ["A", "B"]`,
+			ValidateFunc: validation.StringInSlice([]string{"A", "B", ""}, false),
+		},
+		expectedViolation: false,
+	},
+	{
+		name: "shrinking enum",
+		oldField: &schema.Schema{
+			Description:  `beep Possible values: ["A", "B"]`,
+			ValidateFunc: validation.StringInSlice([]string{"A", "B", ""}, false),
+		},
+		newField: &schema.Schema{
+			Description:  `beep Possible values: ["A"]`,
+			ValidateFunc: validation.StringInSlice([]string{"A", ""}, false),
+		},
+		expectedViolation: true,
+	},
+	{
+		name: "shrinking enum - default value",
+		oldField: &schema.Schema{
+			Default:      "B",
+			ValidateFunc: validation.StringInSlice([]string{"A", "B"}, false),
+		},
+		newField: &schema.Schema{
+			Default:      "B",
+			ValidateFunc: validation.StringInSlice([]string{"A"}, false),
+		},
+		expectedViolation: true,
+	},
+	{
+		name: "shrinking enum - list elements",
+		oldField: &schema.Schema{
+			Type:        schema.TypeList,
+			Description: `beep Possible values: ["A", "B"]`,
+			Elem: &schema.Schema{
+				Type:         schema.TypeString,
+				ValidateFunc: validation.StringInSlice([]string{"A", "B"}, false),
+			},
+		},
+		newField: &schema.Schema{
+			Type:        schema.TypeList,
+			Description: `beep Possible values: ["B"]`,
+			Elem: &schema.Schema{
+				Type:         schema.TypeString,
+				ValidateFunc: validation.StringInSlice([]string{"B"}, false),
+			},
+		},
+		expectedViolation: true,
+	},
+	{
+		name: "shrinking enum - validation only",
+		oldField: &schema.Schema{
+			ValidateFunc: validation.StringInSlice([]string{"A", "B", "C", ""}, false),
+		},
+		newField: &schema.Schema{
+			ValidateFunc: validation.StringInSlice([]string{"A", "C", ""}, false),
+		},
+		expectedViolation: true,
+	},
+	{
+		name: "shrinking enum - diag validation",
+		oldField: &schema.Schema{
+			ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{"A", "B"}, false)),
+		},
+		newField: &schema.Schema{
+			ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{"A"}, false)),
+		},
+		expectedViolation: true,
+	},
+	{
+		name: "shrinking enum - no validation",
+		oldField: &schema.Schema{
+			Description: `beep Possible values: ["A", "B"]`,
+		},
+		newField: &schema.Schema{
+			Description: `beep Possible values: ["A"]`,
+		},
+		expectedViolation: true,
+	},
+	{
+		name: "shrinking enum - element descriptions",
+		oldField: &schema.Schema{
+			Type: schema.TypeSet,
+			Elem: &schema.Schema{
+				Type:        schema.TypeString,
+				Description: `beep Possible values: ["A", "B"]`,
+			},
+		},
+		newField: &schema.Schema{
+			Type: schema.TypeSet,
+			Elem: &schema.Schema{
+				Type:        schema.TypeString,
+				Description: `beep Possible values: ["B"]`,
+			},
+		},
+		expectedViolation: true,
+	},
+	{
+		name: "control - validation removed",
+		oldField: &schema.Schema{
+			ValidateFunc: validation.StringInSlice([]string{"A", "B"}, false),
+		},
+		newField:          &schema.Schema{},
+		expectedViolation: false,
+	},
+	{
+		name: "control - not an enum",
+		oldField: &schema.Schema{
+			ValidateFunc: validation.StringLenBetween(1, 10),
+		},
+		newField: &schema.Schema{
+			ValidateFunc: validation.StringLenBetween(1, 5),
+		},
+		expectedViolation: false,
+	},
+}
+
+func TestGetPossibleValues(t *testing.T) {
+	cases := map[string]struct {
+		field    *schema.Schema
+		expected []string
+	}{
+		"description": {
+			field:    &schema.Schema{Description: `beep Possible values: ["A", "B"]`},
+			expected: []string{"A", "B"},
+		},
+		"validation": {
+			field:    &schema.Schema{ValidateFunc: validation.StringInSlice([]string{"A", "B", ""}, false)},
+			expected: []string{"A", "B"},
+		},
+		"description, validation and default": {
+			field: &schema.Schema{
+				Description:  `beep Possible values: ["A", "B"]`,
+				ValidateFunc: validation.StringInSlice([]string{"A", "C"}, false),
+				Default:      "D",
+			},
+			expected: []string{"A", "B", "C", "D"},
+		},
+		"element validation": {
+			field: &schema.Schema{
+				Type: schema.TypeList,
+				Elem: &schema.Schema{
+					Type:             schema.TypeString,
+					ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{"A", "B"}, false)),
+				},
+			},
+			expected: []string{"A", "B"},
+		},
+	}
+	for tn, tc := range cases {
+		if got := getPossibleValues(tc.field); strings.Join(got, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("Test `%s` failed: expected %v, got %v", tn, tc.expected, got)
+		}
+	}
+}
+
 func (tc *fieldTestCase) check(rule FieldRule, t *testing.T) {
-	breakage := rule.isRuleBreak(tc.oldField, tc.newField, MessageContext{})
+	breakage := rule.isRuleBreak(tc.oldField, tc.newField, MessageContext{Field: tc.field, OldSchema: tc.oldSchema})
 
-	violation := breakage != ""
-	if strings.Contains(breakage, "{{") {
+	violation := breakage != nil
+	if violation && strings.Contains(breakage.Message, "{{") {
 		t.Errorf("Test `%s` failed: replacements for `{{<val>}}` not successful ", tc.name)
 	}
 	if tc.expectedViolation != violation {
@@ -456,8 +935,29 @@ func TestBreakingMessage(t *testing.T) {
 		},
 	)
 
-	if !strings.Contains(breakageMessage, "Field `b` transitioned from optional+computed to optional `a`") {
+	if breakageMessage == nil || !strings.Contains(breakageMessage.Message, "Field `b` transitioned from optional+computed to optional `a`") {
 		t.Errorf("Test `%s` failed: replacements for `{{<val>}}` not successful ", "TestBreakingMessage")
 	}
 
 }
+
+func TestBreakingMessage_Datasource(t *testing.T) {
+	breakage := fieldRule_BecomingRequired.IsRuleBreak(
+		&schema.Schema{
+			Optional: true,
+		},
+		&schema.Schema{
+			Required: true,
+		},
+		MessageContext{
+			Resource:   "a",
+			Field:      "b",
+			Version:    "beta",
+			Datasource: true,
+		},
+	)
+
+	if breakage == nil || breakage.Message != "Field `b` changed from optional to required on datasource `a`" {
+		t.Errorf("Test `%s` failed: datasource message not distinguishable", "TestBreakingMessage_Datasource")
+	}
+}
@@ -1,34 +1,171 @@
 package rules
 
 import (
-	"fmt"
 	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
+// ProviderResource is the resource name used to
+// report breakages in the provider configuration block
+const ProviderResource = "provider"
+
+// ProviderConfig - the provider configuration
+// compared by the provider config rules
+type ProviderConfig struct {
+	// Schema is the flattened provider block schema
+	Schema map[string]*schema.Schema
+	// EnvVars maps provider attributes to the environment
+	// variables they fall back to, nil when unknown
+	EnvVars map[string][]string
+}
+
 // ProviderConfigRule provides
-// structure for rules regarding resource
-// inventory changes
+// structure for rules regarding provider
+// configuration schema changes
 type ProviderConfigRule struct {
 	name        string
 	definition  string
 	message     string
 	identifier  string
-	isRuleBreak func(old, new map[string]*schema.Resource) []string
+	isRuleBreak func(old, new ProviderConfig, mc MessageContext) []Breakage
 }
 
 // ProviderConfigRules is a list of ProviderConfigRule
 // guarding against provider breaking changes
-var ProviderConfigRules = []ProviderConfigRule{providerConfigRule_ConfigurationChanges}
+var ProviderConfigRules = []ProviderConfigRule{
+	providerConfigRule_RemovingAnAttribute,
+	providerConfigRule_ChangingAttributeType,
+	providerConfigRule_AddingRequiredAttribute,
+	providerConfigRule_RemovingEnvVarFallback,
+	providerConfigRule_ConfigurationChanges,
+}
 
 var providerConfigRule_ConfigurationChanges = ProviderConfigRule{
 	name:        "Changing fundamental provider behavior",
-	definition:  "Including, but not limited to modification of: authentication, environment variable usage, and constricting retry behavior.",
+	definition:  "Including, but not limited to modification of: authentication, configuration precedence, and constricting retry behavior.",
 	identifier:  "provider-config-fundamental",
 	isRuleBreak: nil,
 }
 
+var providerConfigRule_RemovingAnAttribute = ProviderConfigRule{
+	name:        "Removing a provider attribute",
+	definition:  "Provider attributes such as the `*_custom_endpoint` fields and the `batching` block are set in existing provider blocks. Removing one will cause configurations setting it to fail to plan.",
+	message:     "Provider attribute {{field}} was removed",
+	identifier:  "provider-config-removing-attribute",
+	isRuleBreak: providerConfigRule_RemovingAnAttribute_func,
+}
+
+func providerConfigRule_RemovingAnAttribute_func(old, new ProviderConfig, mc MessageContext) []Breakage {
+	breakages := []Breakage{}
+	for attributeName := range old.Schema {
+		if _, ok := new.Schema[attributeName]; !ok {
+			mc.Field = attributeName
+			breakages = append(breakages, *populateMessageContext(mc.message, mc))
+		}
+	}
+	return breakages
+}
+
+var providerConfigRule_ChangingAttributeType = ProviderConfigRule{
+	name:        "Changing a provider attribute type",
+	definition:  "Changing the type of a provider attribute will cause existing provider blocks and environment variable values to fail validation.",
+	message:     "Provider attribute {{field}} changed from {{oldType}} to {{newType}}",
+	identifier:  "provider-config-changing-attribute-type",
+	isRuleBreak: providerConfigRule_ChangingAttributeType_func,
+}
+
+func providerConfigRule_ChangingAttributeType_func(old, new ProviderConfig, mc MessageContext) []Breakage {
+	breakages := []Breakage{}
+	for attributeName, newAttribute := range new.Schema {
+		oldAttribute, ok := old.Schema[attributeName]
+		if !ok {
+			continue
+		}
+		oldType := getValueType(oldAttribute.Type)
+		newType := getValueType(newAttribute.Type)
+		oldElem, _ := oldAttribute.Elem.(*schema.Schema)
+		newElem, _ := newAttribute.Elem.(*schema.Schema)
+		if oldAttribute.Type == newAttribute.Type && oldElem != nil && newElem != nil {
+			oldType += "." + getValueType(oldElem.Type)
+			newType += "." + getValueType(newElem.Type)
+		}
+		if oldType == newType {
+			continue
+		}
+		message := strings.ReplaceAll(mc.message, "{{oldType}}", oldType)
+		message = strings.ReplaceAll(message, "{{newType}}", newType)
+		mc.Field = attributeName
+		mc.oldValue, mc.newValue = oldType, newType
+		breakages = append(breakages, *populateMessageContext(message, mc))
+	}
+	return breakages
+}
+
+var providerConfigRule_AddingRequiredAttribute = ProviderConfigRule{
+	name:        "Adding a required provider attribute",
+	definition:  "A new required provider attribute, or an existing one becoming required, breaks every provider block that does not set it. Attributes nested in a block that did not previously exist are exempt.",
+	message:     "Provider attribute {{field}} is now required",
+	identifier:  "provider-config-adding-required-attribute",
+	isRuleBreak: providerConfigRule_AddingRequiredAttribute_func,
+}
+
+func providerConfigRule_AddingRequiredAttribute_func(old, new ProviderConfig, mc MessageContext) []Breakage {
+	breakages := []Breakage{}
+	for attributeName, newAttribute := range new.Schema {
+		if !newAttribute.Required {
+			continue
+		}
+		if oldAttribute, ok := old.Schema[attributeName]; ok && oldAttribute.Required {
+			continue
+		}
+		// a required attribute inside a block that is
+		// new as well only applies to new configurations
+		if i := strings.LastIndex(attributeName, "."); i != -1 {
+			if _, ok := old.Schema[attributeName[:i]]; !ok {
+				continue
+			}
+		}
+		mc.Field = attributeName
+		breakages = append(breakages, *populateMessageContext(mc.message, mc))
+	}
+	return breakages
+}
+
+var providerConfigRule_RemovingEnvVarFallback = ProviderConfigRule{
+	name:        "Removing an environment variable fallback",
+	definition:  "Provider attributes fall back to environment variables when they aren't set in the provider block (e.g. `GOOGLE_PROJECT` and `GOOGLE_CLOUD_PROJECT` for `project`), as listed in the provider's `ProviderEnvVars`. Pipelines often configure the provider solely through these variables so every variable previously read must continue to be read.",
+	message:     "Provider attribute {{field}} no longer reads the environment variables {{envVars}}",
+	identifier:  "provider-config-removing-env-var",
+	isRuleBreak: providerConfigRule_RemovingEnvVarFallback_func,
+}
+
+func providerConfigRule_RemovingEnvVarFallback_func(old, new ProviderConfig, mc MessageContext) []Breakage {
+	breakages := []Breakage{}
+	// the fallbacks can't be compared unless
+	// both providers list them
+	if old.EnvVars == nil || new.EnvVars == nil {
+		return breakages
+	}
+	for attributeName, oldEnvVars := range old.EnvVars {
+		// removed attributes are reported by
+		// providerConfigRule_RemovingAnAttribute
+		if _, ok := new.Schema[attributeName]; !ok {
+			continue
+		}
+		newEnvVars := new.EnvVars[attributeName]
+		lost := stringSliceDifference(oldEnvVars, newEnvVars)
+		if len(lost) == 0 {
+			continue
+		}
+		message := strings.ReplaceAll(mc.message, "{{envVars}}", formatStringSlice(lost))
+		mc.Field = attributeName
+		mc.oldValue, mc.newValue = strings.Join(oldEnvVars, ","), strings.Join(newEnvVars, ",")
+		breakages = append(breakages, *populateMessageContext(message, mc))
+	}
+	return breakages
+}
+
 func providerConfigRulesToRuleArray(pcrs []ProviderConfigRule) []Rule {
 	var rules []Rule
 	for _, prc := range pcrs {
@@ -52,24 +189,21 @@ func (prc ProviderConfigRule) Identifier() string {
 	return prc.identifier
 }
 
-// Message - a message to to inform the user
-// of a breakage.
-func (prc ProviderConfigRule) Message(version, resource string) string {
-	msg := prc.message
-	resource = fmt.Sprintf("`%s`", resource)
-	msg = strings.ReplaceAll(msg, "{{resource}}", resource)
-	return msg + documentationReference(version, prc.identifier)
-}
-
-// IsRuleBreak - compares resource entries and returns
-// a list of resources violating the rule
-func (prc ProviderConfigRule) IsRuleBreak(old, new map[string]*schema.Resource) []string {
+// IsRuleBreak - compares the provider
+// configurations and returns a breakage for
+// every attribute violating the rule
+func (prc ProviderConfigRule) IsRuleBreak(old, new ProviderConfig, mc MessageContext) []Breakage {
 	if prc.isRuleBreak == nil {
-		return []string{}
+		return []Breakage{}
 	}
-	return prc.isRuleBreak(old, new)
+	mc.Resource = ProviderResource
+	mc.identifier = prc.identifier
+	mc.message = prc.message
+	return prc.isRuleBreak(old, new, mc)
 }
 
+// Undetectable - informs if there are functions in place
+// to detect this rule.
 func (prc ProviderConfigRule) Undetectable() bool {
 	return prc.isRuleBreak == nil
 }
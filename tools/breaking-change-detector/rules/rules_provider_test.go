@@ -0,0 +1,269 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+type providerConfigTestCase struct {
+	name               string
+	oldProviderSchema  map[string]*schema.Schema
+	newProviderSchema  map[string]*schema.Schema
+	oldEnvVars         map[string][]string
+	newEnvVars         map[string][]string
+	expectedViolations int
+}
+
+func TestProviderConfigRule_RemovingAnAttribute(t *testing.T) {
+	for _, tc := range providerConfigRule_RemovingAnAttribute_TestCases {
+		tc.check(providerConfigRule_RemovingAnAttribute, t)
+	}
+}
+
+var providerConfigRule_RemovingAnAttribute_TestCases = []providerConfigTestCase{
+	{
+		name: "control",
+		oldProviderSchema: map[string]*schema.Schema{
+			"project":                 {Type: schema.TypeString, Optional: true},
+			"compute_custom_endpoint": {Type: schema.TypeString, Optional: true},
+		},
+		newProviderSchema: map[string]*schema.Schema{
+			"project":                 {Type: schema.TypeString, Optional: true},
+			"compute_custom_endpoint": {Type: schema.TypeString, Optional: true},
+		},
+		expectedViolations: 0,
+	},
+	{
+		name: "adding an attribute",
+		oldProviderSchema: map[string]*schema.Schema{
+			"project": {Type: schema.TypeString, Optional: true},
+		},
+		newProviderSchema: map[string]*schema.Schema{
+			"project":                 {Type: schema.TypeString, Optional: true},
+			"compute_custom_endpoint": {Type: schema.TypeString, Optional: true},
+		},
+		expectedViolations: 0,
+	},
+	{
+		name: "removing a custom endpoint",
+		oldProviderSchema: map[string]*schema.Schema{
+			"project":                 {Type: schema.TypeString, Optional: true},
+			"compute_custom_endpoint": {Type: schema.TypeString, Optional: true},
+		},
+		newProviderSchema: map[string]*schema.Schema{
+			"project": {Type: schema.TypeString, Optional: true},
+		},
+		expectedViolations: 1,
+	},
+	{
+		name: "removing a nested batching attribute",
+		oldProviderSchema: map[string]*schema.Schema{
+			"batching":                 {Type: schema.TypeList, Optional: true, MaxItems: 1},
+			"batching.send_after":      {Type: schema.TypeString, Optional: true},
+			"batching.enable_batching": {Type: schema.TypeBool, Optional: true},
+		},
+		newProviderSchema: map[string]*schema.Schema{
+			"batching":            {Type: schema.TypeList, Optional: true, MaxItems: 1},
+			"batching.send_after": {Type: schema.TypeString, Optional: true},
+		},
+		expectedViolations: 1,
+	},
+}
+
+func TestProviderConfigRule_ChangingAttributeType(t *testing.T) {
+	for _, tc := range providerConfigRule_ChangingAttributeType_TestCases {
+		tc.check(providerConfigRule_ChangingAttributeType, t)
+	}
+}
+
+var providerConfigRule_ChangingAttributeType_TestCases = []providerConfigTestCase{
+	{
+		name: "control",
+		oldProviderSchema: map[string]*schema.Schema{
+			"scopes": {Type: schema.TypeList, Optional: true, Elem: &schema.Schema{Type: schema.TypeString}},
+		},
+		newProviderSchema: map[string]*schema.Schema{
+			"scopes": {Type: schema.TypeList, Optional: true, Elem: &schema.Schema{Type: schema.TypeString}},
+		},
+		expectedViolations: 0,
+	},
+	{
+		name: "changing the attribute type",
+		oldProviderSchema: map[string]*schema.Schema{
+			"user_project_override": {Type: schema.TypeBool, Optional: true},
+		},
+		newProviderSchema: map[string]*schema.Schema{
+			"user_project_override": {Type: schema.TypeString, Optional: true},
+		},
+		expectedViolations: 1,
+	},
+	{
+		name: "changing the element type",
+		oldProviderSchema: map[string]*schema.Schema{
+			"scopes": {Type: schema.TypeList, Optional: true, Elem: &schema.Schema{Type: schema.TypeString}},
+		},
+		newProviderSchema: map[string]*schema.Schema{
+			"scopes": {Type: schema.TypeList, Optional: true, Elem: &schema.Schema{Type: schema.TypeInt}},
+		},
+		expectedViolations: 1,
+	},
+	{
+		name: "removing the attribute",
+		oldProviderSchema: map[string]*schema.Schema{
+			"user_project_override": {Type: schema.TypeBool, Optional: true},
+		},
+		newProviderSchema:  map[string]*schema.Schema{},
+		expectedViolations: 0,
+	},
+}
+
+func TestProviderConfigRule_AddingRequiredAttribute(t *testing.T) {
+	for _, tc := range providerConfigRule_AddingRequiredAttribute_TestCases {
+		tc.check(providerConfigRule_AddingRequiredAttribute, t)
+	}
+}
+
+var providerConfigRule_AddingRequiredAttribute_TestCases = []providerConfigTestCase{
+	{
+		name: "control",
+		oldProviderSchema: map[string]*schema.Schema{
+			"project": {Type: schema.TypeString, Optional: true},
+		},
+		newProviderSchema: map[string]*schema.Schema{
+			"project": {Type: schema.TypeString, Optional: true},
+		},
+		expectedViolations: 0,
+	},
+	{
+		name: "adding an optional attribute",
+		oldProviderSchema: map[string]*schema.Schema{
+			"project": {Type: schema.TypeString, Optional: true},
+		},
+		newProviderSchema: map[string]*schema.Schema{
+			"project":        {Type: schema.TypeString, Optional: true},
+			"request_reason": {Type: schema.TypeString, Optional: true},
+		},
+		expectedViolations: 0,
+	},
+	{
+		name: "adding a required attribute",
+		oldProviderSchema: map[string]*schema.Schema{
+			"project": {Type: schema.TypeString, Optional: true},
+		},
+		newProviderSchema: map[string]*schema.Schema{
+			"project":        {Type: schema.TypeString, Optional: true},
+			"request_reason": {Type: schema.TypeString, Required: true},
+		},
+		expectedViolations: 1,
+	},
+	{
+		name: "attribute becoming required",
+		oldProviderSchema: map[string]*schema.Schema{
+			"project": {Type: schema.TypeString, Optional: true},
+		},
+		newProviderSchema: map[string]*schema.Schema{
+			"project": {Type: schema.TypeString, Required: true},
+		},
+		expectedViolations: 1,
+	},
+	{
+		name: "required attribute in an existing block",
+		oldProviderSchema: map[string]*schema.Schema{
+			"batching": {Type: schema.TypeList, Optional: true, MaxItems: 1},
+		},
+		newProviderSchema: map[string]*schema.Schema{
+			"batching":            {Type: schema.TypeList, Optional: true, MaxItems: 1},
+			"batching.send_after": {Type: schema.TypeString, Required: true},
+		},
+		expectedViolations: 1,
+	},
+	{
+		name:              "required attribute in a new block",
+		oldProviderSchema: map[string]*schema.Schema{},
+		newProviderSchema: map[string]*schema.Schema{
+			"batching":            {Type: schema.TypeList, Optional: true, MaxItems: 1},
+			"batching.send_after": {Type: schema.TypeString, Required: true},
+		},
+		expectedViolations: 0,
+	},
+}
+
+func TestProviderConfigRule_RemovingEnvVarFallback(t *testing.T) {
+	for _, tc := range providerConfigRule_RemovingEnvVarFallback_TestCases {
+		tc.check(providerConfigRule_RemovingEnvVarFallback, t)
+	}
+}
+
+var envVarTestSchema = map[string]*schema.Schema{
+	"project":                 {Type: schema.TypeString, Optional: true},
+	"compute_custom_endpoint": {Type: schema.TypeString, Optional: true},
+}
+
+var providerConfigRule_RemovingEnvVarFallback_TestCases = []providerConfigTestCase{
+	{
+		name:               "control",
+		oldProviderSchema:  envVarTestSchema,
+		newProviderSchema:  envVarTestSchema,
+		oldEnvVars:         map[string][]string{"project": {"GOOGLE_PROJECT", "GCLOUD_PROJECT"}},
+		newEnvVars:         map[string][]string{"project": {"GOOGLE_PROJECT", "GCLOUD_PROJECT"}},
+		expectedViolations: 0,
+	},
+	{
+		name:               "adding an env var",
+		oldProviderSchema:  envVarTestSchema,
+		newProviderSchema:  envVarTestSchema,
+		oldEnvVars:         map[string][]string{"project": {"GOOGLE_PROJECT"}},
+		newEnvVars:         map[string][]string{"project": {"GOOGLE_PROJECT", "CLOUDSDK_CORE_PROJECT"}},
+		expectedViolations: 0,
+	},
+	{
+		name:               "control - env vars unknown",
+		oldProviderSchema:  envVarTestSchema,
+		newProviderSchema:  envVarTestSchema,
+		oldEnvVars:         map[string][]string{"project": {"GOOGLE_PROJECT"}},
+		expectedViolations: 0,
+	},
+	{
+		name:               "control - removing the attribute",
+		oldProviderSchema:  envVarTestSchema,
+		newProviderSchema:  map[string]*schema.Schema{"compute_custom_endpoint": {Type: schema.TypeString, Optional: true}},
+		oldEnvVars:         map[string][]string{"project": {"GOOGLE_PROJECT"}},
+		newEnvVars:         map[string][]string{},
+		expectedViolations: 0,
+	},
+	{
+		name:               "removing an env var",
+		oldProviderSchema:  envVarTestSchema,
+		newProviderSchema:  envVarTestSchema,
+		oldEnvVars:         map[string][]string{"project": {"GOOGLE_PROJECT", "GOOGLE_CLOUD_PROJECT"}},
+		newEnvVars:         map[string][]string{"project": {"GOOGLE_PROJECT"}},
+		expectedViolations: 1,
+	},
+	{
+		name:               "removing every env var of an attribute",
+		oldProviderSchema:  envVarTestSchema,
+		newProviderSchema:  envVarTestSchema,
+		oldEnvVars:         map[string][]string{"compute_custom_endpoint": {"GOOGLE_COMPUTE_CUSTOM_ENDPOINT"}},
+		newEnvVars:         map[string][]string{},
+		expectedViolations: 1,
+	},
+}
+
+func (tc *providerConfigTestCase) check(rule ProviderConfigRule, t *testing.T) {
+	old := ProviderConfig{Schema: tc.oldProviderSchema, EnvVars: tc.oldEnvVars}
+	new := ProviderConfig{Schema: tc.newProviderSchema, EnvVars: tc.newEnvVars}
+	breakages := rule.IsRuleBreak(old, new, MessageContext{})
+	for _, breakage := range breakages {
+		if strings.Contains(breakage.Message, "{{") {
+			t.Errorf("Test `%s` failed: replacements for `{{<val>}}` not successful ", tc.name)
+		}
+		if breakage.Resource != ProviderResource {
+			t.Errorf("Test `%s` failed: expected breakage on `%s`, got `%s`", tc.name, ProviderResource, breakage.Resource)
+		}
+	}
+	if tc.expectedViolations != len(breakages) {
+		t.Errorf("Test `%s` failed: expected %d violations, got %d", tc.name, tc.expectedViolations, len(breakages))
+	}
+}
@@ -1,9 +1,6 @@
 package rules
 
 import (
-	"fmt"
-	"strings"
-
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
@@ -64,13 +61,11 @@ func (rm ResourceInventoryRule) Identifier() string {
 	return rm.identifier
 }
 
-// Message - a message to to inform the user
-// of a breakage.
-func (rm ResourceInventoryRule) Message(version, resource string) string {
-	msg := rm.message
-	resource = fmt.Sprintf("`%s`", resource)
-	msg = strings.ReplaceAll(msg, "{{resource}}", resource)
-	return msg + documentationReference(version, rm.identifier)
+// Breakage - the breakage to inform the
+// user of for the given resource.
+func (rm ResourceInventoryRule) Breakage(mc MessageContext) Breakage {
+	mc.identifier = rm.identifier
+	return *populateMessageContext(rm.message, mc)
 }
 
 // IsRuleBreak - compares resource entries and returns
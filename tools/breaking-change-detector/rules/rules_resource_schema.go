@@ -1,20 +1,25 @@
 package rules
 
 import (
-	"fmt"
-	"strings"
-
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 // ResourceSchemaRule provides structure for
 // rules regarding resource attribute changes
 type ResourceSchemaRule struct {
-	name        string
-	definition  string
-	message     string
-	identifier  string
-	isRuleBreak func(old, new map[string]*schema.Schema) []string
+	name                string
+	definition          string
+	message             string
+	identifier          string
+	isRuleBreak         func(old, new map[string]*schema.Schema) []string
+	isIdentityRuleBreak func(old, new ResourceIdentity) []string
+}
+
+// ResourceIdentity holds the id template and the
+// import id formats that a resource accepts
+type ResourceIdentity struct {
+	IdFormat      string   `json:"id_format"`
+	ImportFormats []string `json:"import_formats"`
 }
 
 // ResourceSchemaRules is a list of ResourceInventoryRule
@@ -22,15 +27,47 @@ type ResourceSchemaRule struct {
 var ResourceSchemaRules = []ResourceSchemaRule{resourceSchemaRule_RemovingAField, resourceSchemaRule_ChangingResourceIDFormat, resourceSchemaRule_ChangingImportIDFormat}
 
 var resourceSchemaRule_ChangingResourceIDFormat = ResourceSchemaRule{
-	name:       "Changing resource ID format",
-	definition: "Terraform uses resource ID to read resource state from the api. Modification of the ID format will break the ability to parse the IDs from any deployments.",
-	identifier: "resource-id",
+	name:                "Changing resource ID format",
+	definition:          "Terraform uses resource ID to read resource state from the api. Modification of the ID format will break the ability to parse the IDs from any deployments. Only detected for resources generated by mmv1, as handwritten and DCL resources don't export their ID format.",
+	message:             "ID format {{field}} was changed on resource {{resource}}",
+	identifier:          "resource-id",
+	isIdentityRuleBreak: resourceSchemaRule_ChangingResourceIDFormat_func,
+}
+
+func resourceSchemaRule_ChangingResourceIDFormat_func(old, new ResourceIdentity) []string {
+	// resources without an exported id template can't be compared
+	if old.IdFormat == "" || new.IdFormat == "" {
+		return []string{}
+	}
+	if old.IdFormat != new.IdFormat {
+		return []string{old.IdFormat}
+	}
+	return []string{}
 }
 
 var resourceSchemaRule_ChangingImportIDFormat = ResourceSchemaRule{
-	name:       "Changing resource ID import format",
-	definition: "Automation external to our provider may rely on importing resources with a certain format. Removal or modification of existing formats will break this automation.",
-	identifier: "resource-import-format",
+	name:                "Changing resource ID import format",
+	definition:          "Automation external to our provider may rely on importing resources with a certain format. Removal or modification of existing formats will break this automation. Only detected for resources generated by mmv1, as handwritten and DCL resources don't export their import formats.",
+	message:             "Import format {{field}} was removed from resource {{resource}}",
+	identifier:          "resource-import-format",
+	isIdentityRuleBreak: resourceSchemaRule_ChangingImportIDFormat_func,
+}
+
+func resourceSchemaRule_ChangingImportIDFormat_func(old, new ResourceIdentity) []string {
+	formatsNotPresent := []string{}
+	for _, oldFormat := range old.ImportFormats {
+		found := false
+		for _, newFormat := range new.ImportFormats {
+			if oldFormat == newFormat {
+				found = true
+				break
+			}
+		}
+		if !found {
+			formatsNotPresent = append(formatsNotPresent, oldFormat)
+		}
+	}
+	return formatsNotPresent
 }
 
 var resourceSchemaRule_RemovingAField = ResourceSchemaRule{
@@ -75,15 +112,11 @@ func (rs ResourceSchemaRule) Identifier() string {
 	return rs.identifier
 }
 
-// Message - a message to to inform the user
-// of a breakage.
-func (rs ResourceSchemaRule) Message(version, resource, field string) string {
-	msg := rs.message
-	resource = fmt.Sprintf("`%s`", resource)
-	field = fmt.Sprintf("`%s`", field)
-	msg = strings.ReplaceAll(msg, "{{resource}}", resource)
-	msg = strings.ReplaceAll(msg, "{{field}}", field)
-	return msg + documentationReference(version, rs.identifier)
+// Breakage - the breakage to inform the user
+// of for the given resource and field.
+func (rs ResourceSchemaRule) Breakage(mc MessageContext) Breakage {
+	mc.identifier = rs.identifier
+	return *populateMessageContext(rs.message, mc)
 }
 
 // IsRuleBreak - compares the field entries and returns
@@ -95,8 +128,17 @@ func (rs ResourceSchemaRule) IsRuleBreak(old, new map[string]*schema.Schema) []s
 	return rs.isRuleBreak(old, new)
 }
 
+// IsIdentityRuleBreak - compares the resource identities and
+// returns a list of id or import formats violating the rule
+func (rs ResourceSchemaRule) IsIdentityRuleBreak(old, new ResourceIdentity) []string {
+	if rs.isIdentityRuleBreak == nil {
+		return []string{}
+	}
+	return rs.isIdentityRuleBreak(old, new)
+}
+
 // Undetectable - informs if there are functions in place
 // to detect this rule.
 func (rs ResourceSchemaRule) Undetectable() bool {
-	return rs.isRuleBreak == nil
+	return rs.isRuleBreak == nil && rs.isIdentityRuleBreak == nil
 }
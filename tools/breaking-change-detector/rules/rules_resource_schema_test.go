@@ -87,3 +87,93 @@ func (tc *resourceSchemaTestCase) check(rule ResourceSchemaRule, t *testing.T) {
 		t.Errorf("Test `%s` failed: expected %d violations, got %d", tc.name, tc.expectedViolations, len(violations))
 	}
 }
+
+func TestResourceSchemaRule_ChangingResourceIDFormat(t *testing.T) {
+	for _, tc := range resourceSchemaRule_ChangingResourceIDFormat_TestCases {
+		tc.check(resourceSchemaRule_ChangingResourceIDFormat, t)
+	}
+}
+
+type resourceIdentityTestCase struct {
+	name               string
+	oldIdentity        ResourceIdentity
+	newIdentity        ResourceIdentity
+	expectedViolations int
+}
+
+var resourceSchemaRule_ChangingResourceIDFormat_TestCases = []resourceIdentityTestCase{
+	{
+		name:               "control",
+		oldIdentity:        ResourceIdentity{IdFormat: "projects/{{project}}/topics/{{name}}"},
+		newIdentity:        ResourceIdentity{IdFormat: "projects/{{project}}/topics/{{name}}"},
+		expectedViolations: 0,
+	},
+	{
+		name:               "control - id format not exported",
+		oldIdentity:        ResourceIdentity{},
+		newIdentity:        ResourceIdentity{IdFormat: "projects/{{project}}/topics/{{name}}"},
+		expectedViolations: 0,
+	},
+	{
+		name:               "id format changed",
+		oldIdentity:        ResourceIdentity{IdFormat: "projects/{{project}}/topics/{{name}}"},
+		newIdentity:        ResourceIdentity{IdFormat: "{{project}}/{{name}}"},
+		expectedViolations: 1,
+	},
+}
+
+func TestResourceSchemaRule_ChangingImportIDFormat(t *testing.T) {
+	for _, tc := range resourceSchemaRule_ChangingImportIDFormat_TestCases {
+		tc.check(resourceSchemaRule_ChangingImportIDFormat, t)
+	}
+}
+
+var resourceSchemaRule_ChangingImportIDFormat_TestCases = []resourceIdentityTestCase{
+	{
+		name: "control",
+		oldIdentity: ResourceIdentity{
+			ImportFormats: []string{"projects/{{project}}/topics/{{name}}", "{{name}}"},
+		},
+		newIdentity: ResourceIdentity{
+			ImportFormats: []string{"projects/{{project}}/topics/{{name}}", "{{name}}"},
+		},
+		expectedViolations: 0,
+	},
+	{
+		name: "adding an import format",
+		oldIdentity: ResourceIdentity{
+			ImportFormats: []string{"{{name}}"},
+		},
+		newIdentity: ResourceIdentity{
+			ImportFormats: []string{"projects/{{project}}/topics/{{name}}", "{{name}}"},
+		},
+		expectedViolations: 0,
+	},
+	{
+		name: "removing an import format",
+		oldIdentity: ResourceIdentity{
+			ImportFormats: []string{"projects/{{project}}/topics/{{name}}", "{{name}}"},
+		},
+		newIdentity: ResourceIdentity{
+			ImportFormats: []string{"projects/{{project}}/topics/{{name}}"},
+		},
+		expectedViolations: 1,
+	},
+	{
+		name: "modifying import formats",
+		oldIdentity: ResourceIdentity{
+			ImportFormats: []string{"projects/{{project}}/topics/{{name}}", "{{name}}"},
+		},
+		newIdentity: ResourceIdentity{
+			ImportFormats: []string{"projects/{{project}}/locations/{{location}}/topics/{{name}}"},
+		},
+		expectedViolations: 2,
+	},
+}
+
+func (tc *resourceIdentityTestCase) check(rule ResourceSchemaRule, t *testing.T) {
+	violations := rule.isIdentityRuleBreak(tc.oldIdentity, tc.newIdentity)
+	if tc.expectedViolations != len(violations) {
+		t.Errorf("Test `%s` failed: expected %d violations, got %d", tc.name, tc.expectedViolations, len(violations))
+	}
+}
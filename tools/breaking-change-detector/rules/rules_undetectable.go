@@ -4,7 +4,4 @@ package rules
 undetectable
 	Changing fundamental provider behaviors (e.g. authentication or configuration precedence)
 
-	*? Changing resource import ID format
-	Changing resource ID format
-
 */
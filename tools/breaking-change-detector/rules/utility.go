@@ -2,8 +2,12 @@ package rules
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/GoogleCloudPlatform/magic-modules/.ci/breaking-change-detector/constants"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
@@ -30,3 +34,168 @@ func getValueType(valueType schema.ValueType) string {
 	}
 	return "TypeUndefined"
 }
+
+// stringSliceDifference returns the entries of a
+// that are not present in b
+func stringSliceDifference(a, b []string) []string {
+	difference := []string{}
+	for _, x := range a {
+		found := false
+		for _, y := range b {
+			if x == y {
+				found = true
+				break
+			}
+		}
+		if !found {
+			difference = append(difference, x)
+		}
+	}
+	return difference
+}
+
+func formatStringSlice(values []string) string {
+	quoted := []string{}
+	for _, value := range values {
+		quoted = append(quoted, fmt.Sprintf("`%s`", value))
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+var possibleValuesRegexp = regexp.MustCompile(`Possible values: \[([^\]]*)\]`)
+var quotedValueRegexp = regexp.MustCompile(`"([^"]*)"`)
+
+// validValuesRegexp matches the error of validation.StringInSlice,
+// which lists every value it accepts
+var validValuesRegexp = regexp.MustCompile(`to be one of \[(.*)\], got`)
+
+// invalidProbeValue is a value no enum is expected to accept
+const invalidProbeValue = "breaking-change-detector-invalid-probe"
+
+// getEnumValues returns the enum values listed in a field
+// description. Generated enum fields document their
+// values as `Possible values: ["A", "B"]`.
+func getEnumValues(description string) []string {
+	values := []string{}
+	for _, match := range possibleValuesRegexp.FindAllStringSubmatch(description, -1) {
+		for _, quoted := range quotedValueRegexp.FindAllStringSubmatch(match[1], -1) {
+			values = append(values, quoted[1])
+		}
+	}
+	return values
+}
+
+// getDocumentedValues returns the enum values documented on a
+// field, or on its elements for lists and sets of enums
+func getDocumentedValues(s *schema.Schema) []string {
+	description := s.Description
+	if elem, ok := s.Elem.(*schema.Schema); ok {
+		description += elem.Description
+	}
+	return getEnumValues(description)
+}
+
+// getPossibleValues returns the enum values documented on a
+// field, those its validation lists and its default.
+func getPossibleValues(s *schema.Schema) []string {
+	values := getDocumentedValues(s)
+	if validate := getValidator(s); validate != nil {
+		values = append(values, stringSliceDifference(getValidatedValues(validate), values)...)
+	}
+	if defaultValue, ok := s.Default.(string); ok && defaultValue != "" {
+		values = append(values, stringSliceDifference([]string{defaultValue}, values)...)
+	}
+	return values
+}
+
+// getValidator returns a probe of the validation applied to
+// values of the field, or its elements for lists and sets,
+// either through ValidateFunc or ValidateDiagFunc. It returns
+// nil if values aren't validated.
+func getValidator(s *schema.Schema) func(value string) error {
+	validated := s
+	if s.ValidateFunc == nil && s.ValidateDiagFunc == nil {
+		elem, ok := s.Elem.(*schema.Schema)
+		if !ok {
+			return nil
+		}
+		validated = elem
+	}
+	// validations commonly assert the type of the value
+	if validated.Type != schema.TypeString && validated.Type != schema.TypeInvalid {
+		return nil
+	}
+	switch {
+	case validated.ValidateFunc != nil:
+		return func(value string) (err error) {
+			defer recoverProbe(&err)
+			if _, errs := validated.ValidateFunc(value, "probe"); len(errs) > 0 {
+				return errs[0]
+			}
+			return nil
+		}
+	case validated.ValidateDiagFunc != nil:
+		return func(value string) (err error) {
+			defer recoverProbe(&err)
+			for _, d := range validated.ValidateDiagFunc(value, cty.GetAttrPath("probe")) {
+				if d.Severity == diag.Error {
+					return fmt.Errorf("%s: %s", d.Summary, d.Detail)
+				}
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// recoverProbe reports a validation panicking
+// on a probed value as rejecting it
+func recoverProbe(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("validation panicked: %v", r)
+	}
+}
+
+// getValidatedValues returns the values accepted by a validation
+// listing them when rejecting a value, such as the closure returned
+// by validation.StringInSlice. Only values the validation accepts
+// are returned, so values it lists ambiguously are left out.
+func getValidatedValues(validate func(value string) error) []string {
+	values := []string{}
+	err := validate(invalidProbeValue)
+	if err == nil {
+		return values
+	}
+	match := validValuesRegexp.FindStringSubmatch(err.Error())
+	if match == nil {
+		return values
+	}
+	// values are listed with %v, or %q by more recent SDK versions
+	candidates := strings.Fields(match[1])
+	if quoted := quotedValueRegexp.FindAllStringSubmatch(match[1], -1); len(quoted) > 0 {
+		candidates = []string{}
+		for _, q := range quoted {
+			candidates = append(candidates, q[1])
+		}
+	}
+	for _, candidate := range candidates {
+		if validate(candidate) == nil {
+			values = append(values, candidate)
+		}
+	}
+	return values
+}
+
+// acceptsValue informs if a field accepts the value. Its validation
+// is probed, falling back to the values its description documents
+// when it has none. A field with neither accepts any value.
+func acceptsValue(s *schema.Schema, value string) bool {
+	if validate := getValidator(s); validate != nil {
+		return validate(value) == nil
+	}
+	documented := getDocumentedValues(s)
+	if len(documented) == 0 {
+		return true
+	}
+	return len(stringSliceDifference([]string{value}, documented)) == 0
+}
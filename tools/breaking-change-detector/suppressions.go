@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/GoogleCloudPlatform/magic-modules/.ci/breaking-change-detector/rules"
+	"github.com/golang/glog"
+	"github.com/hashicorp/go-version"
+)
+
+// Suppression - an acknowledged breakage, such as one
+// introduced on purpose for a major version release
+type Suppression struct {
+	Identifier    string `json:"identifier"`
+	Resource      string `json:"resource"`
+	Datasource    bool   `json:"datasource,omitempty"`
+	Field         string `json:"field,omitempty"`
+	Justification string `json:"justification"`
+	// ExpiresIn is the provider version from which
+	// the suppression no longer applies
+	ExpiresIn string `json:"expires_in,omitempty"`
+}
+
+// SuppressedBreakage - a breakage matched by a suppression
+type SuppressedBreakage struct {
+	rules.Breakage
+	Justification string `json:"justification"`
+}
+
+// StaleSuppression - a suppression that no longer
+// applies and should be removed from the file
+type StaleSuppression struct {
+	Suppression
+	Reason string `json:"reason"`
+}
+
+// report - the outcome of a comparison once
+// suppressions have been applied
+type report struct {
+	Breakages         []rules.Breakage     `json:"breakages"`
+	Suppressed        []SuppressedBreakage `json:"suppressed"`
+	StaleSuppressions []StaleSuppression   `json:"stale_suppressions"`
+}
+
+// loadSuppressions reads the suppression file. A missing
+// file is treated as having no suppressions.
+func loadSuppressions(path string) []Suppression {
+	suppressions := []Suppression{}
+	if path == "" {
+		return suppressions
+	}
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		glog.Infof("suppression file %s not found, no breakages will be suppressed", path)
+		return suppressions
+	}
+	if err != nil {
+		glog.Exit(err)
+	}
+
+	if err := json.Unmarshal(contents, &suppressions); err != nil {
+		glog.Exitf("error parsing suppressions from %s: %v", path, err)
+	}
+	for i, s := range suppressions {
+		if err := s.validate(); err != nil {
+			glog.Exitf("invalid suppression #%d in %s: %v", i, path, err)
+		}
+	}
+	return suppressions
+}
+
+func (s Suppression) validate() error {
+	if s.Identifier == "" || s.Resource == "" {
+		return fmt.Errorf("identifier and resource are required")
+	}
+	if s.Justification == "" {
+		return fmt.Errorf("a justification is required for suppressing %s on %s", s.Identifier, s.Resource)
+	}
+	if s.ExpiresIn != "" {
+		if _, err := version.NewVersion(s.ExpiresIn); err != nil {
+			return fmt.Errorf("expires_in %q is not a valid provider version: %v", s.ExpiresIn, err)
+		}
+	}
+	return nil
+}
+
+func (s Suppression) matches(b rules.Breakage) bool {
+	if s.Identifier != b.Identifier || s.Resource != b.Resource || s.Datasource != b.Datasource {
+		return false
+	}
+	// suppressions without a field path cover
+	// every field of the resource
+	return s.Field == "" || s.Field == b.Field
+}
+
+// isExpired informs if the suppression no longer applies
+// to the given provider release. Without a release
+// version suppressions never expire.
+func (s Suppression) isExpired(releaseVersion string) bool {
+	if s.ExpiresIn == "" || releaseVersion == "" {
+		return false
+	}
+	release, err := version.NewVersion(releaseVersion)
+	if err != nil {
+		glog.Exitf("release version %q is not a valid provider version: %v", releaseVersion, err)
+	}
+	expiry, _ := version.NewVersion(s.ExpiresIn)
+	return release.GreaterThanOrEqual(expiry)
+}
+
+func applySuppressions(breakages []rules.Breakage, suppressions []Suppression, releaseVersion string) report {
+	r := report{
+		Breakages:         []rules.Breakage{},
+		Suppressed:        []SuppressedBreakage{},
+		StaleSuppressions: []StaleSuppression{},
+	}
+
+	active := []Suppression{}
+	for _, s := range suppressions {
+		if s.isExpired(releaseVersion) {
+			r.StaleSuppressions = append(r.StaleSuppressions, StaleSuppression{
+				Suppression: s,
+				Reason:      fmt.Sprintf("expired in provider version %s", s.ExpiresIn),
+			})
+			continue
+		}
+		active = append(active, s)
+	}
+
+	used := make([]bool, len(active))
+	for _, b := range breakages {
+		suppressed := false
+		for i, s := range active {
+			if s.matches(b) {
+				used[i] = true
+				if !suppressed {
+					r.Suppressed = append(r.Suppressed, SuppressedBreakage{
+						Breakage:      b,
+						Justification: s.Justification,
+					})
+					suppressed = true
+				}
+			}
+		}
+		if !suppressed {
+			r.Breakages = append(r.Breakages, b)
+		}
+	}
+
+	for i, s := range active {
+		if !used[i] {
+			r.StaleSuppressions = append(r.StaleSuppressions, StaleSuppression{
+				Suppression: s,
+				Reason:      "no longer matches any breakage",
+			})
+		}
+	}
+
+	return r
+}
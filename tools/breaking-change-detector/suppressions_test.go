@@ -0,0 +1,120 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/magic-modules/.ci/breaking-change-detector/rules"
+)
+
+type suppressionTestCase struct {
+	name               string
+	breakages          []rules.Breakage
+	suppressions       []Suppression
+	releaseVersion     string
+	expectedBreakages  int
+	expectedSuppressed int
+	expectedStale      int
+}
+
+var suppressionTestBreakages = []rules.Breakage{
+	{Identifier: "resource-schema-field-removal-or-rename", Resource: "google-x", Field: "field-a"},
+	{Identifier: "resource-schema-field-removal-or-rename", Resource: "google-x", Field: "field-b"},
+	{Identifier: "resource-map-resource-removal-or-rename", Resource: "google-y"},
+}
+
+var suppressionTestCases = []suppressionTestCase{
+	{
+		name:              "control - no suppressions",
+		breakages:         suppressionTestBreakages,
+		suppressions:      []Suppression{},
+		expectedBreakages: 3,
+	},
+	{
+		name:      "suppressing a field",
+		breakages: suppressionTestBreakages,
+		suppressions: []Suppression{
+			{Identifier: "resource-schema-field-removal-or-rename", Resource: "google-x", Field: "field-a", Justification: "beep"},
+		},
+		expectedBreakages:  2,
+		expectedSuppressed: 1,
+	},
+	{
+		name:      "suppressing every field of a resource",
+		breakages: suppressionTestBreakages,
+		suppressions: []Suppression{
+			{Identifier: "resource-schema-field-removal-or-rename", Resource: "google-x", Justification: "beep"},
+		},
+		expectedBreakages:  1,
+		expectedSuppressed: 2,
+	},
+	{
+		name:      "suppression for a different rule",
+		breakages: suppressionTestBreakages,
+		suppressions: []Suppression{
+			{Identifier: "resource-map-resource-removal-or-rename", Resource: "google-x", Justification: "beep"},
+		},
+		expectedBreakages: 3,
+		expectedStale:     1,
+	},
+	{
+		name:      "suppression not yet expired",
+		breakages: suppressionTestBreakages,
+		suppressions: []Suppression{
+			{Identifier: "resource-map-resource-removal-or-rename", Resource: "google-y", Justification: "beep", ExpiresIn: "5.0.0"},
+		},
+		releaseVersion:     "4.80.0",
+		expectedBreakages:  2,
+		expectedSuppressed: 1,
+	},
+	{
+		name:      "suppression expired",
+		breakages: suppressionTestBreakages,
+		suppressions: []Suppression{
+			{Identifier: "resource-map-resource-removal-or-rename", Resource: "google-y", Justification: "beep", ExpiresIn: "5.0.0"},
+		},
+		releaseVersion:    "5.0.0",
+		expectedBreakages: 3,
+		expectedStale:     1,
+	},
+	{
+		name:      "expiry ignored without release version",
+		breakages: suppressionTestBreakages,
+		suppressions: []Suppression{
+			{Identifier: "resource-map-resource-removal-or-rename", Resource: "google-y", Justification: "beep", ExpiresIn: "5.0.0"},
+		},
+		expectedBreakages:  2,
+		expectedSuppressed: 1,
+	},
+}
+
+func TestApplySuppressions(t *testing.T) {
+	for _, tc := range suppressionTestCases {
+		tc.check(t)
+	}
+}
+
+func (tc *suppressionTestCase) check(t *testing.T) {
+	r := applySuppressions(tc.breakages, tc.suppressions, tc.releaseVersion)
+	if tc.expectedBreakages != len(r.Breakages) {
+		t.Errorf("Test `%s` failed: expected %d breakages, got %d", tc.name, tc.expectedBreakages, len(r.Breakages))
+	}
+	if tc.expectedSuppressed != len(r.Suppressed) {
+		t.Errorf("Test `%s` failed: expected %d suppressed, got %d", tc.name, tc.expectedSuppressed, len(r.Suppressed))
+	}
+	if tc.expectedStale != len(r.StaleSuppressions) {
+		t.Errorf("Test `%s` failed: expected %d stale suppressions, got %d", tc.name, tc.expectedStale, len(r.StaleSuppressions))
+	}
+}
+
+func TestSuppressionValidation(t *testing.T) {
+	invalid := []Suppression{
+		{Resource: "google-x", Justification: "beep"},
+		{Identifier: "resource-map-resource-removal-or-rename", Resource: "google-x"},
+		{Identifier: "resource-map-resource-removal-or-rename", Resource: "google-x", Justification: "beep", ExpiresIn: "next"},
+	}
+	for _, s := range invalid {
+		if err := s.validate(); err == nil {
+			t.Errorf("Test `%s` failed: expected %+v to be invalid", "suppression validation", s)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+module google/provider/new
+
+go 1.19
+
+require (
+	github.com/hashicorp/terraform-plugin-framework v1.1.1
+	github.com/hashicorp/terraform-plugin-sdk/v2 v2.24.0
+)
@@ -0,0 +1,52 @@
+// Package google is a stub of the symbols the detector reads from a
+// generated provider, used to vet the tagged builds against providers
+// exporting a framework provider.
+package google
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func Provider() *schema.Provider {
+	return &schema.Provider{}
+}
+
+func ResourceMap() map[string]*schema.Resource {
+	return map[string]*schema.Resource{}
+}
+
+func ProviderEnvVars() map[string][]string {
+	return map[string][]string{}
+}
+
+func New(version string) provider.ProviderWithMetaSchema {
+	return &frameworkProvider{}
+}
+
+type frameworkProvider struct{}
+
+func (p *frameworkProvider) Metadata(_ context.Context, _ provider.MetadataRequest, resp *provider.MetadataResponse) {
+	resp.TypeName = "google"
+}
+
+func (p *frameworkProvider) MetaSchema(_ context.Context, _ provider.MetaSchemaRequest, _ *provider.MetaSchemaResponse) {
+}
+
+func (p *frameworkProvider) Schema(_ context.Context, _ provider.SchemaRequest, _ *provider.SchemaResponse) {
+}
+
+func (p *frameworkProvider) Configure(_ context.Context, _ provider.ConfigureRequest, _ *provider.ConfigureResponse) {
+}
+
+func (p *frameworkProvider) DataSources(_ context.Context) []func() datasource.DataSource {
+	return nil
+}
+
+func (p *frameworkProvider) Resources(_ context.Context) []func() resource.Resource {
+	return nil
+}
@@ -1,9 +1,15 @@
 package main
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
+
 	newProvider "google/provider/new/google-beta"
 	oldProvider "google/provider/old/google-beta"
 
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
@@ -15,8 +21,11 @@ type ResourceChanges map[string]any
 type Field struct {
 	// Added is true when the field is newly added between oldProvider and newProvider.
 	Added bool
-	// Changed is true when the field type has changed between oldProvider and newProvider.
+	// Changed is true when the field type, element type, requiredness or enum values
+	// have changed between oldProvider and newProvider.
 	Changed bool
+	// Reason describes why the field needs test coverage.
+	Reason string
 	// Tested is true when a test has been found that includes the field.
 	Tested bool
 }
@@ -70,20 +79,182 @@ func changedFieldsUnder(oldFieldSchema, newFieldSchema *schema.Schema) any {
 		// Output only fields should not be included in missing test detection.
 		return nil
 	}
-	if newFieldSchema.Elem != nil {
-		if newFieldSchemaElem, ok := newFieldSchema.Elem.(*schema.Resource); ok {
-			if oldFieldSchema != nil {
-				return changedFields(oldFieldSchema.Elem.(*schema.Resource), newFieldSchemaElem, true)
-			}
-			return changedFields(nil, newFieldSchemaElem, true)
+	if newFieldSchemaElem, ok := newFieldSchema.Elem.(*schema.Resource); ok {
+		if oldFieldSchema != nil {
+			// A field whose Elem was not a schema.Resource before has all nested fields added.
+			oldFieldSchemaElem, _ := oldFieldSchema.Elem.(*schema.Resource)
+			return changedFields(oldFieldSchemaElem, newFieldSchemaElem, true)
 		}
+		return changedFields(nil, newFieldSchemaElem, true)
 	}
 	if oldFieldSchema == nil {
-		return &Field{Added: true}
+		return &Field{Added: true, Reason: "added"}
 	}
+	var reasons []string
 	if oldFieldSchema.Type != newFieldSchema.Type {
-		return &Field{Changed: true}
+		reasons = append(reasons, fmt.Sprintf("type changed from %s to %s", oldFieldSchema.Type, newFieldSchema.Type))
+	} else if newFieldSchemaElem, ok := newFieldSchema.Elem.(*schema.Schema); ok {
+		if oldFieldSchemaElem, ok := oldFieldSchema.Elem.(*schema.Schema); !ok {
+			reasons = append(reasons, fmt.Sprintf("element type changed to %s", newFieldSchemaElem.Type))
+		} else if oldFieldSchemaElem.Type != newFieldSchemaElem.Type {
+			reasons = append(reasons, fmt.Sprintf("element type changed from %s to %s", oldFieldSchemaElem.Type, newFieldSchemaElem.Type))
+		}
+	}
+	if !oldFieldSchema.Required && newFieldSchema.Required {
+		reasons = append(reasons, "changed from optional to required")
+	}
+	if addedValues := addedEnumValues(oldFieldSchema, newFieldSchema); len(addedValues) > 0 {
+		reasons = append(reasons, fmt.Sprintf("enum values %s added", strings.Join(addedValues, ", ")))
+	}
+	if len(reasons) > 0 {
+		return &Field{Changed: true, Reason: strings.Join(reasons, "; ")}
+	}
+	return nil
+}
+
+// Return the enum values accepted by the new schema but not by the old one.
+// Values are read from the validation of the field or its elements when it
+// lists them, as validation.StringInSlice does, and from the description,
+// where generated enum fields list their values on the field itself or on
+// the Elem of a list or set of enums.
+func addedEnumValues(oldFieldSchema, newFieldSchema *schema.Schema) []string {
+	var added []string
+	for _, value := range enumValues(newFieldSchema) {
+		if acceptsEnumValue(newFieldSchema, value) && !acceptsEnumValue(oldFieldSchema, value) {
+			added = append(added, value)
+		}
+	}
+	return added
+}
+
+var possibleValuesRegexp = regexp.MustCompile(`Possible values: \[([^\]]*)\]`)
+var quotedValueRegexp = regexp.MustCompile(`"([^"]*)"`)
+
+// validValuesRegexp matches the error of validation.StringInSlice, which
+// lists every value it accepts.
+var validValuesRegexp = regexp.MustCompile(`to be one of \[(.*)\], got`)
+
+// invalidProbeValue is a value no enum is expected to accept.
+const invalidProbeValue = "missing-test-detector-invalid-probe"
+
+func enumValues(fieldSchema *schema.Schema) []string {
+	values := documentedEnumValues(fieldSchema)
+	if validate := enumValidator(fieldSchema); validate != nil {
+		for _, value := range validatedEnumValues(validate) {
+			if !containsString(values, value) {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+func documentedEnumValues(fieldSchema *schema.Schema) []string {
+	description := fieldSchema.Description
+	if elem, ok := fieldSchema.Elem.(*schema.Schema); ok {
+		description += elem.Description
+	}
+	var values []string
+	for _, match := range possibleValuesRegexp.FindAllStringSubmatch(description, -1) {
+		for _, quoted := range quotedValueRegexp.FindAllStringSubmatch(match[1], -1) {
+			if !containsString(values, quoted[1]) {
+				values = append(values, quoted[1])
+			}
+		}
+	}
+	return values
+}
+
+// Return a probe of the ValidateFunc or ValidateDiagFunc of the field, or of
+// its elements for lists and sets, or nil if values aren't validated.
+func enumValidator(fieldSchema *schema.Schema) func(value string) error {
+	validated := fieldSchema
+	if fieldSchema.ValidateFunc == nil && fieldSchema.ValidateDiagFunc == nil {
+		elem, ok := fieldSchema.Elem.(*schema.Schema)
+		if !ok {
+			return nil
+		}
+		validated = elem
+	}
+	// validations commonly assert the type of the value
+	if validated.Type != schema.TypeString && validated.Type != schema.TypeInvalid {
+		return nil
+	}
+	switch {
+	case validated.ValidateFunc != nil:
+		return func(value string) (err error) {
+			defer recoverProbe(&err)
+			if _, errs := validated.ValidateFunc(value, "probe"); len(errs) > 0 {
+				return errs[0]
+			}
+			return nil
+		}
+	case validated.ValidateDiagFunc != nil:
+		return func(value string) (err error) {
+			defer recoverProbe(&err)
+			for _, d := range validated.ValidateDiagFunc(value, cty.GetAttrPath("probe")) {
+				if d.Severity == diag.Error {
+					return fmt.Errorf("%s: %s", d.Summary, d.Detail)
+				}
+			}
+			return nil
+		}
 	}
-	// TODO(trodge) handle the case where something under Elem changed but Elem is not a schema.Resource
 	return nil
 }
+
+// Report a validation panicking on a probed value as rejecting it.
+func recoverProbe(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("validation panicked: %v", r)
+	}
+}
+
+// Return the values a validation accepts, read from the error it returns for
+// a value it rejects. Only values the validation accepts are returned, so
+// values listed ambiguously are left out.
+func validatedEnumValues(validate func(value string) error) []string {
+	var values []string
+	err := validate(invalidProbeValue)
+	if err == nil {
+		return values
+	}
+	match := validValuesRegexp.FindStringSubmatch(err.Error())
+	if match == nil {
+		return values
+	}
+	// Values are listed with %v, or %q by more recent SDK versions.
+	candidates := strings.Fields(match[1])
+	if quoted := quotedValueRegexp.FindAllStringSubmatch(match[1], -1); len(quoted) > 0 {
+		candidates = nil
+		for _, q := range quoted {
+			candidates = append(candidates, q[1])
+		}
+	}
+	for _, candidate := range candidates {
+		if validate(candidate) == nil {
+			values = append(values, candidate)
+		}
+	}
+	return values
+}
+
+// Report whether the field accepts the value, probing its validation or,
+// when it has none, looking the value up in the documented values. Fields
+// with neither accept any value.
+func acceptsEnumValue(fieldSchema *schema.Schema, value string) bool {
+	if validate := enumValidator(fieldSchema); validate != nil {
+		return validate(value) == nil
+	}
+	documented := documentedEnumValues(fieldSchema)
+	return len(documented) == 0 || containsString(documented, value)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -9,6 +9,7 @@ import (
 	oldProvider "google/provider/old/google-beta"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func TestNewProviderOldProviderChanges(t *testing.T) {
@@ -166,7 +167,7 @@ func TestResourceMapChanges(t *testing.T) {
 			expectedChangedFields: map[string]ResourceChanges{
 				"google_service_one_resource_two": {
 					"field_two": ResourceChanges{
-						"field_four": &Field{Added: true},
+						"field_four": &Field{Added: true, Reason: "added"},
 					},
 				},
 			},
@@ -254,12 +255,12 @@ func TestResourceMapChanges(t *testing.T) {
 			expectedChangedFields: map[string]ResourceChanges{
 				"google_service_one_resource_one": {
 					"field_two": ResourceChanges{
-						"field_four": &Field{Added: true},
+						"field_four": &Field{Added: true, Reason: "added"},
 					},
 				},
 				"google_service_one_resource_two": {
 					"field_two": ResourceChanges{
-						"field_four": &Field{Added: true},
+						"field_four": &Field{Added: true, Reason: "added"},
 					},
 				},
 			},
@@ -308,7 +309,248 @@ func TestResourceMapChanges(t *testing.T) {
 			},
 			expectedChangedFields: map[string]ResourceChanges{
 				"google_service_one_resource_one": {
-					"field_one": &Field{Added: true},
+					"field_one": &Field{Added: true, Reason: "added"},
+				},
+			},
+		},
+		{
+			name: "changed-elem-type",
+			oldResourceMap: map[string]*schema.Resource{
+				"google_service_one_resource_one": {
+					Schema: map[string]*schema.Schema{
+						"field_one": {
+							Type: schema.TypeList,
+							Elem: &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+			newResourceMap: map[string]*schema.Resource{
+				"google_service_one_resource_one": {
+					Schema: map[string]*schema.Schema{
+						"field_one": {
+							Type: schema.TypeList,
+							Elem: &schema.Schema{Type: schema.TypeInt},
+						},
+					},
+				},
+			},
+			expectedChangedFields: map[string]ResourceChanges{
+				"google_service_one_resource_one": {
+					"field_one": &Field{Changed: true, Reason: "element type changed from TypeString to TypeInt"},
+				},
+			},
+		},
+		{
+			name: "elem-becoming-resource",
+			oldResourceMap: map[string]*schema.Resource{
+				"google_service_one_resource_one": {
+					Schema: map[string]*schema.Schema{
+						"field_one": {
+							Type: schema.TypeList,
+							Elem: &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+			newResourceMap: map[string]*schema.Resource{
+				"google_service_one_resource_one": {
+					Schema: map[string]*schema.Schema{
+						"field_one": {
+							Type: schema.TypeList,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"field_two": {
+										Type: schema.TypeString,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedChangedFields: map[string]ResourceChanges{
+				"google_service_one_resource_one": {
+					"field_one": ResourceChanges{
+						"field_two": &Field{Added: true, Reason: "added"},
+					},
+				},
+			},
+		},
+		{
+			name: "optional-to-required",
+			oldResourceMap: map[string]*schema.Resource{
+				"google_service_one_resource_one": {
+					Schema: map[string]*schema.Schema{
+						"field_one": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+					},
+				},
+			},
+			newResourceMap: map[string]*schema.Resource{
+				"google_service_one_resource_one": {
+					Schema: map[string]*schema.Schema{
+						"field_one": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+					},
+				},
+			},
+			expectedChangedFields: map[string]ResourceChanges{
+				"google_service_one_resource_one": {
+					"field_one": &Field{Changed: true, Reason: "changed from optional to required"},
+				},
+			},
+		},
+		{
+			name: "added-enum-values",
+			oldResourceMap: map[string]*schema.Resource{
+				"google_service_one_resource_one": {
+					Schema: map[string]*schema.Schema{
+						"field_one": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: `The mode. Possible values: ["ONE", "TWO"]`,
+						},
+						"field_two": {
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem: &schema.Schema{
+								Type:        schema.TypeString,
+								Description: `Possible values: ["ONE"]`,
+							},
+						},
+					},
+				},
+			},
+			newResourceMap: map[string]*schema.Resource{
+				"google_service_one_resource_one": {
+					Schema: map[string]*schema.Schema{
+						"field_one": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: `The mode. Possible values: ["ONE", "TWO", "THREE"]`,
+						},
+						"field_two": {
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem: &schema.Schema{
+								Type:        schema.TypeString,
+								Description: `Possible values: ["ONE"]`,
+							},
+						},
+					},
+				},
+			},
+			expectedChangedFields: map[string]ResourceChanges{
+				"google_service_one_resource_one": {
+					"field_one": &Field{Changed: true, Reason: "enum values THREE added"},
+				},
+			},
+		},
+		{
+			name: "added-validated-enum-values",
+			oldResourceMap: map[string]*schema.Resource{
+				"google_service_one_resource_one": {
+					Schema: map[string]*schema.Schema{
+						"field_one": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringInSlice([]string{"ONE", "TWO", ""}, false),
+						},
+						"field_two": {
+							Type:     schema.TypeSet,
+							Optional: true,
+							Elem: &schema.Schema{
+								Type:             schema.TypeString,
+								ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{"ONE"}, false)),
+							},
+						},
+					},
+				},
+			},
+			newResourceMap: map[string]*schema.Resource{
+				"google_service_one_resource_one": {
+					Schema: map[string]*schema.Schema{
+						"field_one": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringInSlice([]string{"ONE", "TWO", "THREE", ""}, false),
+						},
+						"field_two": {
+							Type:     schema.TypeSet,
+							Optional: true,
+							Elem: &schema.Schema{
+								Type:             schema.TypeString,
+								ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{"ONE", "TWO"}, false)),
+							},
+						},
+					},
+				},
+			},
+			expectedChangedFields: map[string]ResourceChanges{
+				"google_service_one_resource_one": {
+					"field_one": &Field{Changed: true, Reason: "enum values THREE added"},
+					"field_two": &Field{Changed: true, Reason: "enum values TWO added"},
+				},
+			},
+		},
+		{
+			name: "stale-enum-description",
+			oldResourceMap: map[string]*schema.Resource{
+				"google_service_one_resource_one": {
+					Schema: map[string]*schema.Schema{
+						"field_one": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Description:  `The mode. Possible values: ["ONE"]`,
+							ValidateFunc: validation.StringInSlice([]string{"ONE", "TWO"}, false),
+						},
+					},
+				},
+			},
+			newResourceMap: map[string]*schema.Resource{
+				"google_service_one_resource_one": {
+					Schema: map[string]*schema.Schema{
+						"field_one": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Description:  `The mode. Possible values: ["ONE", "TWO"]`,
+							ValidateFunc: validation.StringInSlice([]string{"ONE", "TWO"}, false),
+						},
+					},
+				},
+			},
+			expectedChangedFields: map[string]ResourceChanges{},
+		},
+		{
+			name: "multiple-reasons",
+			oldResourceMap: map[string]*schema.Resource{
+				"google_service_one_resource_one": {
+					Schema: map[string]*schema.Schema{
+						"field_one": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+					},
+				},
+			},
+			newResourceMap: map[string]*schema.Resource{
+				"google_service_one_resource_one": {
+					Schema: map[string]*schema.Schema{
+						"field_one": {
+							Type:     schema.TypeInt,
+							Required: true,
+						},
+					},
+				},
+			},
+			expectedChangedFields: map[string]ResourceChanges{
+				"google_service_one_resource_one": {
+					"field_one": &Field{Changed: true, Reason: "type changed from TypeString to TypeInt; changed from optional to required"},
 				},
 			},
 		},
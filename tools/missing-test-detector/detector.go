@@ -2,12 +2,15 @@ package main
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
 type MissingTestInfo struct {
-	UntestedFields []string
-	Tests          []string
+	UntestedFields []string `json:"untested_fields"`
+	// UntestedFieldReasons maps each untested field to the reason it needs a test.
+	UntestedFieldReasons map[string]string `json:"untested_field_reasons"`
+	Tests                []string          `json:"tests"`
 }
 
 type FieldSet map[string]struct{}
@@ -15,13 +18,12 @@ type FieldSet map[string]struct{}
 // Detect missing tests for the given resource changes map in the given slice of tests.
 // Return a map of resource names to missing test info about that resource.
 func detectMissingTests(changedFields map[string]ResourceChanges, allTests []*Test) (map[string]*MissingTestInfo, error) {
-	resourceNamesToTests := make(map[string][]string)
+	resourceNamesToTests := testsByResource(changedFields, allTests)
 	for _, test := range allTests {
 		for _, step := range test.Steps {
 			for resourceName, resourceMap := range step {
 				if changedResourceFields, ok := changedFields[resourceName]; ok {
 					// This resource type has changed fields.
-					resourceNamesToTests[resourceName] = append(resourceNamesToTests[resourceName], test.Name)
 					for _, resourceConfig := range resourceMap {
 						if err := markCoverage(changedResourceFields, resourceConfig); err != nil {
 							return nil, err
@@ -35,15 +37,44 @@ func detectMissingTests(changedFields map[string]ResourceChanges, allTests []*Te
 	for resourceName, fieldCoverage := range changedFields {
 		untested := untestedFields(fieldCoverage, nil)
 		if len(untested) > 0 {
+			untestedFieldNames := make([]string, 0, len(untested))
+			for fieldName := range untested {
+				untestedFieldNames = append(untestedFieldNames, fieldName)
+			}
+			sort.Strings(untestedFieldNames)
 			missingTests[resourceName] = &MissingTestInfo{
-				UntestedFields: untestedFields(fieldCoverage, nil),
-				Tests:          resourceNamesToTests[resourceName],
+				UntestedFields:       untestedFieldNames,
+				UntestedFieldReasons: untested,
+				Tests:                resourceNamesToTests[resourceName],
 			}
 		}
 	}
 	return missingTests, nil
 }
 
+// Return a map of names of resources with changed fields to the names of tests
+// which include that resource in any step.
+func testsByResource(changedFields map[string]ResourceChanges, allTests []*Test) map[string][]string {
+	resourceNamesToTests := make(map[string][]string)
+	for _, test := range allTests {
+		included := make(map[string]struct{})
+		for _, step := range test.Steps {
+			for resourceName := range step {
+				if _, ok := changedFields[resourceName]; ok {
+					included[resourceName] = struct{}{}
+				}
+			}
+		}
+		for resourceName := range included {
+			resourceNamesToTests[resourceName] = append(resourceNamesToTests[resourceName], test.Name)
+		}
+	}
+	for _, tests := range resourceNamesToTests {
+		sort.Strings(tests)
+	}
+	return resourceNamesToTests
+}
+
 func markCoverage(fieldCoverage ResourceChanges, config Resource) error {
 	for fieldName, fieldValue := range config {
 		if coverage, ok := fieldCoverage[fieldName]; ok {
@@ -63,15 +94,18 @@ func markCoverage(fieldCoverage ResourceChanges, config Resource) error {
 	return nil
 }
 
-func untestedFields(fieldCoverage ResourceChanges, path []string) []string {
-	fields := make([]string, 0)
+// Return a map of dotted paths of untested fields to the reason they need a test.
+func untestedFields(fieldCoverage ResourceChanges, path []string) map[string]string {
+	fields := make(map[string]string)
 	for fieldName, coverage := range fieldCoverage {
 		if field, ok := coverage.(*Field); ok {
 			if !field.Tested {
-				fields = append(fields, strings.Join(append(path, fieldName), "."))
+				fields[strings.Join(append(path, fieldName), ".")] = field.Reason
 			}
 		} else if objectCoverage, ok := coverage.(ResourceChanges); ok {
-			fields = append(fields, untestedFields(objectCoverage, append(path, fieldName))...)
+			for nestedFieldName, reason := range untestedFields(objectCoverage, append(path, fieldName)) {
+				fields[nestedFieldName] = reason
+			}
 		}
 	}
 	return fields
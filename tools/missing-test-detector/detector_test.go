@@ -15,6 +15,7 @@ func TestDetectMissingTests(t *testing.T) {
 		name                   string
 		changedFields          map[string]ResourceChanges
 		expectedUntestedFields []string
+		expectedReasons        map[string]string
 	}{
 		{
 			name: "covered-resource",
@@ -36,18 +37,22 @@ func TestDetectMissingTests(t *testing.T) {
 			name: "uncovered-resource",
 			changedFields: map[string]ResourceChanges{
 				"uncovered_resource": {
-					"field_one": &Field{Changed: true},
+					"field_one": &Field{Changed: true, Reason: "type changed from TypeString to TypeInt"},
 					"field_two": ResourceChanges{
 						"field_three": &Field{Added: true},
 					},
 					"field_four": ResourceChanges{
 						"field_five": ResourceChanges{
-							"field_six": &Field{Changed: true},
+							"field_six": &Field{Changed: true, Reason: "changed from optional to required"},
 						},
 					},
 				},
 			},
 			expectedUntestedFields: []string{"field_four.field_five.field_six", "field_one"},
+			expectedReasons: map[string]string{
+				"field_four.field_five.field_six": "changed from optional to required",
+				"field_one":                       "type changed from TypeString to TypeInt",
+			},
 		},
 		{
 			name: "config-variable-resource",
@@ -86,6 +91,11 @@ func TestDetectMissingTests(t *testing.T) {
 							"did not find expected untested fields in %s, found %v, expected %v",
 							test.name, missingTest.UntestedFields, test.expectedUntestedFields)
 					}
+					if test.expectedReasons != nil && !reflect.DeepEqual(missingTest.UntestedFieldReasons, test.expectedReasons) {
+						t.Errorf(
+							"did not find expected untested field reasons in %s, found %v, expected %v",
+							test.name, missingTest.UntestedFieldReasons, test.expectedReasons)
+					}
 				}
 			} else {
 				t.Errorf("found unexpected number of missing tests in %s: %d", test.name, len(missingTests))
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"regexp"
+	"strconv"
+)
+
+// Maximum depth of nested helper calls and variable references followed when
+// resolving a config. Guards against recursive helpers.
+const maxResolveDepth = 32
+
+// Value substituted for template arguments that can't be resolved statically.
+const placeholderValue = "placeholder"
+
+// configIndex holds the declarations of every test file in a package so configs
+// built by helpers in other files can be resolved.
+type configIndex struct {
+	funcDecls map[string]*ast.FuncDecl // map of function names to function declarations
+	varDecls  map[string]ast.Expr      // map of package variable and constant names to value expressions
+}
+
+// scope maps local variable names to the expression last assigned to them.
+type scope map[string]ast.Expr
+
+func newConfigIndex() *configIndex {
+	return &configIndex{
+		funcDecls: make(map[string]*ast.FuncDecl),
+		varDecls:  make(map[string]ast.Expr),
+	}
+}
+
+// Add the top level function, variable and constant declarations of a file to the index.
+func (index *configIndex) addFile(f *ast.File) {
+	for _, decl := range f.Decls {
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok {
+			// This is a function declaration. Skip methods since they can't be called by name.
+			if funcDecl.Recv == nil && funcDecl.Body != nil {
+				index.funcDecls[funcDecl.Name.Name] = funcDecl
+			}
+		} else if genDecl, ok := decl.(*ast.GenDecl); ok {
+			// This is an import, constant, type, or variable declaration
+			for _, spec := range genDecl.Specs {
+				if valueSpec, ok := spec.(*ast.ValueSpec); ok {
+					for i, name := range valueSpec.Names {
+						if i < len(valueSpec.Values) {
+							index.varDecls[name.Name] = valueSpec.Values[i]
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// Return the local variables assigned in the body of the given function.
+func localScope(funcDecl *ast.FuncDecl) scope {
+	s := make(scope)
+	if funcDecl.Body == nil {
+		return s
+	}
+	for _, stmt := range funcDecl.Body.List {
+		switch stmt := stmt.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range stmt.Lhs {
+				ident, ok := lhs.(*ast.Ident)
+				if !ok || i >= len(stmt.Rhs) || len(stmt.Lhs) != len(stmt.Rhs) {
+					continue
+				}
+				rhs, tok := stmt.Rhs[i], stmt.Tok
+				if binaryExpr, ok := rhs.(*ast.BinaryExpr); ok && tok == token.ASSIGN && binaryExpr.Op == token.ADD {
+					// Fold config = config + "..." into the existing value.
+					if x, ok := binaryExpr.X.(*ast.Ident); ok && x.Name == ident.Name {
+						rhs, tok = binaryExpr.Y, token.ADD_ASSIGN
+					}
+				}
+				if existing, ok := s[ident.Name]; ok && tok == token.ADD_ASSIGN {
+					s[ident.Name] = &ast.BinaryExpr{X: existing, Op: token.ADD, Y: rhs}
+					continue
+				}
+				s[ident.Name] = rhs
+			}
+		case *ast.DeclStmt:
+			if genDecl, ok := stmt.Decl.(*ast.GenDecl); ok {
+				for _, spec := range genDecl.Specs {
+					if valueSpec, ok := spec.(*ast.ValueSpec); ok {
+						for i, name := range valueSpec.Names {
+							if i < len(valueSpec.Values) {
+								s[name.Name] = valueSpec.Values[i]
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return s
+}
+
+// Resolve the given expression to the config string it produces, following
+// config helpers, variables, string concatenation and fmt.Sprintf/Nprintf templates.
+// Template arguments that can't be resolved are replaced with placeholder values.
+func (index *configIndex) resolveConfig(expr ast.Expr, s scope) (string, error) {
+	return index.resolve(expr, s, 0)
+}
+
+func (index *configIndex) resolve(expr ast.Expr, s scope, depth int) (string, error) {
+	if depth > maxResolveDepth {
+		return "", fmt.Errorf("exceeded maximum depth resolving %v", expr)
+	}
+	switch expr := expr.(type) {
+	case *ast.BasicLit:
+		if expr.Kind != token.STRING {
+			return expr.Value, nil
+		}
+		return strconv.Unquote(expr.Value)
+	case *ast.ParenExpr:
+		return index.resolve(expr.X, s, depth+1)
+	case *ast.Ident:
+		if value, ok := s[expr.Name]; ok {
+			return index.resolve(value, s, depth+1)
+		}
+		if value, ok := index.varDecls[expr.Name]; ok {
+			return index.resolve(value, nil, depth+1)
+		}
+		return "", fmt.Errorf("failed to find declaration of %s", expr.Name)
+	case *ast.BinaryExpr:
+		if expr.Op != token.ADD {
+			return "", fmt.Errorf("unsupported operator %s in config", expr.Op)
+		}
+		x, err := index.resolve(expr.X, s, depth+1)
+		if err != nil {
+			return "", err
+		}
+		y, err := index.resolve(expr.Y, s, depth+1)
+		if err != nil {
+			return "", err
+		}
+		return x + y, nil
+	case *ast.CallExpr:
+		return index.resolveCall(expr, s, depth)
+	}
+	return "", fmt.Errorf("unsupported expression %T in config", expr)
+}
+
+func (index *configIndex) resolveCall(callExpr *ast.CallExpr, s scope, depth int) (string, error) {
+	var funcName string
+	switch fun := callExpr.Fun.(type) {
+	case *ast.Ident:
+		funcName = fun.Name
+	case *ast.SelectorExpr:
+		if ident, ok := fun.X.(*ast.Ident); ok && ident.Name == "fmt" && fun.Sel.Name == "Sprintf" {
+			return index.resolveSprintf(callExpr, s, depth)
+		}
+		// Nprintf may be called from another package, e.g. acctest.Nprintf.
+		funcName = fun.Sel.Name
+		if funcName != "Nprintf" {
+			return "", fmt.Errorf("unsupported call to %v.%s in config", fun.X, funcName)
+		}
+	default:
+		return "", fmt.Errorf("failed to get ident for %v", callExpr.Fun)
+	}
+	if funcName == "Nprintf" {
+		return index.resolveNprintf(callExpr, s, depth)
+	}
+	configFunc, ok := index.funcDecls[funcName]
+	if !ok {
+		return "", fmt.Errorf("failed to find function declaration %s", funcName)
+	}
+	return index.resolveConfigFunc(configFunc, depth)
+}
+
+// Resolve the value returned by a config-returning helper function.
+func (index *configIndex) resolveConfigFunc(configFunc *ast.FuncDecl, depth int) (string, error) {
+	s := localScope(configFunc)
+	for _, stmt := range configFunc.Body.List {
+		if returnStmt, ok := stmt.(*ast.ReturnStmt); ok {
+			if len(returnStmt.Results) == 0 {
+				return "", fmt.Errorf("failed to find a result in return statement of %s", configFunc.Name.Name)
+			}
+			return index.resolve(returnStmt.Results[0], s, depth+1)
+		}
+	}
+	return "", fmt.Errorf("failed to find a return statement in %s", configFunc.Name.Name)
+}
+
+var nprintfKeyRegexp = regexp.MustCompile(`%{[^}]*}`)
+
+// Resolve a call to Nprintf, replacing every %{key} with a placeholder value since
+// the parameters map is usually built in the test function.
+func (index *configIndex) resolveNprintf(callExpr *ast.CallExpr, s scope, depth int) (string, error) {
+	if len(callExpr.Args) == 0 {
+		return "", fmt.Errorf("no arguments found for call expression %v", callExpr.Fun)
+	}
+	format, err := index.resolve(callExpr.Args[0], s, depth+1)
+	if err != nil {
+		return "", err
+	}
+	return nprintfKeyRegexp.ReplaceAllString(format, placeholderValue), nil
+}
+
+var sprintfVerbRegexp = regexp.MustCompile(`%(\[(\d+)\])?[-+# 0]*(\*|\d+)?(\.(\*|\d+)?)?([a-zA-Z%])`)
+
+// Resolve a call to fmt.Sprintf. Arguments that resolve to strings, such as other
+// config helpers, are substituted. The rest are replaced with placeholder values.
+func (index *configIndex) resolveSprintf(callExpr *ast.CallExpr, s scope, depth int) (string, error) {
+	if len(callExpr.Args) == 0 {
+		return "", fmt.Errorf("no arguments found for call expression %v", callExpr.Fun)
+	}
+	format, err := index.resolve(callExpr.Args[0], s, depth+1)
+	if err != nil {
+		return "", err
+	}
+	args := callExpr.Args[1:]
+	argIndex := 0
+	return sprintfVerbRegexp.ReplaceAllStringFunc(format, func(verb string) string {
+		submatches := sprintfVerbRegexp.FindStringSubmatch(verb)
+		if submatches[6] == "%" {
+			return "%"
+		}
+		if submatches[2] != "" {
+			if explicitIndex, err := strconv.Atoi(submatches[2]); err == nil {
+				argIndex = explicitIndex - 1
+			}
+		}
+		var arg ast.Expr
+		if argIndex >= 0 && argIndex < len(args) {
+			arg = args[argIndex]
+		}
+		argIndex++
+		if arg != nil && (submatches[6] == "s" || submatches[6] == "v" || submatches[6] == "q") {
+			if value, err := index.resolve(arg, s, depth+1); err == nil {
+				if submatches[6] == "q" {
+					return strconv.Quote(value)
+				}
+				return value
+			}
+		}
+		return placeholderForVerb(submatches[6])
+	}), nil
+}
+
+func placeholderForVerb(verb string) string {
+	switch verb {
+	case "d", "b", "o", "x", "X", "c", "U":
+		return "1"
+	case "e", "E", "f", "F", "g", "G":
+		return "1.0"
+	case "t":
+		return "true"
+	case "q":
+		return strconv.Quote(placeholderValue)
+	}
+	return placeholderValue
+}
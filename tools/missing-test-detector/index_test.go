@@ -0,0 +1,84 @@
+package main
+
+import (
+	"go/parser"
+	"testing"
+)
+
+func TestResolveConfig(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		expr     string
+		expected string
+	}{
+		{
+			name:     "string-literal",
+			expr:     "`resource \"a\" \"b\" {}`",
+			expected: `resource "a" "b" {}`,
+		},
+		{
+			name:     "concatenation",
+			expr:     `"one" + ("two" + "three")`,
+			expected: "onetwothree",
+		},
+		{
+			name:     "nprintf",
+			expr:     `Nprintf("name = \"tf-test-%{random_suffix}\"", context)`,
+			expected: `name = "tf-test-placeholder"`,
+		},
+		{
+			name:     "package-nprintf",
+			expr:     `acctest.Nprintf("%{a}-%{b}", context)`,
+			expected: "placeholder-placeholder",
+		},
+		{
+			name:     "sprintf-verbs",
+			expr:     `fmt.Sprintf("%s %d %t %q %.2f %%", name, count, enabled, quoted, ratio)`,
+			expected: `placeholder 1 true "placeholder" 1.0 %`,
+		},
+		{
+			name:     "sprintf-resolved-arguments",
+			expr:     `fmt.Sprintf("%s-%v-%[1]s", "one", "two")`,
+			expected: "one-two-one",
+		},
+	} {
+		expr, err := parser.ParseExpr(test.expr)
+		if err != nil {
+			t.Fatalf("error parsing expression for %s: %v", test.name, err)
+		}
+		resolved, err := newConfigIndex().resolveConfig(expr, nil)
+		if err != nil {
+			t.Errorf("error resolving config for %s: %v", test.name, err)
+		} else if resolved != test.expected {
+			t.Errorf("%s test failed: resolved %q, expected %q", test.name, resolved, test.expected)
+		}
+	}
+}
+
+func TestResolveConfigErrors(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		expr string
+	}{
+		{
+			name: "undeclared-variable",
+			expr: "undeclared",
+		},
+		{
+			name: "undeclared-function",
+			expr: "testAccUndeclared()",
+		},
+		{
+			name: "unsupported-call",
+			expr: `strings.Repeat("a", 2)`,
+		},
+	} {
+		expr, err := parser.ParseExpr(test.expr)
+		if err != nil {
+			t.Fatalf("error parsing expression for %s: %v", test.name, err)
+		}
+		if resolved, err := newConfigIndex().resolveConfig(expr, nil); err == nil {
+			t.Errorf("%s test failed: expected an error, resolved %q", test.name, resolved)
+		}
+	}
+}
@@ -1,23 +1,39 @@
 package main
 
 import (
+	"errors"
 	"flag"
-	"fmt"
 	"os"
-	"strings"
-	"text/template"
 
 	"github.com/golang/glog"
 )
 
+// Exit codes so CI can tell missing coverage apart from a failed analysis.
+// Usage errors exit with 1 through glog.Exit.
+const (
+	exitCodeMissingTests   = 2
+	exitCodeAnalysisFailed = 3
+)
+
 var flagProviderDir = flag.String("provider-dir", "", "directory where test files are located")
+var flagOutputFormat = flag.String("output-format", outputFormatMarkdown, "format of the report: markdown or json")
 
 func main() {
 	flag.Parse()
+	if *flagOutputFormat != outputFormatMarkdown && *flagOutputFormat != outputFormatJSON {
+		glog.Exitf("unknown output format %q, expected markdown or json", *flagOutputFormat)
+	}
 
-	allTests, err := readAllTests(*flagProviderDir)
-	if err != nil {
-		glog.Errorf("error reading all test files: %v", err)
+	analysisFailed := false
+	allTests, readErr := readAllTests(*flagProviderDir)
+	if readErr != nil {
+		glog.Errorf("error reading all test files: %v", readErr)
+		// Errors in individual files are listed in the report and only make it
+		// incomplete; failing to read the provider directory fails the analysis.
+		var fileErrs FileErrors
+		if !errors.As(readErr, &fileErrs) {
+			analysisFailed = true
+		}
 	}
 
 	changedFields := changedResourceFields()
@@ -25,27 +41,32 @@ func main() {
 	missingTests, err := detectMissingTests(changedFields, allTests)
 	if err != nil {
 		glog.Errorf("error detecting missing tests: %v", err)
+		analysisFailed = true
+	}
+	for resourceName, missingTestInfo := range missingTests {
+		glog.Infof("%s tests parsed: %v", resourceName, missingTestInfo.Tests)
+	}
+
+	report := newReport(missingTests, testsByResource(changedFields, allTests), *flagProviderDir, readErr)
+	if err := writeReport(os.Stdout, *flagOutputFormat, report); err != nil {
+		glog.Errorf("error writing report: %v", err)
+		glog.Flush()
+		os.Exit(exitCodeAnalysisFailed)
+	}
+
+	glog.Flush()
+	os.Exit(exitCode(missingTests, analysisFailed))
+}
+
+// A failed analysis takes precedence over missing tests, as the missing tests
+// found are incomplete when other resources couldn't be checked. The report
+// still lists those that were found.
+func exitCode(missingTests map[string]*MissingTestInfo, analysisFailed bool) int {
+	if analysisFailed {
+		return exitCodeAnalysisFailed
 	}
 	if len(missingTests) > 0 {
-		funcs := template.FuncMap{
-			"join": strings.Join,
-			"backTickAll": func(ss []string) []string {
-				rs := make([]string, len(ss))
-				for i, s := range ss {
-					rs[i] = fmt.Sprintf("`%s`", s)
-				}
-				return rs
-			},
-		}
-		outputTemplate, err := template.New("output.tmpl").Funcs(funcs).ParseFiles("output.tmpl")
-		if err != nil {
-			glog.Exitf("Error parsing missing test template file: %s", err)
-		}
-		if err := outputTemplate.Execute(os.Stdout, missingTests); err != nil {
-			glog.Exitf("Error executing missing test output template: %s", err)
-		}
-		for resourceName, missingTestInfo := range missingTests {
-			glog.Infof("%s tests parsed: %v", resourceName, missingTestInfo.Tests)
-		}
+		return exitCodeMissingTests
 	}
+	return 0
 }
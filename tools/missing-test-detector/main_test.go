@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestExitCode(t *testing.T) {
+	missingTests := map[string]*MissingTestInfo{
+		"resource_one": {UntestedFields: []string{"field_one"}},
+	}
+	for _, test := range []struct {
+		name           string
+		missingTests   map[string]*MissingTestInfo
+		analysisFailed bool
+		expected       int
+	}{
+		{name: "covered", expected: 0},
+		{name: "missing tests", missingTests: missingTests, expected: exitCodeMissingTests},
+		{name: "missing tests in a failed analysis", missingTests: missingTests, analysisFailed: true, expected: exitCodeAnalysisFailed},
+		{name: "failed analysis", analysisFailed: true, expected: exitCodeAnalysisFailed},
+	} {
+		if code := exitCode(test.missingTests, test.analysisFailed); code != test.expected {
+			t.Errorf("test %s: expected exit code %d, got %d", test.name, test.expected, code)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/template"
+)
+
+const (
+	outputFormatMarkdown = "markdown"
+	outputFormatJSON     = "json"
+)
+
+// Report is the structured result of a missing test detection run.
+type Report struct {
+	// MissingTests maps resource names to missing test info about that resource.
+	MissingTests map[string]*MissingTestInfo `json:"missing_tests"`
+	// ParseErrors maps test file names to the errors encountered reading them.
+	ParseErrors map[string]string `json:"parse_errors"`
+	// ResourceTests maps names of resources with changed fields to the tests that include them.
+	ResourceTests map[string][]string `json:"resource_tests"`
+}
+
+// Build a report from the detected missing tests and the error returned when reading
+// tests in providerDir. Errors not specific to a file are reported for providerDir.
+func newReport(missingTests map[string]*MissingTestInfo, resourceTests map[string][]string, providerDir string, readErr error) *Report {
+	report := &Report{
+		MissingTests:  missingTests,
+		ParseErrors:   make(map[string]string),
+		ResourceTests: resourceTests,
+	}
+	var fileErrs FileErrors
+	if errors.As(readErr, &fileErrs) {
+		for fileName, err := range fileErrs {
+			report.ParseErrors[fileName] = err.Error()
+		}
+	} else if readErr != nil {
+		report.ParseErrors[providerDir] = readErr.Error()
+	}
+	return report
+}
+
+func writeReport(w io.Writer, format string, report *Report) error {
+	switch format {
+	case outputFormatMarkdown:
+		return writeMarkdown(w, report)
+	case outputFormatJSON:
+		return writeJSON(w, report)
+	}
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+func writeMarkdown(w io.Writer, report *Report) error {
+	if len(report.MissingTests) == 0 {
+		return nil
+	}
+	outputTemplate, err := template.ParseFiles("output.tmpl")
+	if err != nil {
+		return fmt.Errorf("error parsing missing test template file: %s", err)
+	}
+	if err := outputTemplate.Execute(w, report.MissingTests); err != nil {
+		return fmt.Errorf("error executing missing test output template: %s", err)
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, report *Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewReport(t *testing.T) {
+	missingTests := map[string]*MissingTestInfo{
+		"resource_one": {
+			UntestedFields:       []string{"field_one"},
+			UntestedFieldReasons: map[string]string{"field_one": "added"},
+			Tests:                []string{"TestAccResourceOne"},
+		},
+	}
+	resourceTests := map[string][]string{
+		"resource_one": {"TestAccResourceOne"},
+		"resource_two": {"TestAccResourceTwo", "TestAccResourceTwo_update"},
+	}
+	for _, test := range []struct {
+		name                string
+		readErr             error
+		expectedParseErrors map[string]string
+	}{
+		{
+			name:                "no-errors",
+			expectedParseErrors: map[string]string{},
+		},
+		{
+			name: "file-errors",
+			readErr: FileErrors{
+				"one_test.go": errors.New("failed one"),
+				"two_test.go": errors.New("failed two"),
+			},
+			expectedParseErrors: map[string]string{
+				"one_test.go": "failed one",
+				"two_test.go": "failed two",
+			},
+		},
+		{
+			name:                "directory-error",
+			readErr:             errors.New("no such directory"),
+			expectedParseErrors: map[string]string{"provider": "no such directory"},
+		},
+	} {
+		report := newReport(missingTests, resourceTests, "provider", test.readErr)
+		if !reflect.DeepEqual(report.ParseErrors, test.expectedParseErrors) {
+			t.Errorf("%s test failed: unexpected parse errors %v, expected %v", test.name, report.ParseErrors, test.expectedParseErrors)
+		}
+		var buf bytes.Buffer
+		if err := writeReport(&buf, outputFormatJSON, report); err != nil {
+			t.Fatalf("%s test failed: error writing json report: %v", test.name, err)
+		}
+		var decoded Report
+		if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatalf("%s test failed: error decoding json report: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(decoded.MissingTests, missingTests) {
+			t.Errorf("%s test failed: unexpected missing tests %v, expected %v", test.name, decoded.MissingTests, missingTests)
+		}
+		if !reflect.DeepEqual(decoded.ResourceTests, resourceTests) {
+			t.Errorf("%s test failed: unexpected resource tests %v, expected %v", test.name, decoded.ResourceTests, resourceTests)
+		}
+	}
+}
+
+func TestWriteReportUnknownFormat(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeReport(&buf, "xml", &Report{}); err == nil || !strings.Contains(err.Error(), "xml") {
+		t.Errorf("expected an unknown format error, got %v", err)
+	}
+}
+
+func TestReadAllTestsFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "broken_test.go"), []byte("package google\n\nfunc {"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := readAllTests(dir)
+	var fileErrs FileErrors
+	if !errors.As(err, &fileErrs) {
+		t.Fatalf("expected errors reading tests to be reported per file, got %v", err)
+	}
+	if _, ok := fileErrs["broken_test.go"]; !ok || len(fileErrs) != 1 {
+		t.Errorf("expected a single error for broken_test.go, got %v", fileErrs)
+	}
+}
@@ -7,7 +7,7 @@ import (
 	"go/token"
 	"os"
 	"path/filepath"
-	"strconv"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
@@ -27,63 +27,83 @@ type Test struct {
 	Steps []Step
 }
 
+// FileErrors maps test file names to the errors encountered reading them.
+type FileErrors map[string]error
+
+func (fileErrs FileErrors) Error() string {
+	fileNames := make([]string, 0, len(fileErrs))
+	for fileName := range fileErrs {
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+	errs := make([]string, len(fileNames))
+	for i, fileName := range fileNames {
+		errs[i] = fmt.Sprintf("%s: %v", fileName, fileErrs[fileName])
+	}
+	return fmt.Sprintf("errors reading tests: [%s]", strings.Join(errs, " "))
+}
+
+// Read all tests in the given directory. Errors reading individual files are
+// returned as FileErrors along with the tests that could be read.
 func readAllTests(providerDir string) ([]*Test, error) {
 	files, err := os.ReadDir(providerDir)
 	if err != nil {
 		return nil, err
 	}
-	allTests := make([]*Test, 0)
-	errs := make([]error, 0)
+	// Parse every test file before reading tests so configs built by helpers
+	// in other files can be resolved.
+	fset := token.NewFileSet()
+	index := newConfigIndex()
+	parsedFiles := make(map[string]*ast.File)
+	fileErrs := make(FileErrors)
 	for _, file := range files {
 		if strings.HasSuffix(file.Name(), "_test.go") {
-			tests, err := readTestFile(filepath.Join(providerDir, file.Name()))
+			f, err := parser.ParseFile(fset, filepath.Join(providerDir, file.Name()), nil, 0)
 			if err != nil {
-				errs = append(errs, err)
+				fileErrs[file.Name()] = err
+				continue
 			}
-			allTests = append(allTests, tests...)
+			index.addFile(f)
+			parsedFiles[file.Name()] = f
 		}
 	}
-	if len(errs) > 0 {
-		return allTests, fmt.Errorf("errors reading tests: %v", errs)
+	allTests := make([]*Test, 0)
+	for fileName, f := range parsedFiles {
+		tests, err := readTestsFromFile(f, index)
+		if err != nil {
+			fileErrs[fileName] = err
+		}
+		allTests = append(allTests, tests...)
+	}
+	if len(fileErrs) > 0 {
+		return allTests, fileErrs
 	}
 	return allTests, nil
 }
 
+// Read the tests in a single file, resolving configs only from declarations in that file.
 func readTestFile(filename string) ([]*Test, error) {
 	fset := token.NewFileSet()
 	f, err := parser.ParseFile(fset, filename, nil, 0)
 	if err != nil {
 		return nil, err
 	}
-	funcDecls := make(map[string]*ast.FuncDecl) // map of function names to function declarations
-	varDecls := make(map[string]*ast.BasicLit)  // map of variable names to value expressions
-	for _, decl := range f.Decls {
-		if funcDecl, ok := decl.(*ast.FuncDecl); ok {
-			// This is a function declaration.
-			funcDecls[funcDecl.Name.Name] = funcDecl
-		} else if genDecl, ok := decl.(*ast.GenDecl); ok {
-			// This is an import, constant, type, or variable declaration
-			for _, spec := range genDecl.Specs {
-				if valueSpec, ok := spec.(*ast.ValueSpec); ok {
-					if len(valueSpec.Values) > 0 {
-						if basicLit, ok := valueSpec.Values[0].(*ast.BasicLit); ok {
-							varDecls[valueSpec.Names[0].Name] = basicLit
-						}
-					}
-				}
-			}
-		}
-	}
+	index := newConfigIndex()
+	index.addFile(f)
+	return readTestsFromFile(f, index)
+}
+
+func readTestsFromFile(f *ast.File, index *configIndex) ([]*Test, error) {
 	tests := make([]*Test, 0)
 	errs := make([]error, 0)
-	for name, funcDecl := range funcDecls {
-		if strings.HasPrefix(name, "TestAcc") {
-			test, err := readTestFunc(funcDecl, funcDecls, varDecls)
+	for _, decl := range f.Decls {
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok && strings.HasPrefix(funcDecl.Name.Name, "TestAcc") {
+			test, err := readTestFunc(funcDecl, index)
 			if err != nil {
 				errs = append(errs, err)
 			}
 			if test != nil {
-				test.Name = name
+				test.Name = funcDecl.Name.Name
 				tests = append(tests, test)
 			}
 		}
@@ -94,14 +114,14 @@ func readTestFile(filename string) ([]*Test, error) {
 	return tests, nil
 }
 
-func readTestFunc(testFunc *ast.FuncDecl, funcDecls map[string]*ast.FuncDecl, varDecls map[string]*ast.BasicLit) (*Test, error) {
+func readTestFunc(testFunc *ast.FuncDecl, index *configIndex) (*Test, error) {
 	// This is an exported test function.
 	for _, stmt := range testFunc.Body.List {
 		if exprStmt, ok := stmt.(*ast.ExprStmt); ok {
 			if callExpr, ok := exprStmt.X.(*ast.CallExpr); ok {
 				// This is a call expression.
 				if ident, ok := callExpr.Fun.(*ast.Ident); ok && ident.Name == "VcrTest" {
-					return readVcrTestCall(callExpr, funcDecls, varDecls)
+					return readVcrTestCall(callExpr, index, localScope(testFunc))
 				}
 			}
 		}
@@ -109,12 +129,12 @@ func readTestFunc(testFunc *ast.FuncDecl, funcDecls map[string]*ast.FuncDecl, va
 	return nil, nil
 }
 
-func readVcrTestCall(vcrTestCall *ast.CallExpr, funcDecls map[string]*ast.FuncDecl, varDecls map[string]*ast.BasicLit) (*Test, error) {
+func readVcrTestCall(vcrTestCall *ast.CallExpr, index *configIndex, s scope) (*Test, error) {
 	for _, arg := range vcrTestCall.Args {
 		if vcrTestArgCompLit, ok := arg.(*ast.CompositeLit); ok {
 			if selExpr, ok := vcrTestArgCompLit.Type.(*ast.SelectorExpr); ok {
 				if ident, ok := selExpr.X.(*ast.Ident); ok && ident.Name == "resource" && selExpr.Sel.Name == "TestCase" {
-					return readTestCaseCompLit(vcrTestArgCompLit, funcDecls, varDecls)
+					return readTestCaseCompLit(vcrTestArgCompLit, index, s)
 				}
 			}
 		}
@@ -122,12 +142,12 @@ func readVcrTestCall(vcrTestCall *ast.CallExpr, funcDecls map[string]*ast.FuncDe
 	return nil, fmt.Errorf("failed to find TestCase in %v", vcrTestCall.Args)
 }
 
-func readTestCaseCompLit(testCaseCompLit *ast.CompositeLit, funcDecls map[string]*ast.FuncDecl, varDecls map[string]*ast.BasicLit) (*Test, error) {
+func readTestCaseCompLit(testCaseCompLit *ast.CompositeLit, index *configIndex, s scope) (*Test, error) {
 	for _, elt := range testCaseCompLit.Elts {
 		if keyValueExpr, ok := elt.(*ast.KeyValueExpr); ok {
 			if ident, ok := keyValueExpr.Key.(*ast.Ident); ok && ident.Name == "Steps" {
 				if stepsCompLit, ok := keyValueExpr.Value.(*ast.CompositeLit); ok {
-					return readStepsCompLit(stepsCompLit, funcDecls, varDecls)
+					return readStepsCompLit(stepsCompLit, index, s)
 				}
 			}
 		}
@@ -135,7 +155,7 @@ func readTestCaseCompLit(testCaseCompLit *ast.CompositeLit, funcDecls map[string
 	return nil, fmt.Errorf("failed to find Steps in %v", testCaseCompLit.Elts)
 }
 
-func readStepsCompLit(stepsCompLit *ast.CompositeLit, funcDecls map[string]*ast.FuncDecl, varDecls map[string]*ast.BasicLit) (*Test, error) {
+func readStepsCompLit(stepsCompLit *ast.CompositeLit, index *configIndex, s scope) (*Test, error) {
 	test := &Test{}
 	errs := make([]error, 0)
 	for _, elt := range stepsCompLit.Elts {
@@ -143,21 +163,16 @@ func readStepsCompLit(stepsCompLit *ast.CompositeLit, funcDecls map[string]*ast.
 			for _, eltCompLitElt := range eltCompLit.Elts {
 				if keyValueExpr, ok := eltCompLitElt.(*ast.KeyValueExpr); ok {
 					if ident, ok := keyValueExpr.Key.(*ast.Ident); ok && ident.Name == "Config" {
-						if configCallExpr, ok := keyValueExpr.Value.(*ast.CallExpr); ok {
-							step, err := readConfigCallExpr(configCallExpr, funcDecls, varDecls)
-							if err != nil {
-								errs = append(errs, err)
-							}
-							test.Steps = append(test.Steps, step)
-						} else if ident, ok := keyValueExpr.Value.(*ast.Ident); ok {
-							if configVar, ok := varDecls[ident.Name]; ok {
-								step, err := readConfigBasicLit(configVar)
-								if err != nil {
-									errs = append(errs, err)
-								}
-								test.Steps = append(test.Steps, step)
-							}
+						configStr, err := index.resolveConfig(keyValueExpr.Value, s)
+						if err != nil {
+							errs = append(errs, err)
+							continue
 						}
+						step, err := readConfigString(configStr)
+						if err != nil {
+							errs = append(errs, err)
+						}
+						test.Steps = append(test.Steps, step)
 					}
 				}
 			}
@@ -169,91 +184,57 @@ func readStepsCompLit(stepsCompLit *ast.CompositeLit, funcDecls map[string]*ast.
 	return test, nil
 }
 
-func readConfigCallExpr(configCallExpr *ast.CallExpr, funcDecls map[string]*ast.FuncDecl, varDecls map[string]*ast.BasicLit) (Step, error) {
-	if ident, ok := configCallExpr.Fun.(*ast.Ident); ok {
-		if configFunc, ok := funcDecls[ident.Name]; ok {
-			return readConfigFunc(configFunc)
-		}
-		return nil, fmt.Errorf("failed to find function declaration %s", ident.Name)
-	}
-	return nil, fmt.Errorf("failed to get ident for %v", configCallExpr.Fun)
-}
-
-func readConfigFunc(configFunc *ast.FuncDecl) (Step, error) {
-	for _, stmt := range configFunc.Body.List {
-		if returnStmt, ok := stmt.(*ast.ReturnStmt); ok {
-			for _, result := range returnStmt.Results {
-				if callExpr, ok := result.(*ast.CallExpr); ok {
-					if len(callExpr.Args) == 0 {
-						return nil, fmt.Errorf("no arguments found for call expression %v in %v", callExpr, result)
-					}
-					if basicLit, ok := callExpr.Args[0].(*ast.BasicLit); ok && basicLit.Kind == token.STRING {
-						return readConfigBasicLit(basicLit)
-					}
-					return nil, fmt.Errorf("no string literal found in arguments to call expression %v", callExpr)
-				}
-			}
-			return nil, fmt.Errorf("failed to find a call expression in results %v", returnStmt.Results)
-		}
+func readConfigString(configStr string) (Step, error) {
+	// Remove any remaining template variables because they interfere with hcl parsing.
+	configStr = strings.ReplaceAll(configStr, "%", "")
+	parser := hclparse.NewParser()
+	file, diagnostics := parser.ParseHCL([]byte(configStr), "config.hcl")
+	if diagnostics.HasErrors() {
+		return nil, fmt.Errorf("errors parsing hcl: %v", diagnostics.Errs())
 	}
-	return nil, fmt.Errorf("failed to find a return statement in %v", configFunc.Body.List)
-}
-
-func readConfigBasicLit(configBasicLit *ast.BasicLit) (Step, error) {
-	if configStr, err := strconv.Unquote(configBasicLit.Value); err != nil {
-		return nil, err
-	} else {
-		// Remove template variables because they interfere with hcl parsing.
-		configStr = strings.ReplaceAll(configStr, "%", "")
-		parser := hclparse.NewParser()
-		file, diagnostics := parser.ParseHCL([]byte(configStr), "config.hcl")
-		if diagnostics.HasErrors() {
-			return nil, fmt.Errorf("errors parsing hcl: %v", diagnostics.Errs())
-		}
-		content, diagnostics := file.Body.Content(&hcl.BodySchema{
-			Blocks: []hcl.BlockHeaderSchema{
-				{
-					Type:       "resource",
-					LabelNames: []string{"type", "name"},
-				},
-				{
-					Type:       "data",
-					LabelNames: []string{"type", "name"},
-				},
-				{
-					Type:       "output",
-					LabelNames: []string{"name"},
-				},
-				{
-					Type: "locals",
-				},
+	content, diagnostics := file.Body.Content(&hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{
+				Type:       "resource",
+				LabelNames: []string{"type", "name"},
+			},
+			{
+				Type:       "data",
+				LabelNames: []string{"type", "name"},
+			},
+			{
+				Type:       "output",
+				LabelNames: []string{"name"},
+			},
+			{
+				Type: "locals",
 			},
-		})
-		if diagnostics.HasErrors() {
-			return nil, fmt.Errorf("errors getting hcl body content: %v", diagnostics.Errs())
+		},
+	})
+	if diagnostics.HasErrors() {
+		return nil, fmt.Errorf("errors getting hcl body content: %v", diagnostics.Errs())
+	}
+	m := make(map[string]Resources)
+	errs := make([]error, 0)
+	for _, block := range content.Blocks {
+		if len(block.Labels) != 2 {
+			continue
 		}
-		m := make(map[string]Resources)
-		errs := make([]error, 0)
-		for _, block := range content.Blocks {
-			if len(block.Labels) != 2 {
-				continue
-			}
-			if _, ok := m[block.Labels[0]]; !ok {
-				// Create an empty map for this resource type.
-				m[block.Labels[0]] = make(Resources)
-			}
-			// Use the resource name as a key.
-			resourceConfig, err := readHCLBlockBody(block.Body, file.Bytes)
-			if err != nil {
-				errs = append(errs, err)
-			}
-			m[block.Labels[0]][block.Labels[1]] = resourceConfig
+		if _, ok := m[block.Labels[0]]; !ok {
+			// Create an empty map for this resource type.
+			m[block.Labels[0]] = make(Resources)
 		}
-		if len(errs) > 0 {
-			return m, fmt.Errorf("errors reading hcl blocks: %v", errs)
+		// Use the resource name as a key.
+		resourceConfig, err := readHCLBlockBody(block.Body, file.Bytes)
+		if err != nil {
+			errs = append(errs, err)
 		}
-		return m, nil
+		m[block.Labels[0]][block.Labels[1]] = resourceConfig
 	}
+	if len(errs) > 0 {
+		return m, fmt.Errorf("errors reading hcl blocks: %v", errs)
+	}
+	return m, nil
 }
 
 func readHCLBlockBody(body hcl.Body, fileBytes []byte) (Resource, error) {
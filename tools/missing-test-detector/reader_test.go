@@ -95,3 +95,56 @@ func TestReadMultipleResourcesTestFile(t *testing.T) {
 		t.Errorf("found unexpected test steps for multiple resources: %#v, expected %#v", tests[0].Steps, expectedSteps)
 	}
 }
+
+func TestReadCrossFileConfigTests(t *testing.T) {
+	allTests, err := readAllTests("testdata")
+	if err != nil {
+		t.Fatalf("error reading test files: %v", err)
+	}
+	var crossFileTest *Test
+	for _, test := range allTests {
+		if test.Name == "TestAccCrossFileConfig" {
+			crossFileTest = test
+		}
+	}
+	if crossFileTest == nil {
+		t.Fatalf("did not find TestAccCrossFileConfig in %d tests", len(allTests))
+	}
+	if expectedSteps := []Step{
+		{
+			"shared_network": {
+				"network": {"name": "\"tf-test-network-placeholder\""},
+			},
+			"cross_file_resource": {
+				"instance": {
+					"name":      "\"tf-test-placeholder\"",
+					"network":   "\"network\"",
+					"field_one": "\"value-one\"",
+				},
+			},
+		},
+		{
+			"shared_network": {
+				"network": {"name": "\"tf-test-network-placeholder\""},
+			},
+			"shared_subnetwork": {
+				"subnetwork": {"network": "\"placeholder\""},
+			},
+			"cross_file_resource": {
+				"shared": {
+					"field_two": Resource{"field_three": "\"value-three\""},
+				},
+			},
+		},
+		{
+			"cross_file_resource": {
+				"formatted": {
+					"field_one":   "\"value-two\"",
+					"field_count": "1",
+				},
+			},
+		},
+	}; !reflect.DeepEqual(crossFileTest.Steps, expectedSteps) {
+		t.Errorf("found unexpected test steps for cross file configs: %#v, expected %#v", crossFileTest.Steps, expectedSteps)
+	}
+}
@@ -0,0 +1,41 @@
+package google
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccCrossFileConfig(t *testing.T) {
+	context := map[string]interface{}{
+		"random_suffix": RandString(t, 10),
+	}
+	config := testAccCrossFileConfig_network(context)
+
+	VcrTest(t, resource.TestCase{
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCrossFileConfig_instance(context),
+			},
+			{
+				Config: config + testAccSharedConfigResource,
+			},
+			{
+				Config: fmt.Sprintf(testAccSharedConfigFormat, "value-two", 3),
+			},
+		},
+	})
+}
+
+func testAccCrossFileConfig_instance(context map[string]interface{}) string {
+	return fmt.Sprintf(`
+%s
+
+resource "cross_file_resource" "instance" {
+  name       = "tf-test-%s"
+  network    = %q
+  field_one  = "value-one"
+}
+`, testAccSharedConfig_network(context), context["random_suffix"], "network")
+}
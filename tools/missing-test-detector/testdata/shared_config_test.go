@@ -0,0 +1,38 @@
+package google
+
+import (
+	"fmt"
+)
+
+const testAccSharedConfigResource = `
+resource "cross_file_resource" "shared" {
+  field_two {
+    field_three = "value-three"
+  }
+}
+`
+
+var testAccSharedConfigFormat = `
+resource "cross_file_resource" "formatted" {
+  field_one   = "%s"
+  field_count = %d
+}
+`
+
+func testAccSharedConfig_network(context map[string]interface{}) string {
+	return Nprintf(`
+resource "shared_network" "network" {
+  name = "tf-test-network-%{random_suffix}"
+}
+`, context)
+}
+
+func testAccCrossFileConfig_network(context map[string]interface{}) string {
+	config := testAccSharedConfig_network(context)
+	config += fmt.Sprintf(`
+resource "shared_subnetwork" "subnetwork" {
+  network = "%s"
+}
+`, context["random_suffix"])
+	return config
+}
@@ -17,7 +17,6 @@ package main
 import (
 	"bytes"
 	"fmt"
-	"io/ioutil"
 	"path"
 	"sort"
 	"text/template"
@@ -120,9 +119,6 @@ func generateResourceWebsiteFile(res *Resource, resources map[Version][]*Resourc
 		fmt.Printf("%v\n", string(source))
 	} else {
 		outname := fmt.Sprintf("%s_%s.html.markdown", res.ProductName(), res.Name())
-		err := ioutil.WriteFile(path.Join(*oPath, "website/docs/r", outname), source, 0644)
-		if err != nil {
-			glog.Exit(err)
-		}
+		outputs.write(res.sourceFile, path.Join("website/docs/r", outname), source)
 	}
 }
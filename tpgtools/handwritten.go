@@ -19,6 +19,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/golang/glog"
@@ -68,15 +69,16 @@ func copyHandwrittenFiles(inPath string, outPath string) {
 		if strings.HasSuffix(f.Name(), ".go") {
 			b, err = formatSource(bytes.NewBuffer(b))
 			if err != nil {
-				glog.Error("error formatting %s: %v", f.Name(), err)
+				glog.Errorf("error formatting %s: %v", f.Name(), err)
 				continue
 			}
 		}
 
 		// Write copied file.
-		err = ioutil.WriteFile(path.Join(outPath, terraformResourceDirectory, f.Name()), b, 0644)
+		rel, err := filepath.Rel(*oPath, path.Join(outPath, terraformResourceDirectory, f.Name()))
 		if err != nil {
 			glog.Exit(err)
 		}
+		outputs.write(handwrittenInput, rel, b)
 	}
 }
@@ -43,13 +43,17 @@ var vFilter = flag.String("version", "", "optional version name. If specified, t
 
 var mode = flag.String("mode", "", "mode for the generator. If unset, creates the provider. Options: 'serialization'")
 
+var check = flag.Bool("check", false, "check that the output is up to date instead of writing it, exiting with a non-zero status if it isn't")
+var manifest = flag.String("manifest", "", "optional path to the generation manifest, outside of the output directory. If specified, generated files that are no longer generated are detected")
+var prune = flag.Bool("prune", true, "delete generated files that are no longer generated. If unset, they're reported instead")
+
 var terraformResourceDirectory = "google-beta"
 var terraformProviderModule = "github.com/hashicorp/terraform-provider-google-beta"
 
 func main() {
 	resources, products, err := loadAndModelResources()
 	if err != nil {
-		glog.Exitf("Error loading resources: %w", err)
+		glog.Exitf("Error loading resources: %v", err)
 	}
 
 	if mode != nil && *mode == "serialization" {
@@ -82,8 +86,15 @@ func main() {
 		terraformProviderModule = "internal/terraform-next"
 	}
 
+	if oPath != nil && *oPath != "" {
+		outputs = newOutputTree(*oPath, *manifest, *check, *prune, isFilteredRun())
+	}
+
 	generatedResources := make([]*Resource, 0, len(resourcesForVersion))
 	for _, resource := range resourcesForVersion {
+		if !isFilteredOut(resource) {
+			outputs.touch(resource.sourceFile)
+		}
 		if skipResource(resource) {
 			continue
 		}
@@ -103,6 +114,9 @@ func main() {
 		websiteVersion = BETA_VERSION
 	}
 	for _, resource := range resources[websiteVersion] {
+		if !isFilteredOut(resource) {
+			outputs.touch(resource.sourceFile)
+		}
 		if skipResource(resource) {
 			continue
 		}
@@ -120,20 +134,20 @@ func main() {
 
 	if cPath == nil || *cPath == "" {
 		glog.Info("No handwritten path specified")
-		return
+	} else {
+		copyHandwrittenFiles(*cPath, *oPath)
 	}
 
-	copyHandwrittenFiles(*cPath, *oPath)
+	outputs.finish()
 }
 
 func skipResource(r *Resource) bool {
-	// if a filter is specified, skip filtered services
-	if sFilter != nil && *sFilter != "" && DCLPackageName(*sFilter) != r.ProductMetadata().PackageName {
+	if isFilteredOut(r) {
 		return true
 	}
 
-	// skip filtered resources
-	if rFilter != nil && *rFilter != "" && SnakeCaseTerraformResourceName(*rFilter) != r.Name() {
+	// skip if already generated by mmv1
+	if r.SkipInProvider {
 		return true
 	}
 
@@ -141,11 +155,31 @@ func skipResource(r *Resource) bool {
 	return r.SerializationOnly
 }
 
+// isFilteredRun returns whether only some resources are generated.
+func isFilteredRun() bool {
+	return (sFilter != nil && *sFilter != "") || (rFilter != nil && *rFilter != "")
+}
+
+// isFilteredOut returns whether the resource is excluded by the service or
+// resource filters.
+func isFilteredOut(r *Resource) bool {
+	// if a filter is specified, skip filtered services
+	if sFilter != nil && *sFilter != "" && DCLPackageName(*sFilter) != r.ProductMetadata().PackageName {
+		return true
+	}
+
+	// skip filtered resources
+	return rFilter != nil && *rFilter != "" && SnakeCaseTerraformResourceName(*rFilter) != r.Name()
+}
+
 func loadAndModelResources() (map[Version][]*Resource, map[Version][]*ProductMetadata, error) {
 	flag.Parse()
 	if tPath == nil || *tPath == "" {
 		return nil, nil, errors.New("no path specified")
 	}
+	if *check && (oPath == nil || *oPath == "") {
+		return nil, nil, errors.New("--check requires --output")
+	}
 
 	dirs, err := ioutil.ReadDir(*tPath)
 	if err != nil {
@@ -202,7 +236,11 @@ func loadAndModelResources() (map[Version][]*Resource, map[Version][]*ProductMet
 					glog.Infof("Loaded overrides for %s", resourceFile.Name())
 				}
 
-				newResources = append(newResources, createResourcesFromDocumentAndOverrides(document, overrides, packagePath, version)...)
+				sourceFile := path.Join(string(packagePath), resourceFile.Name())
+				for _, res := range createResourcesFromDocumentAndOverrides(document, overrides, packagePath, version) {
+					res.sourceFile = sourceFile
+					newResources = append(newResources, res)
+				}
 			}
 
 			// if we found no resources, just keep going
@@ -391,10 +429,7 @@ func generateResourceFile(res *Resource) {
 		fmt.Printf("%v", string(formatted))
 	} else {
 		outname := fmt.Sprintf("resource_%s_%s.go", res.ProductName(), res.Name())
-		err := ioutil.WriteFile(path.Join(*oPath, terraformResourceDirectory, outname), formatted, 0644)
-		if err != nil {
-			glog.Exit(err)
-		}
+		outputs.write(res.sourceFile, path.Join(terraformResourceDirectory, outname), formatted)
 	}
 }
 
@@ -432,10 +467,7 @@ func generateSweeperFile(res *Resource) {
 		fmt.Printf("%v", string(formatted))
 	} else {
 		outname := fmt.Sprintf("resource_%s_%s_sweeper_test.go", res.ProductName(), res.Name())
-		err := ioutil.WriteFile(path.Join(*oPath, terraformResourceDirectory, outname), formatted, 0644)
-		if err != nil {
-			glog.Exit(err)
-		}
+		outputs.write(res.sourceFile, path.Join(terraformResourceDirectory, outname), formatted)
 	}
 }
 
@@ -474,10 +506,7 @@ func generateResourceTestFile(res *Resource) {
 		fmt.Printf("%v", string(formatted))
 	} else {
 		outname := fmt.Sprintf("resource_%s_%s_generated_test.go", res.ProductName(), res.Name())
-		err := ioutil.WriteFile(path.Join(*oPath, terraformResourceDirectory, outname), formatted, 0644)
-		if err != nil {
-			glog.Exit(err)
-		}
+		outputs.write(res.sourceFile, path.Join(terraformResourceDirectory, outname), formatted)
 	}
 }
 
@@ -501,8 +530,8 @@ func generateProviderResourcesFile(resources []*Resource) {
 
 	if oPath == nil || *oPath == "" {
 		fmt.Print(string(formatted))
-	} else if err = ioutil.WriteFile(path.Join(*oPath, terraformResourceDirectory, "provider_dcl_resources.go"), formatted, 0644); err != nil {
-		glog.Exit(err)
+	} else {
+		outputs.write(providerInput, path.Join(terraformResourceDirectory, "provider_dcl_resources.go"), formatted)
 	}
 }
 
@@ -533,15 +562,7 @@ func generateProductsFile(fileName string, products []*ProductMetadata) {
 		fmt.Print(string(formatted))
 	} else {
 		outname := fileName + ".go"
-
-		DCLFolderPath := path.Join(*oPath, terraformResourceDirectory, "transport")
-		if err := os.MkdirAll(DCLFolderPath, os.ModePerm); err != nil {
-			glog.Error(fmt.Errorf("error creating Terraform DCL directory %v: %v", DCLFolderPath, err))
-		}
-
-		if err = ioutil.WriteFile(path.Join(DCLFolderPath, outname), formatted, 0644); err != nil {
-			glog.Exit(err)
-		}
+		outputs.write(productsInput, path.Join(terraformResourceDirectory, "transport", outname), formatted)
 	}
 }
 
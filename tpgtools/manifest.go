@@ -0,0 +1,289 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/golang/glog"
+)
+
+// Inputs of generated files that aren't resources.
+const (
+	productsInput    = "<products>"
+	providerInput    = "<provider>"
+	handwrittenInput = "<handwritten>"
+)
+
+// GenerationManifest records the files generated from each input, keyed by the
+// path of the resource's overrides file, with their content hashes.
+type GenerationManifest struct {
+	Inputs map[string]*ManifestInput `json:"inputs"`
+}
+
+// ManifestInput is an input of the generator and the files generated from it.
+type ManifestInput struct {
+	// Outputs maps the paths of the generated files, relative to the output
+	// directory, to the hashes of their contents.
+	Outputs map[string]string `json:"outputs"`
+}
+
+// outputTree writes generated files to the output directory, only rewriting
+// files whose contents changed, and tracks them in the generation manifest.
+// Files generated by a previous run from an input processed in this run, but
+// not generated again, are orphans: they're pruned or reported. Orphans that
+// were changed since, such as files now generated by mmv1, are left alone.
+type outputTree struct {
+	root string
+	// manifest is the path of the generation manifest, kept out of the output
+	// directory. If unset, orphaned files aren't detected
+	manifest string
+	// check reports out of date files instead of writing them
+	check bool
+	// prune deletes orphaned files instead of reporting them
+	prune bool
+	// filtered is set when only some inputs are processed, so inputs missing
+	// from this run may still exist
+	filtered bool
+
+	previous *GenerationManifest
+	current  *GenerationManifest
+	// touched are the inputs processed in this run
+	touched map[string]bool
+
+	written   int
+	unchanged int
+	outOfDate []string
+}
+
+// outputs is the output tree of this run, or nil if generated files are
+// printed instead.
+var outputs *outputTree
+
+func newOutputTree(root, manifest string, check, prune, filtered bool) *outputTree {
+	t := &outputTree{
+		root:     root,
+		manifest: manifest,
+		check:    check,
+		prune:    prune,
+		filtered: filtered,
+		previous: &GenerationManifest{Inputs: make(map[string]*ManifestInput)},
+		current:  &GenerationManifest{Inputs: make(map[string]*ManifestInput)},
+		touched:  make(map[string]bool),
+	}
+
+	if manifest == "" {
+		glog.Infof("No generation manifest given, orphaned files won't be detected")
+		return t
+	}
+	b, err := ioutil.ReadFile(manifest)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			glog.Exit(err)
+		}
+		glog.Infof("No generation manifest found at %s, orphaned files won't be detected", manifest)
+		return t
+	}
+	if err := json.Unmarshal(b, t.previous); err != nil {
+		glog.Exitf("Error reading generation manifest %s: %v", manifest, err)
+	}
+	if t.previous.Inputs == nil {
+		t.previous.Inputs = make(map[string]*ManifestInput)
+	}
+	return t
+}
+
+func hashContents(contents ...[]byte) string {
+	h := sha256.New()
+	for _, c := range contents {
+		h.Write(c)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (t *outputTree) input(name string) *ManifestInput {
+	in, ok := t.current.Inputs[name]
+	if !ok {
+		in = &ManifestInput{Outputs: make(map[string]string)}
+		t.current.Inputs[name] = in
+	}
+	return in
+}
+
+// touch marks an input as processed in this run, whether or not it generates
+// any files.
+func (t *outputTree) touch(name string) {
+	if t == nil {
+		return
+	}
+	t.touched[name] = true
+	t.input(name)
+}
+
+// write writes contents to the file at relPath in the output directory,
+// generated from input, unless the file is up to date.
+func (t *outputTree) write(input, relPath string, contents []byte) {
+	t.touched[input] = true
+	t.input(input).Outputs[relPath] = hashContents(contents)
+
+	fullPath := path.Join(t.root, relPath)
+	existing, err := ioutil.ReadFile(fullPath)
+	if err == nil && bytes.Equal(existing, contents) {
+		t.unchanged++
+		return
+	}
+	if t.check {
+		t.outOfDate = append(t.outOfDate, relPath)
+		return
+	}
+
+	if err := os.MkdirAll(path.Dir(fullPath), 0755); err != nil {
+		glog.Exit(err)
+	}
+	if err := ioutil.WriteFile(fullPath, contents, 0644); err != nil {
+		glog.Exit(err)
+	}
+	t.written++
+}
+
+// orphans returns the files of the previous manifest that are no longer
+// generated, and still hold the contents they were generated with.
+func (t *outputTree) orphans() []string {
+	generated := make(map[string]bool)
+	for _, in := range t.current.Inputs {
+		for p := range in.Outputs {
+			generated[p] = true
+		}
+	}
+
+	var orphans []string
+	for name, in := range t.previous.Inputs {
+		if t.filtered && !t.touched[name] {
+			continue
+		}
+		for p, hash := range in.Outputs {
+			if generated[p] {
+				continue
+			}
+			existing, err := ioutil.ReadFile(path.Join(t.root, p))
+			if err != nil {
+				if !os.IsNotExist(err) {
+					glog.Exit(err)
+				}
+				continue
+			}
+			if hashContents(existing) != hash {
+				glog.Infof("Generated file %s was changed since it was generated, it's no longer tracked", p)
+				continue
+			}
+			orphans = append(orphans, p)
+		}
+	}
+	sort.Strings(orphans)
+	return orphans
+}
+
+// finish prunes or reports orphaned files and saves the manifest, if any. In check
+// mode, it exits with a non-zero status if the output tree is out of date.
+func (t *outputTree) finish() {
+	if t == nil {
+		return
+	}
+
+	// Inputs outside of a filtered run are kept as they were
+	if t.filtered {
+		for name, in := range t.previous.Inputs {
+			if !t.touched[name] {
+				t.current.Inputs[name] = in
+			}
+		}
+	}
+
+	orphans := t.orphans()
+	if t.check {
+		if err := t.checkResult(orphans); err != nil {
+			glog.Exit(err)
+		}
+		glog.Infof("%d generated files are up to date in %s", t.unchanged, t.root)
+		return
+	}
+
+	for _, p := range orphans {
+		if !t.prune {
+			glog.Warningf("Orphaned generated file %s", p)
+			continue
+		}
+		if err := os.Remove(path.Join(t.root, p)); err != nil && !os.IsNotExist(err) {
+			glog.Exit(err)
+		}
+		glog.Infof("Pruned orphaned generated file %s", p)
+	}
+	if !t.prune {
+		// Keep tracking orphans until they're pruned
+		for name, in := range t.previous.Inputs {
+			for p, hash := range in.Outputs {
+				if isOrphan(p, orphans) {
+					t.input(name).Outputs[p] = hash
+				}
+			}
+		}
+	}
+
+	glog.Infof("Wrote %d generated files, %d were up to date", t.written, t.unchanged)
+	if t.manifest == "" {
+		return
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(t.current); err != nil {
+		glog.Exit(err)
+	}
+	if err := os.MkdirAll(path.Dir(t.manifest), 0755); err != nil {
+		glog.Exit(err)
+	}
+	if err := ioutil.WriteFile(t.manifest, b.Bytes(), 0644); err != nil {
+		glog.Exit(err)
+	}
+}
+
+// checkResult prints the out of date and orphaned files of a check, and
+// returns an error if there are any.
+func (t *outputTree) checkResult(orphans []string) error {
+	for _, p := range t.outOfDate {
+		fmt.Printf("out of date: %s\n", p)
+	}
+	for _, p := range orphans {
+		fmt.Printf("orphaned: %s\n", p)
+	}
+	if len(t.outOfDate) > 0 || len(orphans) > 0 {
+		return fmt.Errorf("%d generated files are out of date and %d are orphaned in %s", len(t.outOfDate), len(orphans), t.root)
+	}
+	return nil
+}
+
+func isOrphan(p string, orphans []string) bool {
+	i := sort.SearchStrings(orphans, p)
+	return i < len(orphans) && orphans[i] == p
+}
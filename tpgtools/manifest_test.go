@@ -0,0 +1,276 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// newTestOutputTree returns an output tree in a new directory holding files,
+// generated by a previous run from the inputs of previous.
+func newTestOutputTree(t *testing.T, files map[string]string, previous map[string][]string, check, prune, filtered bool) *outputTree {
+	t.Helper()
+	root := t.TempDir()
+	for p, contents := range files {
+		writeTestFile(t, root, p, contents)
+	}
+
+	m := GenerationManifest{Inputs: make(map[string]*ManifestInput)}
+	for input, outputs := range previous {
+		in := &ManifestInput{Outputs: make(map[string]string)}
+		for _, p := range outputs {
+			in.Outputs[p] = hashContents([]byte("generated " + p))
+		}
+		m.Inputs[input] = in
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest := path.Join(t.TempDir(), "manifest.json")
+	if err := ioutil.WriteFile(manifest, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return newOutputTree(root, manifest, check, prune, filtered)
+}
+
+func writeTestFile(t *testing.T, root, p, contents string) {
+	t.Helper()
+	if err := os.MkdirAll(path.Dir(path.Join(root, p)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(root, p), []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testFileExists(t *testing.T, root, p string) bool {
+	t.Helper()
+	_, err := os.Stat(path.Join(root, p))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return err == nil
+}
+
+func readTestManifest(t *testing.T, manifest string) map[string][]string {
+	t.Helper()
+	b, err := ioutil.ReadFile(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m GenerationManifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	inputs := make(map[string][]string)
+	for name, in := range m.Inputs {
+		inputs[name] = []string{}
+		for p := range in.Outputs {
+			inputs[name] = append(inputs[name], p)
+		}
+	}
+	return inputs
+}
+
+// testOutputs are the files generated by a previous run of two resources.
+var testOutputs = map[string][]string{
+	"compute/foo.yaml": {"google/resource_compute_foo.go", "google/resource_compute_foo_sweeper_test.go", "website/docs/r/compute_foo.html.markdown"},
+	"compute/bar.yaml": {"google/resource_compute_bar.go"},
+}
+
+func testOutputFiles() map[string]string {
+	files := make(map[string]string)
+	for _, outputs := range testOutputs {
+		for _, p := range outputs {
+			files[p] = "generated " + p
+		}
+	}
+	return files
+}
+
+func TestOutputTreeOrphans(t *testing.T) {
+	files := testOutputFiles()
+	// Taken over by another generator after the previous run
+	files["website/docs/r/compute_foo.html.markdown"] = "mmv1 docs"
+	tree := newTestOutputTree(t, files, testOutputs, false, true, false)
+
+	// foo no longer generates its sweeper and docs, and bar was removed
+	tree.touch("compute/foo.yaml")
+	tree.write("compute/foo.yaml", "google/resource_compute_foo.go", []byte("generated google/resource_compute_foo.go"))
+	tree.touch("compute/baz.yaml")
+
+	expected := []string{"google/resource_compute_bar.go", "google/resource_compute_foo_sweeper_test.go"}
+	if got := tree.orphans(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected orphans %v, got %v", expected, got)
+	}
+
+	tree.finish()
+	for _, p := range expected {
+		if testFileExists(t, tree.root, p) {
+			t.Errorf("expected orphan %s to be pruned", p)
+		}
+	}
+	for _, p := range []string{"google/resource_compute_foo.go", "website/docs/r/compute_foo.html.markdown"} {
+		if !testFileExists(t, tree.root, p) {
+			t.Errorf("expected %s to be kept", p)
+		}
+	}
+	expectedManifest := map[string][]string{
+		"compute/foo.yaml": {"google/resource_compute_foo.go"},
+		"compute/baz.yaml": {},
+	}
+	if got := readTestManifest(t, tree.manifest); !reflect.DeepEqual(got, expectedManifest) {
+		t.Errorf("expected manifest %v, got %v", expectedManifest, got)
+	}
+	if tree.written != 0 || tree.unchanged != 1 {
+		t.Errorf("expected 1 unchanged file and none written, got %d unchanged and %d written", tree.unchanged, tree.written)
+	}
+}
+
+func TestOutputTreeOrphansNotPruned(t *testing.T) {
+	tree := newTestOutputTree(t, testOutputFiles(), testOutputs, false, false, false)
+
+	tree.touch("compute/foo.yaml")
+	tree.write("compute/foo.yaml", "google/resource_compute_foo.go", []byte("generated google/resource_compute_foo.go"))
+	tree.finish()
+
+	// Orphans are kept, and tracked until they're pruned
+	for _, outputs := range testOutputs {
+		for _, p := range outputs {
+			if !testFileExists(t, tree.root, p) {
+				t.Errorf("expected %s to be kept", p)
+			}
+		}
+	}
+	manifest := readTestManifest(t, tree.manifest)
+	if len(manifest["compute/foo.yaml"]) != 3 || len(manifest["compute/bar.yaml"]) != 1 {
+		t.Errorf("expected orphans to stay in the manifest, got %v", manifest)
+	}
+}
+
+func TestOutputTreeFilteredRun(t *testing.T) {
+	tree := newTestOutputTree(t, testOutputFiles(), testOutputs, false, true, true)
+
+	// Only foo is generated, and no longer generates its docs
+	tree.touch("compute/foo.yaml")
+	tree.write("compute/foo.yaml", "google/resource_compute_foo.go", []byte("generated google/resource_compute_foo.go"))
+	tree.write("compute/foo.yaml", "google/resource_compute_foo_sweeper_test.go", []byte("generated google/resource_compute_foo_sweeper_test.go"))
+
+	expected := []string{"website/docs/r/compute_foo.html.markdown"}
+	if got := tree.orphans(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected orphans %v, got %v", expected, got)
+	}
+
+	tree.finish()
+	if !testFileExists(t, tree.root, "google/resource_compute_bar.go") {
+		t.Errorf("expected the files of resources outside of the filter to be kept")
+	}
+	manifest := readTestManifest(t, tree.manifest)
+	if !reflect.DeepEqual(manifest["compute/bar.yaml"], testOutputs["compute/bar.yaml"]) {
+		t.Errorf("expected the inputs outside of the filter to stay in the manifest, got %v", manifest)
+	}
+	if len(manifest["compute/foo.yaml"]) != 2 {
+		t.Errorf("expected the pruned docs to be removed from the manifest, got %v", manifest)
+	}
+}
+
+func TestOutputTreeCheck(t *testing.T) {
+	cases := map[string]struct {
+		contents  map[string]string
+		outOfDate []string
+		orphans   []string
+	}{
+		"up to date": {
+			contents: map[string]string{
+				"google/resource_compute_foo.go":              "generated google/resource_compute_foo.go",
+				"google/resource_compute_foo_sweeper_test.go": "generated google/resource_compute_foo_sweeper_test.go",
+				"website/docs/r/compute_foo.html.markdown":    "generated website/docs/r/compute_foo.html.markdown",
+			},
+		},
+		"out of date": {
+			contents: map[string]string{
+				"google/resource_compute_foo.go":                "changed",
+				"google/resource_compute_foo_sweeper_test.go":   "generated google/resource_compute_foo_sweeper_test.go",
+				"website/docs/r/compute_foo.html.markdown":      "generated website/docs/r/compute_foo.html.markdown",
+				"google/resource_compute_foo_generated_test.go": "new",
+			},
+			outOfDate: []string{"google/resource_compute_foo.go", "google/resource_compute_foo_generated_test.go"},
+		},
+		"orphaned": {
+			contents: map[string]string{
+				"google/resource_compute_foo.go": "generated google/resource_compute_foo.go",
+			},
+			orphans: []string{"google/resource_compute_foo_sweeper_test.go", "website/docs/r/compute_foo.html.markdown"},
+		},
+	}
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			tree := newTestOutputTree(t, testOutputFiles(), testOutputs, true, true, true)
+			tree.touch("compute/foo.yaml")
+			for p, contents := range tc.contents {
+				tree.write("compute/foo.yaml", p, []byte(contents))
+			}
+
+			sort.Strings(tree.outOfDate)
+			if !reflect.DeepEqual(tree.outOfDate, tc.outOfDate) {
+				t.Errorf("expected out of date files %v, got %v", tc.outOfDate, tree.outOfDate)
+			}
+			orphans := tree.orphans()
+			if !reflect.DeepEqual(orphans, tc.orphans) {
+				t.Errorf("expected orphans %v, got %v", tc.orphans, orphans)
+			}
+			err := tree.checkResult(orphans)
+			if expectErr := tc.outOfDate != nil || tc.orphans != nil; (err != nil) != expectErr {
+				t.Errorf("expected an error to be %v, got %v", expectErr, err)
+			}
+
+			// Checks don't change the output directory
+			for _, p := range []string{"google/resource_compute_foo.go", "google/resource_compute_bar.go"} {
+				b, err := ioutil.ReadFile(path.Join(tree.root, p))
+				if err != nil || string(b) != "generated "+p {
+					t.Errorf("expected %s to be left as is, got %q (%v)", p, string(b), err)
+				}
+			}
+			if testFileExists(t, tree.root, "google/resource_compute_foo_generated_test.go") {
+				t.Errorf("expected new files not to be written")
+			}
+		})
+	}
+}
+
+func TestOutputTreeWithoutManifest(t *testing.T) {
+	root := t.TempDir()
+	tree := newOutputTree(root, "", false, true, false)
+	tree.touch("compute/foo.yaml")
+	tree.write("compute/foo.yaml", "google/resource_compute_foo.go", []byte("generated google/resource_compute_foo.go"))
+	tree.finish()
+
+	// Only the generated files are written to the output directory
+	entries, err := ioutil.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "google" {
+		t.Errorf("expected only the generated files in the output directory, got %v", entries)
+	}
+}
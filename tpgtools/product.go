@@ -93,7 +93,7 @@ func getProductTitle(documentTitle string, packagePath Filepath) string {
 
 	if ptOk {
 		if pt.Title == "" {
-			glog.Fatalf("error - product title override defined but got empty value for %", packagePath)
+			glog.Fatalf("error - product title override defined but got empty value for %s", packagePath)
 		}
 		title := pt.Title
 		return title
@@ -157,6 +157,11 @@ type Resource struct {
 	// and only be used for serialization
 	SerializationOnly bool
 
+	// sourceFile is the path of the overrides file the resource was generated
+	// from, relative to the overrides directory. It keys the resource's
+	// generated files in the generation manifest.
+	sourceFile string
+
 	// CustomSerializer defines the function this resource should use to serialize itself.
 	CustomSerializer *string
 
@@ -371,6 +376,31 @@ func (r Resource) SweeperFunctionArgs() string {
 	}
 }
 
+// SweeperCreateTimeField returns the name of the DCL field holding the creation
+// time of the resource, used to skip recently created resources when sweeping.
+// It returns an empty string if the resource has no such field.
+func (r Resource) SweeperCreateTimeField() string {
+	for _, p := range r.Properties {
+		if p.Name() == "create_time" && p.Type.String() == SchemaTypeString {
+			return p.PackageName
+		}
+	}
+	return ""
+}
+
+// SweeperLabelsField returns the name of the DCL field holding the labels of
+// the resource, used to find resources created by tests from their ownership
+// labels when sweeping. It returns an empty string if the resource has no
+// labels.
+func (r Resource) SweeperLabelsField() string {
+	for _, p := range r.Properties {
+		if p.Name() == "labels" && p.Type.String() == SchemaTypeMap {
+			return p.PackageName
+		}
+	}
+	return ""
+}
+
 // Returns the name of the ID function for the Terraform resource.
 func (r Resource) IDFunction() string {
 	for _, p := range r.Properties {